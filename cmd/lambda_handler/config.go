@@ -0,0 +1,557 @@
+package main
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/claims"
+	"cognito-lambda-handler/internal/cors"
+	"cognito-lambda-handler/internal/esdk"
+	"cognito-lambda-handler/internal/handlers"
+	"cognito-lambda-handler/internal/health"
+	"cognito-lambda-handler/internal/jwks"
+	"cognito-lambda-handler/internal/legacy"
+	"cognito-lambda-handler/internal/lockout"
+	"cognito-lambda-handler/internal/logging"
+	"cognito-lambda-handler/internal/mail"
+	"cognito-lambda-handler/internal/metrics"
+	"cognito-lambda-handler/internal/oauth"
+	"cognito-lambda-handler/internal/ratelimit"
+	"cognito-lambda-handler/internal/session"
+	"cognito-lambda-handler/internal/signuppolicy"
+	"cognito-lambda-handler/internal/sms"
+	"cognito-lambda-handler/internal/tracing"
+	"cognito-lambda-handler/internal/triggers"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	_ "github.com/lib/pq"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimitRules 認証系エンドポイントのレート制限
+// Lambdaではコンテナごとのメモリに保持されるため、共有が必要な場合はDynamoDB実装のStoreに差し替えます
+var rateLimitRules = map[string]ratelimit.Rule{
+	"/signin": {
+		ByIP:    ratelimit.Per(20, time.Minute),
+		ByEmail: ratelimit.Per(5, time.Minute),
+	},
+	"/forgot-password": {
+		ByIP:    ratelimit.Per(10, time.Minute),
+		ByEmail: ratelimit.Per(3, 15*time.Minute),
+	},
+	"/confirm": {
+		ByIP:    ratelimit.Per(20, time.Minute),
+		ByEmail: ratelimit.Per(5, time.Minute),
+	},
+	"/session/refresh": {
+		ByIP: ratelimit.Per(30, time.Minute),
+	},
+	"/credentials": {
+		ByIP: ratelimit.Per(30, time.Minute),
+	},
+	"/signin/new-password": {
+		ByIP:    ratelimit.Per(20, time.Minute),
+		ByEmail: ratelimit.Per(5, time.Minute),
+	},
+	"/signin/otp/start": {
+		ByIP:    ratelimit.Per(10, time.Minute),
+		ByEmail: ratelimit.Per(3, 15*time.Minute),
+	},
+	"/signin/otp/verify": {
+		ByIP:    ratelimit.Per(20, time.Minute),
+		ByEmail: ratelimit.Per(10, 15*time.Minute),
+	},
+}
+
+// newLogger 環境変数 LOG_LEVEL / LOG_PII_MODE からロガーを作成します
+func newLogger() (*slog.Logger, error) {
+	var level slog.Level
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+		}
+	}
+
+	mode := logging.PIIMask
+	if v := os.Getenv("LOG_PII_MODE"); v != "" {
+		mode = logging.PIIMode(v)
+		if mode != logging.PIIMask && mode != logging.PIIHash {
+			return nil, fmt.Errorf("invalid LOG_PII_MODE: %q", v)
+		}
+	}
+
+	return logging.New(os.Stdout, logging.Options{Level: level, PIIMode: mode}), nil
+}
+
+// newTracingConfig 環境変数 TRACE_EXPORTER / TRACE_FILE_PATH / OTEL_SERVICE_NAME からトレースの設定を読み込みます
+func newTracingConfig() tracing.Config {
+	cfg := tracing.Config{
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		Exporter:    tracing.Exporter(os.Getenv("TRACE_EXPORTER")),
+		FilePath:    os.Getenv("TRACE_FILE_PATH"),
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "cognito-lambda-handler"
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = tracing.ExporterNone
+	}
+	if cfg.FilePath == "" {
+		cfg.FilePath = "/tmp/traces.jsonl"
+	}
+	return cfg
+}
+
+// newAuditLogger 環境変数 AUDIT_SINK（stdout / file）と AUDIT_FILE_PATH から監査ログの出力先を設定します
+func newAuditLogger() (*audit.Logger, error) {
+	switch sink := os.Getenv("AUDIT_SINK"); sink {
+	case "", "stdout":
+		return audit.NewLogger(audit.NewStdoutSink(), audit.Head{}), nil
+	case "file":
+		path := os.Getenv("AUDIT_FILE_PATH")
+		if path == "" {
+			return nil, fmt.Errorf("AUDIT_FILE_PATH is not set")
+		}
+		fileSink, err := audit.OpenFileSink(path)
+		if err != nil {
+			return nil, err
+		}
+		return audit.NewLogger(fileSink, fileSink.Head()), nil
+	default:
+		return nil, fmt.Errorf("invalid AUDIT_SINK: %q", sink)
+	}
+}
+
+// newReadinessChecker /readyzで確認する依存関係を登録します
+// JWT_VERIFICATION_ENABLED=true の場合はJWKS（JWKS_URL、未指定時はユーザープールから導出）の取得も確認します
+func newReadinessChecker(poolId string) (*health.Checker, error) {
+	checks := []health.Check{
+		{Name: "config", Run: func(context.Context) error { return cognitoService.ValidateConfig() }},
+		{Name: "cognito", Run: cognitoService.DescribeUserPoolClient},
+	}
+
+	enabled, err := envBool("JWT_VERIFICATION_ENABLED")
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		url := os.Getenv("JWKS_URL")
+		if url == "" {
+			if url, err = jwks.URL(poolId); err != nil {
+				return nil, err
+			}
+		}
+		checks = append(checks, health.Check{Name: "jwks", Run: func(ctx context.Context) error {
+			_, err := jwks.Fetch(ctx, nil, url)
+			return err
+		}})
+	}
+
+	return health.NewChecker(3*time.Second, checks...), nil
+}
+
+// envBool は真偽値の環境変数を読み込みます。未設定の場合はfalseを返します
+func envBool(key string) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// newMetricsRecorder EMF形式で標準出力にメトリクスを書き込むRecorderを作成します
+// 名前空間は METRICS_NAMESPACE で変更できます
+// テナントのディメンションには METRICS_TENANTS（カンマ区切り）に含まれる値のみ記録します
+func newMetricsRecorder(clientId string) *metrics.Recorder {
+	namespace := os.Getenv("METRICS_NAMESPACE")
+	if namespace == "" {
+		namespace = "CognitoLambdaHandler"
+	}
+	recorder := metrics.NewRecorder(metrics.NewEMFSink(os.Stdout), namespace, metrics.Dimensions{"AppClient": clientId})
+	recorder.AllowTenants(splitList(os.Getenv("METRICS_TENANTS"))...)
+	return recorder
+}
+
+// newLockoutTracker 環境変数からサインイン失敗時のロックアウト設定を読み込みます
+func newLockoutTracker() (*lockout.Tracker, error) {
+	threshold := 10
+	if v := os.Getenv("LOCKOUT_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LOCKOUT_THRESHOLD: %w", err)
+		}
+		threshold = n
+	}
+
+	action := lockout.ActionBackoff
+	if v := os.Getenv("LOCKOUT_ACTION"); v != "" {
+		action = lockout.Action(v)
+	}
+
+	duration := 30 * time.Minute
+	if v := os.Getenv("LOCKOUT_DURATION_MINUTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LOCKOUT_DURATION_MINUTES: %w", err)
+		}
+		duration = time.Duration(n) * time.Minute
+	}
+
+	cfg := lockout.Config{
+		Store: lockout.NewMemoryStore(),
+		Policy: lockout.Policy{
+			BaseDelay:    time.Second,
+			MaxDelay:     15 * time.Minute,
+			Threshold:    threshold,
+			Action:       action,
+			LockDuration: duration,
+		},
+		Disabler: cognitoService,
+	}
+	if action == lockout.ActionDisable {
+		// 失敗状況はコンテナごとのMemoryStoreにあるため、コールドスタート後は期限が過ぎても自動では有効化されません
+		slog.Warn("Lockout state is kept per container; users disabled before a cold start are not enabled automatically")
+	}
+	if action == lockout.ActionCaptcha {
+		cfg.Captcha = &lockout.SiteVerifyCaptcha{
+			URL:    os.Getenv("CAPTCHA_VERIFY_URL"),
+			Secret: os.Getenv("CAPTCHA_SECRET"),
+		}
+	}
+	return lockout.NewTracker(cfg)
+}
+
+// newHandlerOptions 環境変数からハンドラーの設定を読み込みます
+func newHandlerOptions(clientId, clientSecret, poolId string) (handlers.Options, error) {
+	var opts handlers.Options
+	enabled, err := envBool("ENUMERATION_SAFE_RESPONSES")
+	if err != nil {
+		return opts, err
+	}
+	opts.EnumerationSafe = enabled
+
+	opts.MinResponseTime = time.Second
+	if v := os.Getenv("MIN_RESPONSE_TIME_MS"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid MIN_RESPONSE_TIME_MS: %w", err)
+		}
+		opts.MinResponseTime = time.Duration(ms) * time.Millisecond
+	}
+
+	opts.Session, err = newSessionManager()
+	if err != nil {
+		return opts, err
+	}
+
+	opts.OAuth, err = newOAuthFlow(clientId, clientSecret)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.SignUpPolicy, err = newSignUpPolicy()
+	if err != nil {
+		return opts, err
+	}
+
+	opts.TokenVerifier, err = newTokenVerifier(poolId, clientId)
+	if err != nil {
+		return opts, err
+	}
+	opts.AdminGroup = os.Getenv("ADMIN_GROUP")
+	return opts, nil
+}
+
+// newTokenVerifier JWT_VERIFICATION_ENABLED=true の場合に、アクセストークンの署名を検証するVerifierを作成します
+// 管理APIは検証が有効な場合のみ使用できます。JWKSの取得先は JWKS_URL で変更できます
+func newTokenVerifier(poolId, clientId string) (*jwks.Verifier, error) {
+	enabled, err := envBool("JWT_VERIFICATION_ENABLED")
+	if err != nil || !enabled {
+		return nil, err
+	}
+	v, err := jwks.NewVerifier(poolId, clientId)
+	if err != nil {
+		return nil, err
+	}
+	if url := os.Getenv("JWKS_URL"); url != "" {
+		v.URL = url
+	}
+	return v, nil
+}
+
+// newSignUpPolicy 環境変数 SIGNUP_POLICY_FILE（JSON）が設定されている場合に、サインアップできるメールアドレスを制限します
+// 同じ設定をAPIのサインアップとPreSignUpトリガーの両方に適用します
+func newSignUpPolicy() (*signuppolicy.Policy, error) {
+	path := os.Getenv("SIGNUP_POLICY_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	return signuppolicy.Load(path)
+}
+
+// newOAuthFlow 環境変数 OAUTH_DOMAIN（ホストUIのドメイン）が設定されている場合に認可コードフローを有効にします
+// トークンエンドポイントは OAUTH_TOKEN_URL でローカルのスタブなどに差し替えられます
+func newOAuthFlow(clientId, clientSecret string) (*oauth.Flow, error) {
+	domain := strings.TrimSuffix(os.Getenv("OAUTH_DOMAIN"), "/")
+	if domain == "" {
+		return nil, nil
+	}
+
+	cfg := oauth.Config{
+		AuthorizeURL:      domain + "/oauth2/authorize",
+		TokenURL:          domain + "/oauth2/token",
+		ClientID:          clientId,
+		RedirectURI:       os.Getenv("OAUTH_REDIRECT_URI"),
+		Scopes:            strings.Fields(os.Getenv("OAUTH_SCOPES")),
+		StateKey:          []byte(clientSecret),
+		CookiePath:        os.Getenv("OAUTH_COOKIE_PATH"),
+		PostLoginRedirect: os.Getenv("OAUTH_POST_LOGIN_REDIRECT"),
+	}
+	if v := os.Getenv("OAUTH_TOKEN_URL"); v != "" {
+		cfg.TokenURL = v
+	}
+	return oauth.NewFlow(cfg)
+}
+
+// newSessionManager 環境変数 SESSION_MODE=cookie の場合にCookieセッションの設定を読み込みます
+func newSessionManager() (*session.Manager, error) {
+	switch mode := os.Getenv("SESSION_MODE"); mode {
+	case "", "token":
+		return nil, nil
+	case "cookie":
+	default:
+		return nil, fmt.Errorf("invalid SESSION_MODE: %q", mode)
+	}
+
+	cfg := session.Config{
+		RefreshPath: os.Getenv("SESSION_COOKIE_PATH"),
+		Domain:      os.Getenv("SESSION_COOKIE_DOMAIN"),
+	}
+	switch v := os.Getenv("SESSION_ACCESS_TOKEN"); v {
+	case "", "body":
+	case "cookie":
+		cfg.AccessTokenInCookie = true
+	default:
+		return nil, fmt.Errorf("invalid SESSION_ACCESS_TOKEN: %q", v)
+	}
+	if v := os.Getenv("SESSION_REFRESH_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SESSION_REFRESH_DAYS: %w", err)
+		}
+		cfg.RefreshMaxAge = time.Duration(days) * 24 * time.Hour
+	}
+	return session.NewManager(cfg), nil
+}
+
+// newCORSPolicy 環境変数 CORS_ALLOWED_ORIGINS（カンマ区切り）からCORSの設定を読み込みます
+// 未設定の場合はCORSを無効にしてnilを返します
+func newCORSPolicy() (*cors.Policy, error) {
+	origins := splitList(os.Getenv("CORS_ALLOWED_ORIGINS"))
+	if len(origins) == 0 {
+		return nil, nil
+	}
+
+	credentials, err := envBool("CORS_ALLOW_CREDENTIALS")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := cors.Config{
+		AllowedOrigins:   origins,
+		AllowCredentials: credentials,
+		AllowedHeaders:   []string{"Content-Type", "Authorization", lockout.CaptchaHeader, metrics.TenantHeader, session.CSRFHeader},
+		ExposedHeaders:   []string{"Retry-After"},
+		MaxAge:           10 * time.Minute,
+	}
+	if v := os.Getenv("CORS_ALLOWED_HEADERS"); v != "" {
+		cfg.AllowedHeaders = splitList(v)
+	}
+	if v := os.Getenv("CORS_MAX_AGE_SECONDS"); v != "" {
+		sec, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CORS_MAX_AGE_SECONDS: %w", err)
+		}
+		cfg.MaxAge = time.Duration(sec) * time.Second
+	}
+	return cors.New(cfg)
+}
+
+// splitList はカンマ区切りの値を空要素を除いて分割します
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// newTriggerDispatcher 環境変数からCognitoトリガーのハンドラーを設定します
+// ハンドラーを設定しないサインアップ・認証前後・トークン生成前のトリガーは、イベントを変更せずに返します
+// signUpPolicy が設定されている場合、PreSignUpトリガーでAPIのサインアップと同じ条件を適用します
+// TOKEN_CLAIMS_FILE が設定されている場合、トークン生成前トリガーでファイルに定義したクレームを追加します
+// CUSTOM_MESSAGE_APP_NAME が設定されている場合、確認コードなどのメールを埋め込みのテンプレートで作成します
+// LEGACY_USERS_FILE または LEGACY_DB_DSN が設定されている場合、ユーザー移行トリガーを有効にします
+// OTP_MAIL_FROM が設定されている場合、メールの確認コードによるパスワードレスサインインのトリガーを有効にします
+// CUSTOM_SENDER_KMS_KEY_ARN または CUSTOM_SENDER_RAW_KEY が設定されている場合、カスタムEメール・SMS送信者トリガーを有効にします
+func newTriggerDispatcher(signUpPolicy *signuppolicy.Policy, optFns ...func(*config.LoadOptions) error) (*triggers.Dispatcher, error) {
+	d := &triggers.Dispatcher{}
+	if signUpPolicy != nil {
+		d.PreSignUp = &triggers.SignUpPolicy{Policy: signUpPolicy}
+	}
+
+	if path := os.Getenv("TOKEN_CLAIMS_FILE"); path != "" {
+		source, err := claims.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		tokenClaims := &triggers.TokenClaims{Source: source}
+		d.PreTokenGen = tokenClaims
+		d.PreTokenGenV2 = tokenClaims
+	}
+
+	authenticator, err := newLegacyAuthenticator()
+	if err != nil {
+		return nil, err
+	}
+	if authenticator != nil {
+		d.UserMigration = &triggers.UserMigration{Authenticator: authenticator}
+	}
+
+	if appName := os.Getenv("CUSTOM_MESSAGE_APP_NAME"); appName != "" {
+		customMessage, err := triggers.NewCustomMessage(appName, os.Getenv("CUSTOM_MESSAGE_DEFAULT_LOCALE"))
+		if err != nil {
+			return nil, err
+		}
+		d.CustomMessage = customMessage
+	}
+
+	customSender, err := newCustomSender(optFns...)
+	if err != nil {
+		return nil, err
+	}
+	if customSender != nil {
+		if customSender.Email != nil {
+			d.CustomEmailSender = customSender
+		}
+		if customSender.SMS != nil {
+			d.CustomSMSSender = customSender
+		}
+	}
+
+	if from := os.Getenv("OTP_MAIL_FROM"); from != "" {
+		cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SDK config: %w", err)
+		}
+		otp := &triggers.OTP{Sender: mail.NewSESSender(cfg, from)}
+		if v := os.Getenv("OTP_CODE_LENGTH"); v != "" {
+			if otp.CodeLength, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("invalid OTP_CODE_LENGTH: %w", err)
+			}
+		}
+		if v := os.Getenv("OTP_MAX_ATTEMPTS"); v != "" {
+			if otp.MaxAttempts, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("invalid OTP_MAX_ATTEMPTS: %w", err)
+			}
+		}
+		d.OTP = otp
+	}
+	return d, nil
+}
+
+// customSenderRawKeyNamespace と customSenderRawKeyName は CUSTOM_SENDER_RAW_KEY で暗号化したデータキーのプロバイダー
+const (
+	customSenderRawKeyNamespace = "cognito-lambda-handler"
+	customSenderRawKeyName      = "custom-sender"
+)
+
+// newCustomSender カスタム送信者トリガーで使うキーと送信先を設定します
+// CUSTOM_SENDER_KMS_KEY_ARN はユーザープールに設定したKMSキー、CUSTOM_SENDER_RAW_KEY は開発用のAESキー（base64）です
+// メールは CUSTOM_EMAIL_SENDER（ses、smtp、file）、SMSは CUSTOM_SMS_SENDER（sns、file）で送信先を選びます
+func newCustomSender(optFns ...func(*config.LoadOptions) error) (*triggers.CustomSender, error) {
+	var keys esdk.KeyProvider
+	if arn := os.Getenv("CUSTOM_SENDER_KMS_KEY_ARN"); arn != "" {
+		cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SDK config: %w", err)
+		}
+		keys = &esdk.KMSProvider{Client: kms.NewFromConfig(cfg), KeyIDs: []string{arn}}
+	} else if v := os.Getenv("CUSTOM_SENDER_RAW_KEY"); v != "" {
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CUSTOM_SENDER_RAW_KEY: %w", err)
+		}
+		keys = &esdk.RawAESProvider{Namespace: customSenderRawKeyNamespace, Name: customSenderRawKeyName, Key: key}
+	} else {
+		return nil, nil
+	}
+
+	messages, err := triggers.NewCustomMessage(os.Getenv("CUSTOM_MESSAGE_APP_NAME"), os.Getenv("CUSTOM_MESSAGE_DEFAULT_LOCALE"))
+	if err != nil {
+		return nil, err
+	}
+	s := &triggers.CustomSender{Keys: keys, Messages: messages}
+
+	switch v := os.Getenv("CUSTOM_EMAIL_SENDER"); v {
+	case "":
+	case "ses":
+		cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SDK config: %w", err)
+		}
+		s.Email = mail.NewSESSender(cfg, os.Getenv("CUSTOM_EMAIL_FROM"))
+	case "smtp":
+		if s.Email, err = mail.NewSMTPSender(os.Getenv("SMTP_ADDR"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("CUSTOM_EMAIL_FROM")); err != nil {
+			return nil, err
+		}
+	case "file":
+		s.Email = &mail.FileSender{Path: os.Getenv("CUSTOM_EMAIL_FILE")}
+	default:
+		return nil, fmt.Errorf("invalid CUSTOM_EMAIL_SENDER %q", v)
+	}
+
+	switch v := os.Getenv("CUSTOM_SMS_SENDER"); v {
+	case "":
+	case "sns":
+		cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SDK config: %w", err)
+		}
+		s.SMS = sms.NewSNSSender(cfg)
+	case "file":
+		s.SMS = &sms.FileSender{Path: os.Getenv("CUSTOM_SMS_FILE")}
+	default:
+		return nil, fmt.Errorf("invalid CUSTOM_SMS_SENDER %q", v)
+	}
+	return s, nil
+}
+
+// newLegacyAuthenticator ユーザー移行で使う移行元のユーザーストアを設定します
+// LEGACY_USERS_FILE はJSONファイル、LEGACY_DB_DSN はPostgreSQLの接続文字列で、クエリは LEGACY_DB_QUERY で変更できます
+func newLegacyAuthenticator() (legacy.Authenticator, error) {
+	if path := os.Getenv("LEGACY_USERS_FILE"); path != "" {
+		return legacy.LoadFile(path)
+	}
+	dsn := os.Getenv("LEGACY_DB_DSN")
+	if dsn == "" {
+		return nil, nil
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open legacy database: %w", err)
+	}
+	return &legacy.SQLAuthenticator{DB: db, Query: os.Getenv("LEGACY_DB_QUERY")}, nil
+}
@@ -1,41 +1,93 @@
 package main
 
 import (
+	"cognito-lambda-handler/internal/audit"
 	"cognito-lambda-handler/internal/cognito"
+	"cognito-lambda-handler/internal/cors"
+	"cognito-lambda-handler/internal/handlers"
+	"cognito-lambda-handler/internal/health"
+	"cognito-lambda-handler/internal/identity"
+	"cognito-lambda-handler/internal/lockout"
+	"cognito-lambda-handler/internal/logging"
+	"cognito-lambda-handler/internal/metrics"
+	"cognito-lambda-handler/internal/ratelimit"
+	"cognito-lambda-handler/internal/requestinfo"
+	"cognito-lambda-handler/internal/tracing"
+	"cognito-lambda-handler/internal/triggers"
 	"cognito-lambda-handler/routes"
 	"context"
+	"encoding/json"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
-	"log"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
 )
 
-var cognitoService *cognito.Service
+var (
+	cognitoService  *cognito.Service
+	metricsRecorder *metrics.Recorder
+	auditLogger     *audit.Logger
+	tracerProvider  *tracing.Provider
+	tracingConfig   tracing.Config
+	rateLimiter     *ratelimit.Limiter
+	lockoutTracker  *lockout.Tracker
+	handlerOptions  handlers.Options
+	readiness       *health.Checker
+	corsPolicy      *cors.Policy
+	identityService *identity.Service
+	dispatcher      *triggers.Dispatcher
+	// router はウォームスタートの呼び出し間で共有するため、初期化時に一度だけ作成します
+	router *mux.Router
+)
 
 // ResponseWriter APIGatewayProxyResponse用のカスタムResponseWriter
 type ResponseWriter struct {
-	StatusCode int
-	Headers    map[string]string
-	Body       string
+	StatusCode        int
+	Headers           map[string]string
+	MultiValueHeaders map[string][]string
+	Body              string
+	header            http.Header
 }
 
 // Header ヘッダーのマップを返します
 func (rw *ResponseWriter) Header() http.Header {
-	return http.Header{}
+	if rw.header == nil {
+		rw.header = http.Header{}
+	}
+	return rw.header
 }
 
 // Write メソッドは、レスポンスボディを記録します
 func (rw *ResponseWriter) Write(b []byte) (int, error) {
-	rw.Body = string(b)
+	if rw.StatusCode == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.Body += string(b)
 	return len(b), nil
 }
 
 // WriteHeader メソッドは、ステータスコードを設定します
 func (rw *ResponseWriter) WriteHeader(statusCode int) {
+	if rw.StatusCode != 0 {
+		return
+	}
 	rw.StatusCode = statusCode
+	for k, v := range rw.Header() {
+		// Set-Cookieのようにカンマで結合できないヘッダーがあるため、複数値はMultiValueHeadersで返す
+		if len(v) == 1 {
+			rw.Headers[k] = v[0]
+		} else {
+			rw.MultiValueHeaders[k] = v
+		}
+	}
 }
 
 // NewRequest APIGatewayリクエストをHTTPリクエストに変換
@@ -45,64 +97,180 @@ func NewRequest(req events.APIGatewayProxyRequest) *http.Request {
 	for k, v := range req.Headers {
 		httpReq.Header.Set(k, v)
 	}
+	httpReq.RemoteAddr = req.RequestContext.Identity.SourceIP
 	return httpReq
 }
 
-func Handler(_ context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	r := routes.RegisterRoutes(cognitoService)
+// registerRoutes 初期化済みの依存関係でルーターを作成します
+func registerRoutes() *mux.Router {
+	return routes.RegisterRoutes(cognitoService,
+		routes.WithMiddleware(
+			otelmux.Middleware(tracingConfig.ServiceName),
+			logging.Middleware,
+			auditLogger.Middleware,
+			metricsRecorder.Middleware,
+			rateLimiter.Middleware,
+			lockoutTracker.Middleware,
+		),
+		routes.WithHandlerOptions(handlerOptions),
+		routes.WithReadinessChecker(readiness),
+		routes.WithCORS(corsPolicy),
+		routes.WithIdentityService(identityService),
+	)
+}
 
-	httpReq := NewRequest(req)
+func Handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	info := &requestinfo.Info{APIRequestID: req.RequestContext.RequestID}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		info.LambdaRequestID = lc.AwsRequestID
+	}
+	httpReq := NewRequest(req).WithContext(requestinfo.NewContext(ctx, info))
+	if traceID, ok := ctx.Value("x-amzn-trace-id").(string); ok && httpReq.Header.Get("X-Amzn-Trace-Id") == "" {
+		// API Gatewayがヘッダーを渡さなかった場合はLambdaのX-Rayトレースを親にする
+		httpReq.Header.Set("X-Amzn-Trace-Id", traceID)
+	}
+
+	rw := &ResponseWriter{Headers: map[string]string{}, MultiValueHeaders: map[string][]string{}}
+	router.ServeHTTP(rw, httpReq)
 
-	rw := &ResponseWriter{Headers: map[string]string{}}
-	r.ServeHTTP(rw, httpReq)
+	// Lambdaは呼び出し間でフリーズされるため、バッチ中のスパンをここで送信する
+	if err := tracerProvider.ForceFlush(ctx); err != nil {
+		slog.WarnContext(ctx, "Failed to flush traces", "error", err)
+	}
 
 	return events.APIGatewayProxyResponse{
-		StatusCode: rw.StatusCode,
-		Headers:    rw.Headers,
-		Body:       rw.Body,
+		StatusCode:        rw.StatusCode,
+		Headers:           rw.Headers,
+		MultiValueHeaders: rw.MultiValueHeaders,
+		Body:              rw.Body,
 	}, nil
 }
 
+// Invoke はペイロードのtriggerSourceでCognitoのトリガーとAPI Gatewayのリクエストを振り分けます
+// 同じイメージをAPI Gatewayとユーザープールのトリガーの両方に設定できます
+func Invoke(ctx context.Context, payload json.RawMessage) (any, error) {
+	if source := triggers.Source(payload); source != "" {
+		resp, err := dispatcher.Dispatch(ctx, source, payload)
+		if err != nil {
+			slog.ErrorContext(ctx, "Cognito trigger failed", "trigger_source", source, "error", err)
+		}
+		// HTTPのリクエストと同じく、呼び出しの最後に集計中のメトリクスを出力する
+		metricsRecorder.Flush()
+		return resp, err
+	}
+
+	var req events.APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, err
+	}
+	return Handler(ctx, req)
+}
+
+// fatal エラーログを出力してプロセスを終了します
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func init() {
 	var err error
 	if _, exists := os.LookupEnv("AWS_LAMBDA_FUNCTION_NAME"); !exists {
 		// ローカル環境でのみ .env をロード
 		err := godotenv.Load()
 		if err != nil {
-			log.Fatalf("Error loading .env file: %v", err)
+			fatal("Error loading .env file", "error", err)
 		}
 	}
 
+	logger, err := newLogger()
+	if err != nil {
+		fatal("Failed to initialize logger", "error", err)
+	}
+	slog.SetDefault(logger)
+
 	clientId := os.Getenv("AWS_COGNITO_CLIENT_ID")
 	if clientId == "" {
-		log.Fatal("AWS_COGNITO_CLIENT_ID is not set")
+		fatal("AWS_COGNITO_CLIENT_ID is not set")
 	}
 
 	clientSecret := os.Getenv("AWS_COGNITO_CLIENT_SECRET")
 	if clientSecret == "" {
-		log.Fatal("AWS_COGNITO_CLIENT_SECRET is not set")
+		fatal("AWS_COGNITO_CLIENT_SECRET is not set")
 	}
 
 	poolId := os.Getenv("AWS_COGNITO_POOL_ID")
 	if poolId == "" {
-		log.Fatal("AWS_COGNITO_POOL_ID is not set")
+		fatal("AWS_COGNITO_POOL_ID is not set")
 	}
 
-	cognitoService, err = cognito.NewCognitoService(clientId, clientSecret, poolId)
+	tracingConfig = newTracingConfig()
+	tracerProvider, err = tracing.Setup(context.Background(), tracingConfig)
 	if err != nil {
-		log.Fatalf("Failed to initialize Cognito service: %v", err)
+		fatal("Failed to initialize tracing", "error", err)
 	}
+
+	metricsRecorder = newMetricsRecorder(clientId)
+
+	auditLogger, err = newAuditLogger()
+	if err != nil {
+		fatal("Failed to initialize audit logger", "error", err)
+	}
+
+	sdkOptions := []func(*config.LoadOptions) error{tracing.WithAWSMiddleware()}
+	// CognitoLatencyはユーザープールのAPIのみ計測する
+	cognitoService, err = cognito.NewCognitoService(clientId, clientSecret, poolId, append([]func(*config.LoadOptions) error{
+		config.WithAPIOptions([]func(*middleware.Stack) error{metricsRecorder.CognitoLatencyMiddleware}),
+	}, sdkOptions...)...)
+	if err != nil {
+		fatal("Failed to initialize Cognito service", "error", err)
+	}
+
+	// IDプールは任意。設定されている場合のみ/credentialsを有効にする
+	if identityPoolId := os.Getenv("IDENTITY_POOL_ID"); identityPoolId != "" {
+		identityService, err = identity.NewIdentityService(identityPoolId, poolId, sdkOptions...)
+		if err != nil {
+			fatal("Failed to initialize identity pool service", "error", err)
+		}
+	}
+
+	rateLimiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rateLimitRules)
+
+	lockoutTracker, err = newLockoutTracker()
+	if err != nil {
+		fatal("Failed to initialize lockout tracker", "error", err)
+	}
+
+	handlerOptions, err = newHandlerOptions(clientId, clientSecret, poolId)
+	if err != nil {
+		fatal("Failed to load handler options", "error", err)
+	}
+
+	readiness, err = newReadinessChecker(poolId)
+	if err != nil {
+		fatal("Failed to initialize readiness checks", "error", err)
+	}
+
+	corsPolicy, err = newCORSPolicy()
+	if err != nil {
+		fatal("Failed to load CORS settings", "error", err)
+	}
+
+	dispatcher, err = newTriggerDispatcher(handlerOptions.SignUpPolicy, sdkOptions...)
+	if err != nil {
+		fatal("Failed to initialize Cognito triggers", "error", err)
+	}
+
+	router = registerRoutes()
 }
 
 func main() {
 	if _, isLambda := os.LookupEnv("AWS_LAMBDA_FUNCTION_NAME"); isLambda {
 		// AWS Lambda環境
-		lambda.Start(Handler)
+		lambda.Start(Invoke)
 
 	} else {
 		// ローカル環境
-		r := routes.RegisterRoutes(cognitoService)
-		log.Println("Starting local server on :8080")
-		log.Fatal(http.ListenAndServe(":8080", r))
+		slog.Info("Starting local server on :8080")
+		fatal("Local server stopped", "error", http.ListenAndServe(":8080", router))
 	}
 }
@@ -0,0 +1,254 @@
+// user_transfer はユーザープールのユーザーをファイルにエクスポートし、別のユーザープールにインポートするコマンドです
+//
+//	user_transfer export -out users.jsonl
+//	user_transfer import -in users.jsonl -mode api -dry-run
+//	user_transfer import -in users.jsonl -mode job -job-out import.csv
+package main
+
+import (
+	"cognito-lambda-handler/internal/cognito"
+	"cognito-lambda-handler/internal/usertransfer"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage:
+  user_transfer export [flags]  ユーザーをCSVまたはJSONLにエクスポートします
+  user_transfer import [flags]  エクスポートしたユーザーをインポートします
+
+各コマンドのフラグは "user_transfer <command> -h" で確認できます。
+ユーザープールは -pool または環境変数 AWS_COGNITO_POOL_ID で指定します。
+`
+
+func main() {
+	// .env は任意。環境変数やAWSのプロファイルだけでも実行できます
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Ctrl+Cで中断した場合もチェックポイントから再開できるよう、処理中の1件を終えてから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	case "import":
+		err = runImport(ctx, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("Failed to transfer users", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+// newService はpoolIdのユーザープールを操作するCognitoサービスを作成します
+// 管理APIのみ使用するため、アプリクライアントは不要です
+func newService(poolId string) (*cognito.Service, error) {
+	if poolId == "" {
+		return nil, errors.New("user pool is not specified (-pool or AWS_COGNITO_POOL_ID)")
+	}
+	return cognito.NewCognitoService("", "", poolId)
+}
+
+// attributeColumns はエクスポートするCSVの属性の列をユーザープールの列から返します
+func attributeColumns(ctx context.Context, service *cognito.Service) ([]string, error) {
+	header, err := service.GetCSVHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	columns := []string{"sub"}
+	for _, name := range header {
+		if name != "sub" && !strings.HasPrefix(name, "cognito:") {
+			columns = append(columns, name)
+		}
+	}
+	return columns, nil
+}
+
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	pool := fs.String("pool", os.Getenv("AWS_COGNITO_POOL_ID"), "エクスポート元のユーザープールID")
+	out := fs.String("out", "users.jsonl", "出力ファイル")
+	format := fs.String("format", "", "出力形式（csv、jsonl）。省略時は出力ファイルの拡張子から判断します")
+	filter := fs.String("filter", "", `ListUsersのフィルター式（例: status = "CONFIRMED"）`)
+	groups := fs.Bool("groups", true, "ユーザーが所属するグループもエクスポートします")
+	checkpoint := fs.String("checkpoint", "", "チェックポイントのファイル。省略時は <out>.checkpoint、空文字で無効")
+	fs.Parse(args)
+
+	service, err := newService(*pool)
+	if err != nil {
+		return err
+	}
+	f := usertransfer.Format(*format)
+	if f == "" {
+		f = usertransfer.FormatFromPath(*out)
+	}
+	cp, err := usertransfer.LoadCheckpoint(checkpointPath(fs, *checkpoint, *out))
+	if err != nil {
+		return err
+	}
+
+	var columns []string
+	if f == usertransfer.FormatCSV {
+		if columns, err = attributeColumns(ctx, service); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open output: %w", err)
+	}
+	defer file.Close()
+	// 再開する場合は最後に保存したページの後ろから追記する
+	if cp.Resumed() {
+		slog.Info("Resuming export", "processed", cp.Processed)
+	}
+	if err := file.Truncate(cp.Offset); err != nil {
+		return fmt.Errorf("failed to truncate output: %w", err)
+	}
+	if _, err := file.Seek(cp.Offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek output: %w", err)
+	}
+	w, err := usertransfer.NewWriter(f, file, columns, !cp.Resumed())
+	if err != nil {
+		return err
+	}
+
+	exporter := &usertransfer.Exporter{
+		Source:     service,
+		Filter:     *filter,
+		Groups:     *groups,
+		Checkpoint: cp,
+		Offset:     func() (int64, error) { return file.Seek(0, io.SeekCurrent) },
+	}
+	n, err := exporter.Export(ctx, w)
+	if err != nil {
+		return fmt.Errorf("exported %d users before failure: %w", n, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close output: %w", err)
+	}
+	slog.Info("Exported users", "count", n, "out", *out)
+	return cp.Remove()
+}
+
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	pool := fs.String("pool", os.Getenv("AWS_COGNITO_POOL_ID"), "インポート先のユーザープールID")
+	in := fs.String("in", "users.jsonl", "エクスポートしたファイル")
+	format := fs.String("format", "", "入力形式（csv、jsonl）。省略時は入力ファイルの拡張子から判断します")
+	mode := fs.String("mode", "api", "api: AdminCreateUserで作成します。job: インポートジョブ用のCSVを生成します")
+	jobOut := fs.String("job-out", "import.csv", "modeがjobの場合の出力ファイル")
+	interval := fs.Duration("interval", 200*time.Millisecond, "modeがapiの場合のAdminCreateUserの呼び出し間隔")
+	messageAction := fs.String("message-action", "SUPPRESS", "AdminCreateUserのMessageAction（SUPPRESS、空文字で招待メッセージを送信）")
+	usernameAttribute := fs.String("username-attribute", "", "指定した属性（例: email）の値をユーザー名にします。メールアドレスをユーザー名とするプールで使用します")
+	dryRun := fs.Bool("dry-run", false, "ファイルの検証のみ行い、ユーザーの作成やCSVの書き込みをしません")
+	checkpoint := fs.String("checkpoint", "", "modeがapiの場合のチェックポイントのファイル。省略時は <in>.checkpoint、空文字で無効")
+	fs.Parse(args)
+
+	service, err := newService(*pool)
+	if err != nil {
+		return err
+	}
+	f := usertransfer.Format(*format)
+	if f == "" {
+		f = usertransfer.FormatFromPath(*in)
+	}
+	file, err := os.Open(*in)
+	if err != nil {
+		return fmt.Errorf("failed to open input: %w", err)
+	}
+	defer file.Close()
+	r, err := usertransfer.NewReader(f, file)
+	if err != nil {
+		return err
+	}
+
+	switch *mode {
+	case "api":
+		cp, err := usertransfer.LoadCheckpoint(checkpointPath(fs, *checkpoint, *in))
+		if err != nil {
+			return err
+		}
+		if cp.Resumed() && !*dryRun {
+			slog.Info("Resuming import", "processed", cp.Processed)
+		}
+		importer := &usertransfer.Importer{
+			Target:            service,
+			Interval:          *interval,
+			MessageAction:     *messageAction,
+			UsernameAttribute: *usernameAttribute,
+			DryRun:            *dryRun,
+			Checkpoint:        cp,
+		}
+		result, err := importer.Import(ctx, r)
+		slog.Info("Imported users", "created", result.Created, "skipped", result.Skipped, "failed", result.Failed, "dry_run", *dryRun)
+		if err != nil {
+			return err
+		}
+		if *dryRun {
+			return nil
+		}
+		return cp.Remove()
+	case "job":
+		header, err := service.GetCSVHeader(ctx)
+		if err != nil {
+			return err
+		}
+		var out io.Writer = io.Discard
+		if !*dryRun {
+			jobFile, err := os.Create(*jobOut)
+			if err != nil {
+				return fmt.Errorf("failed to create job CSV: %w", err)
+			}
+			defer jobFile.Close()
+			out = jobFile
+		}
+		w, err := usertransfer.NewJobCSVWriter(out, header, true)
+		if err != nil {
+			return err
+		}
+		w.UsernameAttribute = *usernameAttribute
+		n, err := usertransfer.Copy(w, r)
+		if err != nil {
+			return err
+		}
+		slog.Info("Wrote user import job CSV", "count", n, "out", *jobOut, "dry_run", *dryRun)
+		return nil
+	default:
+		return fmt.Errorf("unsupported mode: %q", *mode)
+	}
+}
+
+// checkpointPath は-checkpointが指定されていない場合に、対象のファイル名からチェックポイントのパスを決めます
+func checkpointPath(fs *flag.FlagSet, value, target string) string {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "checkpoint" {
+			set = true
+		}
+	})
+	if set {
+		return value
+	}
+	return target + ".checkpoint"
+}
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"bufio"
+	"cognito-lambda-handler/internal/requestinfo"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Logger は監査レコードをハッシュチェーンで連結してSinkに書き込みます
+type Logger struct {
+	mu   sync.Mutex
+	sink Sink
+	head Head
+	now  func() time.Time
+}
+
+// NewLogger は新しいLoggerを作成します
+// headには既存のチェーンの最後のレコードを指定します（新規の場合はゼロ値）
+func NewLogger(sink Sink, head Head) *Logger {
+	return &Logger{sink: sink, head: head, now: time.Now}
+}
+
+// Head は最後に書き込んだレコードの連番とハッシュを返します
+// 監査ログとは別の場所に保存しておくと、Verifyで末尾のレコードの削除を検出できます
+func (l *Logger) Head() Head {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.head
+}
+
+// Record はレコードにリクエスト情報・時刻・ハッシュを付与して書き込みます
+func (l *Logger) Record(ctx context.Context, event Event) error {
+	if info := requestinfo.FromContext(ctx); info != nil {
+		if event.SourceIP == "" {
+			event.SourceIP = info.SourceIP
+		}
+		if event.UserAgent == "" {
+			event.UserAgent = info.UserAgent
+		}
+		if event.RequestID == "" {
+			event.RequestID = info.APIRequestID
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	event.Seq = l.head.Seq + 1
+	event.Time = l.now().UTC()
+	event.PrevHash = l.head.Hash
+	hash, err := event.computeHash()
+	if err != nil {
+		return err
+	}
+	event.Hash = hash
+
+	if err := l.sink.Write(event); err != nil {
+		return err
+	}
+	l.head = Head{Seq: event.Seq, Hash: hash}
+	return nil
+}
+
+// Middleware はハンドラーからEmitで記録できるよう、Loggerをリクエストのコンテキストに設定します
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), l)))
+	})
+}
+
+type contextKey struct{}
+
+// NewContext はLoggerを保持したコンテキストを返します
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// Emit はコンテキストのLoggerにレコードを書き込みます
+// Loggerが設定されていない場合は何もしません。書き込みに失敗してもリクエストは失敗させずログに残します
+func Emit(ctx context.Context, event Event) {
+	l, _ := ctx.Value(contextKey{}).(*Logger)
+	if l == nil {
+		return
+	}
+	if err := l.Record(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "Failed to write audit event", "event", event.Event, "error", err)
+	}
+}
+
+// Verify はJSON Lines形式の監査レコードを読み込み、ハッシュチェーンが先頭から連続していることを検証します
+// 改ざん・削除・挿入が見つかった場合は該当する行番号を含むエラーを返します
+// headにLogger.Headで保存しておいた値を指定すると、チェーンがそのレコードまで続いていることも検証します（ゼロ値の場合は検証しません）
+func Verify(r io.Reader, head Head) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	last := Head{Hash: GenesisHash}
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("line %d: failed to decode audit event: %w", line, err)
+		}
+		if event.Seq != last.Seq+1 {
+			return fmt.Errorf("line %d: seq %d does not follow %d, records have been removed or inserted", line, event.Seq, last.Seq)
+		}
+		if event.PrevHash != last.Hash {
+			return fmt.Errorf("line %d: prev_hash does not match previous record", line)
+		}
+		hash, err := event.computeHash()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if hash != event.Hash {
+			return fmt.Errorf("line %d: hash mismatch, record has been modified", line)
+		}
+		last = Head{Seq: event.Seq, Hash: event.Hash}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit events: %w", err)
+	}
+	if head.Seq != 0 && last != head {
+		return fmt.Errorf("chain ends at seq %d, expected seq %d with hash %s", last.Seq, head.Seq, head.Hash)
+	}
+	return nil
+}
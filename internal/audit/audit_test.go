@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"bytes"
+	"cognito-lambda-handler/internal/requestinfo"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// レコードがハッシュチェーンで連結され、リクエスト情報が付与されることを確認
+func TestLogger_Record(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(NewWriterSink(&buf), Head{})
+	l.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	ctx := requestinfo.NewContext(context.Background(), &requestinfo.Info{
+		APIRequestID: "req-1",
+		SourceIP:     "192.0.2.1",
+		UserAgent:    "test-agent",
+	})
+	assert.NoError(t, l.Record(ctx, Event{Event: EventSignIn, Subject: "user@example.com", Outcome: OutcomeSuccess}))
+	assert.NoError(t, l.Record(ctx, Event{Event: EventSignIn, Subject: "user@example.com", Outcome: OutcomeFailure, ErrorCode: "NotAuthorizedException"}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var first, second Event
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "192.0.2.1", first.SourceIP)
+	assert.Equal(t, "test-agent", first.UserAgent)
+	assert.Equal(t, "req-1", first.RequestID)
+	assert.Equal(t, GenesisHash, first.PrevHash)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.Equal(t, []uint64{1, 2}, []uint64{first.Seq, second.Seq})
+	assert.Equal(t, Head{Seq: 2, Hash: second.Hash}, l.Head())
+
+	assert.NoError(t, Verify(strings.NewReader(buf.String()), l.Head()))
+}
+
+// レコードの改ざんと、先頭・途中・末尾のレコードの削除が検出されることを確認
+func TestVerify_DetectsTampering(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(NewWriterSink(&buf), Head{})
+	for _, subject := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		assert.NoError(t, l.Record(context.Background(), Event{Event: EventSignIn, Subject: subject, Outcome: OutcomeFailure}))
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	modified := strings.Replace(buf.String(), `"outcome":"failure"`, `"outcome":"success"`, 1)
+	assert.ErrorContains(t, Verify(strings.NewReader(modified), Head{}), "line 1: hash mismatch")
+
+	deleted := lines[0] + "\n" + lines[2] + "\n"
+	assert.ErrorContains(t, Verify(strings.NewReader(deleted), Head{}), "line 2: seq 3 does not follow 1")
+
+	headDeleted := lines[1] + "\n" + lines[2] + "\n"
+	assert.ErrorContains(t, Verify(strings.NewReader(headDeleted), Head{}), "line 1: seq 2 does not follow 0")
+
+	// 連番を振り直しても、ハッシュが合わなくなる
+	renumbered := strings.Replace(lines[1], `"seq":2`, `"seq":1`, 1) + "\n"
+	assert.ErrorContains(t, Verify(strings.NewReader(renumbered), Head{}), "line 1: prev_hash")
+
+	// 末尾の削除は保存しておいたHeadと照合して検出する
+	tailDeleted := lines[0] + "\n" + lines[1] + "\n"
+	assert.NoError(t, Verify(strings.NewReader(tailDeleted), Head{}))
+	assert.ErrorContains(t, Verify(strings.NewReader(tailDeleted), l.Head()), "chain ends at seq 2, expected seq 3")
+}
+
+// ファイルを開き直しても前回の最後のハッシュから連結が続くことを確認
+func TestFileSink_ResumesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	for i := 0; i < 2; i++ {
+		sink, err := OpenFileSink(path)
+		assert.NoError(t, err)
+		l := NewLogger(sink, sink.Head())
+		assert.NoError(t, l.Record(context.Background(), Event{Event: EventSignUp, Subject: "user@example.com", Outcome: OutcomeSuccess}))
+		assert.NoError(t, sink.Close())
+	}
+
+	sink, err := OpenFileSink(path)
+	assert.NoError(t, err)
+	defer sink.Close()
+	assert.Equal(t, uint64(2), sink.Head().Seq)
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, Verify(bytes.NewReader(content), sink.Head()))
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventType は監査イベントの種類
+type EventType string
+
+const (
+	EventSignUp         EventType = "auth.signup"
+	EventConfirmSignUp  EventType = "auth.confirm_signup"
+	EventSignIn         EventType = "auth.signin"
+	EventNewPassword    EventType = "auth.new_password"
+	EventForgotPassword EventType = "auth.forgot_password"
+	EventResetPassword  EventType = "auth.reset_password"
+	EventRefresh        EventType = "auth.refresh"
+	EventLogout         EventType = "auth.logout"
+	EventOAuthSignIn    EventType = "auth.oauth_signin"
+	EventOTPStart       EventType = "auth.otp_start"
+	EventOTPSignIn      EventType = "auth.otp_signin"
+	EventCredentials    EventType = "auth.credentials"
+	EventDeviceConfirm  EventType = "device.confirm"
+	EventDeviceUpdate   EventType = "device.update"
+	EventDeviceForget   EventType = "device.forget"
+
+	EventAdminCreateUser       EventType = "admin.create_user"
+	EventAdminGetUser          EventType = "admin.get_user"
+	EventAdminListUsers        EventType = "admin.list_users"
+	EventAdminDisableUser      EventType = "admin.disable_user"
+	EventAdminEnableUser       EventType = "admin.enable_user"
+	EventAdminDeleteUser       EventType = "admin.delete_user"
+	EventAdminResetPassword    EventType = "admin.reset_password"
+	EventAdminUpdateAttributes EventType = "admin.update_attributes"
+	EventAdminGlobalSignOut    EventType = "admin.global_sign_out"
+	EventAdminCreateGroup      EventType = "admin.create_group"
+	EventAdminListGroups       EventType = "admin.list_groups"
+	EventAdminListGroupUsers   EventType = "admin.list_group_users"
+	EventAdminListUserGroups   EventType = "admin.list_user_groups"
+	EventAdminAddToGroup       EventType = "admin.add_to_group"
+	EventAdminRemoveFromGroup  EventType = "admin.remove_from_group"
+)
+
+// Outcome は操作の結果
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	// OutcomeChallenge は認証情報は正しいものの、チャレンジ（新しいパスワードの設定など）への応答が必要なこと
+	OutcomeChallenge Outcome = "challenge"
+)
+
+// Event は1件の監査レコード
+// PrevHashとHashによって前のレコードと連結され、途中のレコードの改ざんや削除を検出できます
+// Seqはチェーンの先頭を1とする連番で、先頭のレコードのPrevHashはGenesisHashです
+type Event struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Event     EventType `json:"event"`
+	Subject   string    `json:"subject"`
+	Actor     string    `json:"actor,omitempty"` // 管理操作を行ったユーザー。本人の操作の場合は空
+	Group     string    `json:"group,omitempty"` // グループの管理操作の対象グループ
+	SourceIP  string    `json:"source_ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Outcome   Outcome   `json:"outcome"`
+	ErrorCode string    `json:"error_code,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// GenesisHash はチェーンの先頭のレコードのPrevHash
+const GenesisHash = ""
+
+// Head はチェーンの最後のレコードの連番とハッシュ
+// Verifyに渡すと、末尾のレコードの削除も検出できます
+type Head struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// computeHash はHashを除いたレコードのJSONからSHA-256ハッシュを計算します
+func (e Event) computeHash() (string, error) {
+	e.Hash = ""
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink は監査レコードの書き込み先
+type Sink interface {
+	Write(event Event) error
+}
+
+// WriterSink はレコードをJSON Lines形式でio.Writerに書き込むSink
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink はwに書き込むWriterSinkを作成します
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewStdoutSink は標準出力に書き込むSinkを作成します
+// Lambdaでは CloudWatch Logs のサブスクリプションフィルターで $.event = "auth.*" を指定して転送します
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+// Write はレコードを1行のJSONとして書き込みます
+func (s *WriterSink) Write(event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+	return nil
+}
+
+// FileSink はJSON Linesファイルに追記するSink
+type FileSink struct {
+	*WriterSink
+	f    *os.File
+	head Head
+}
+
+// OpenFileSink はpathのファイルを追記モードで開きます
+// 既存のレコードがある場合は最後のレコードから連結を再開します
+func OpenFileSink(path string) (*FileSink, error) {
+	head, err := readHead(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &FileSink{WriterSink: NewWriterSink(f), f: f, head: head}, nil
+}
+
+// Head はファイルを開いた時点での最後のレコードの連番とハッシュを返します
+func (s *FileSink) Head() Head {
+	return s.head
+}
+
+// Close はファイルを閉じます
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+func readHead(path string) (Head, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return Head{}, nil
+	}
+	if err != nil {
+		return Head{}, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer f.Close()
+
+	var last Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &last); err != nil {
+			return Head{}, fmt.Errorf("failed to decode audit file: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Head{}, fmt.Errorf("failed to read audit file: %w", err)
+	}
+	return Head{Seq: last.Seq, Hash: last.Hash}, nil
+}
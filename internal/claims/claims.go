@@ -0,0 +1,62 @@
+package claims
+
+import (
+	"context"
+	"slices"
+)
+
+// Subject はクレームを検索するユーザー
+type Subject struct {
+	Username   string
+	Attributes map[string]string // sub、email などのユーザー属性
+	Groups     []string          // ユーザーが所属するグループ
+	ClientID   string
+}
+
+// Claims はトークンに追加・削除するクレーム
+type Claims struct {
+	// IDToken と AccessToken は各トークンに追加・上書きするクレーム。値には文字列のほか配列やオブジェクトも指定できます
+	// V1のイベントではIDトークンのみ変更でき、文字列以外の値はJSONに変換します
+	IDToken     map[string]any `json:"id_token,omitempty"`
+	AccessToken map[string]any `json:"access_token,omitempty"`
+	// Suppress は両方のトークンから削除するクレーム
+	Suppress []string `json:"suppress,omitempty"`
+	// Groups がnilでない場合、cognito:groups をこの値で置き換えます。空の配列ですべてのグループを外します
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Source はユーザーに追加するクレームを返します
+// 追加するクレームがない場合はnilを返します
+type Source interface {
+	Claims(ctx context.Context, subject Subject) (*Claims, error)
+}
+
+// Merge はotherの内容をcに重ねます。同じクレームはotherの値を優先し、削除するクレームは和集合にします
+func (c *Claims) Merge(other *Claims) {
+	if other == nil {
+		return
+	}
+	c.IDToken = mergeMap(c.IDToken, other.IDToken)
+	c.AccessToken = mergeMap(c.AccessToken, other.AccessToken)
+	for _, name := range other.Suppress {
+		if !slices.Contains(c.Suppress, name) {
+			c.Suppress = append(c.Suppress, name)
+		}
+	}
+	if other.Groups != nil {
+		c.Groups = slices.Clone(other.Groups)
+	}
+}
+
+func mergeMap(dst, src map[string]any) map[string]any {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]any, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
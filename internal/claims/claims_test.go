@@ -0,0 +1,54 @@
+package claims
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 後に重ねた定義の値が優先され、削除するクレームは和集合になることを確認
+func TestClaims_Merge(t *testing.T) {
+	c := &Claims{
+		AccessToken: map[string]any{"tenant_id": "default", "plan": "free"},
+		Suppress:    []string{"email"},
+	}
+	c.Merge(&Claims{
+		AccessToken: map[string]any{"tenant_id": "acme"},
+		Suppress:    []string{"email", "phone_number"},
+		Groups:      []string{},
+	})
+
+	assert.Equal(t, map[string]any{"tenant_id": "acme", "plan": "free"}, c.AccessToken)
+	assert.Equal(t, []string{"email", "phone_number"}, c.Suppress)
+	// 空の配列はすべてのグループを外す指定として残す
+	assert.NotNil(t, c.Groups)
+	assert.Empty(t, c.Groups)
+}
+
+// usersの定義はユーザー名・sub・確認済みのメールアドレスに一致し、未確認のメールアドレスには一致しないことを確認
+func TestFileSource_Claims(t *testing.T) {
+	s := &FileSource{Users: map[string]Claims{
+		"admin@example.com": {Groups: []string{"admin"}},
+		"hanako":            {AccessToken: map[string]any{"tenant_id": "acme"}},
+	}}
+
+	verified := Subject{Username: "taro", Attributes: map[string]string{"sub": "taro-sub", "email": "Admin@Example.com", "email_verified": "true"}}
+	c, err := s.Claims(context.Background(), verified)
+	assert.NoError(t, err)
+	if assert.NotNil(t, c) {
+		assert.Equal(t, []string{"admin"}, c.Groups)
+	}
+
+	unverified := Subject{Username: "jiro", Attributes: map[string]string{"sub": "jiro-sub", "email": "admin@example.com", "email_verified": "false"}}
+	c, err = s.Claims(context.Background(), unverified)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = s.Claims(context.Background(), Subject{Username: "hanako", Attributes: map[string]string{"email": "admin@example.com"}})
+	assert.NoError(t, err)
+	if assert.NotNil(t, c) {
+		assert.Equal(t, "acme", c.AccessToken["tenant_id"])
+		assert.Nil(t, c.Groups)
+	}
+}
@@ -0,0 +1,76 @@
+package claims
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileSource はJSONファイルに定義した固定のクレームを返すSource
+//
+//	{
+//	  "defaults": {"access_token": {"tenant_id": "default"}},
+//	  "groups":   {"admin": {"access_token": {"roles": ["admin"]}}},
+//	  "users":    {"user@example.com": {"id_token": {"tenant_id": "acme"}, "groups": ["acme-users"]}}
+//	}
+//
+// defaults、ユーザーが所属するグループ（所属順）、ユーザーの順に重ね、後のものを優先します
+// usersのキーはユーザー名、sub、確認済みのメールアドレス（小文字）のいずれかです
+// 未確認のメールアドレスはユーザーが自由に設定できるため、照合に使いません
+type FileSource struct {
+	Defaults *Claims           `json:"defaults"`
+	Groups   map[string]Claims `json:"groups"`
+	Users    map[string]Claims `json:"users"`
+}
+
+// LoadFile はJSONファイルからFileSourceを作成します
+func LoadFile(path string) (*FileSource, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claims file: %w", err)
+	}
+	var s FileSource
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse claims file: %w", err)
+	}
+	// メールアドレスは大文字小文字を区別せずに照合する
+	users := make(map[string]Claims, len(s.Users))
+	for k, v := range s.Users {
+		users[strings.ToLower(k)] = v
+	}
+	s.Users = users
+	return &s, nil
+}
+
+// Claims はユーザーに一致する定義を重ねたクレームを返します
+func (s *FileSource) Claims(ctx context.Context, subject Subject) (*Claims, error) {
+	result := &Claims{}
+	found := false
+	if s.Defaults != nil {
+		result.Merge(s.Defaults)
+		found = true
+	}
+	for _, group := range subject.Groups {
+		if c, ok := s.Groups[group]; ok {
+			result.Merge(&c)
+			found = true
+		}
+	}
+	keys := []string{subject.Username, subject.Attributes["sub"]}
+	if subject.Attributes["email_verified"] == "true" {
+		keys = append(keys, subject.Attributes["email"])
+	}
+	for _, key := range keys {
+		if c, ok := s.Users[strings.ToLower(key)]; ok && key != "" {
+			result.Merge(&c)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package cognito
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+)
+
+// AdminDisableUser はユーザーを無効化し、以降のサインインを拒否します
+func (s *Service) AdminDisableUser(ctx context.Context, email string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminDisableUser")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.AdminDisableUserInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(email),
+	}
+
+	_, err = s.client.AdminDisableUser(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to disable user: %w", err)
+	}
+
+	return nil
+}
+
+// AdminEnableUser は無効化されたユーザーを再度有効化します
+func (s *Service) AdminEnableUser(ctx context.Context, email string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminEnableUser")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.AdminEnableUserInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(email),
+	}
+
+	_, err = s.client.AdminEnableUser(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to enable user: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package cognito
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"time"
+)
+
+// User はユーザープールのユーザー
+type User struct {
+	Username       string
+	Status         string // CONFIRMED、FORCE_CHANGE_PASSWORD など
+	Enabled        bool
+	Attributes     map[string]string
+	CreatedAt      time.Time
+	LastModifiedAt time.Time
+}
+
+func attributesMap(attrs []types.AttributeType) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		m[aws.ToString(a.Name)] = aws.ToString(a.Value)
+	}
+	return m
+}
+
+func toUser(u types.UserType) User {
+	return User{
+		Username:       aws.ToString(u.Username),
+		Status:         string(u.UserStatus),
+		Enabled:        u.Enabled,
+		Attributes:     attributesMap(u.Attributes),
+		CreatedAt:      aws.ToTime(u.UserCreateDate),
+		LastModifiedAt: aws.ToTime(u.UserLastModifiedDate),
+	}
+}
+
+// AdminGetUser はユーザーを返します
+func (s *Service) AdminGetUser(ctx context.Context, username string) (user *User, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminGetUser")
+	defer func() { endSpan(span, err) }()
+
+	output, err := s.client.AdminGetUser(ctx, &cognitoidentityprovider.AdminGetUserInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(username),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &User{
+		Username:       aws.ToString(output.Username),
+		Status:         string(output.UserStatus),
+		Enabled:        output.Enabled,
+		Attributes:     attributesMap(output.UserAttributes),
+		CreatedAt:      aws.ToTime(output.UserCreateDate),
+		LastModifiedAt: aws.ToTime(output.UserLastModifiedDate),
+	}, nil
+}
+
+// ListUsers はユーザーを返します。次のページがある場合はnextTokenを返します
+// filterはCognitoのフィルター式（例: `email ^= "taro"`）で、空の場合はすべてのユーザーを返します
+func (s *Service) ListUsers(ctx context.Context, filter string, limit int32, paginationToken string) (users []User, nextToken string, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ListUsers")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.ListUsersInput{UserPoolId: aws.String(s.poolId)}
+	if filter != "" {
+		input.Filter = aws.String(filter)
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+	if paginationToken != "" {
+		input.PaginationToken = aws.String(paginationToken)
+	}
+
+	output, err := s.client.ListUsers(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list users: %w", err)
+	}
+	users = make([]User, 0, len(output.Users))
+	for _, u := range output.Users {
+		users = append(users, toUser(u))
+	}
+	return users, aws.ToString(output.PaginationToken), nil
+}
+
+// AdminDeleteUser はユーザーを削除します
+func (s *Service) AdminDeleteUser(ctx context.Context, username string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminDeleteUser")
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.client.AdminDeleteUser(ctx, &cognitoidentityprovider.AdminDeleteUserInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(username),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// AdminResetUserPassword は現在のパスワードを無効にし、パスワードリセットの確認コードを送信します
+func (s *Service) AdminResetUserPassword(ctx context.Context, username string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminResetUserPassword")
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.client.AdminResetUserPassword(ctx, &cognitoidentityprovider.AdminResetUserPasswordInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(username),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset user password: %w", err)
+	}
+	return nil
+}
+
+// AdminUpdateUserAttributes はユーザーの属性を更新します
+// メールアドレスや電話番号を変更した場合、Cognitoは確認コードを送信します
+func (s *Service) AdminUpdateUserAttributes(ctx context.Context, username string, attributes map[string]string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminUpdateUserAttributes")
+	defer func() { endSpan(span, err) }()
+
+	attrs := make([]types.AttributeType, 0, len(attributes))
+	for name, value := range attributes {
+		attrs = append(attrs, types.AttributeType{Name: aws.String(name), Value: aws.String(value)})
+	}
+	_, err = s.client.AdminUpdateUserAttributes(ctx, &cognitoidentityprovider.AdminUpdateUserAttributesInput{
+		UserPoolId:     aws.String(s.poolId),
+		Username:       aws.String(username),
+		UserAttributes: attrs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user attributes: %w", err)
+	}
+	return nil
+}
+
+// AdminUserGlobalSignOut はユーザーのすべてのリフレッシュトークンを無効にします
+func (s *Service) AdminUserGlobalSignOut(ctx context.Context, username string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminUserGlobalSignOut")
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.client.AdminUserGlobalSignOut(ctx, &cognitoidentityprovider.AdminUserGlobalSignOutInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(username),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sign out user: %w", err)
+	}
+	return nil
+}
+
+// CreateUserInput は管理者が作成するユーザー
+type CreateUserInput struct {
+	Username   string
+	Attributes map[string]string
+	// TemporaryPassword は初回サインインで使う一時パスワード。空の場合はCognitoが生成します
+	TemporaryPassword string
+	// MessageAction はRESEND（招待メッセージの再送）またはSUPPRESS（送信しない）。空の場合は招待メッセージを送信します
+	MessageAction string
+	// DesiredDeliveryMediums は招待メッセージの送信方法（EMAIL、SMS）
+	DesiredDeliveryMediums []string
+}
+
+// AdminCreateUser はユーザーを作成し、一時パスワードを記載した招待メッセージを送信します
+// 作成したユーザーはFORCE_CHANGE_PASSWORDの状態で、初回サインイン時にNEW_PASSWORD_REQUIREDチャレンジが返されます
+func (s *Service) AdminCreateUser(ctx context.Context, in CreateUserInput) (user *User, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminCreateUser")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.AdminCreateUserInput{
+		UserPoolId:    aws.String(s.poolId),
+		Username:      aws.String(in.Username),
+		MessageAction: types.MessageActionType(in.MessageAction),
+	}
+	for name, value := range in.Attributes {
+		input.UserAttributes = append(input.UserAttributes, types.AttributeType{Name: aws.String(name), Value: aws.String(value)})
+	}
+	if in.TemporaryPassword != "" {
+		input.TemporaryPassword = aws.String(in.TemporaryPassword)
+	}
+	for _, medium := range in.DesiredDeliveryMediums {
+		input.DesiredDeliveryMediums = append(input.DesiredDeliveryMediums, types.DeliveryMediumType(medium))
+	}
+
+	output, err := s.client.AdminCreateUser(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	u := toUser(*output.User)
+	return &u, nil
+}
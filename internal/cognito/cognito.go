@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"net/http"
 	"os"
 )
 
@@ -16,10 +17,13 @@ type Service struct {
 	clientId     string
 	clientSecret string
 	poolId       string
+	httpClient   *http.Client // OAuth2のトークンエンドポイント用。nilの場合はhttp.DefaultClient
 }
 
-func NewCognitoService(clientId string, clientSecret string, poolId string) (*Service, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+// NewCognitoService はCognitoサービスを作成します
+// optFnsでSDKのミドルウェア（メトリクスやトレース）などを追加できます
+func NewCognitoService(clientId string, clientSecret string, poolId string, optFns ...func(*config.LoadOptions) error) (*Service, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO(), optFns...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load SDK config: %w", err)
 	}
@@ -7,7 +7,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 )
 
-func (s *Service) ConfirmSignUp(email, confirmationCode string) error {
+func (s *Service) ConfirmSignUp(ctx context.Context, email, confirmationCode string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ConfirmSignUp")
+	defer func() { endSpan(span, err) }()
+
 	secretHash, err := generateSecretHash(email, s.clientId)
 	if err != nil {
 		return fmt.Errorf("failed to generate secret hash: %v", err)
@@ -20,7 +23,7 @@ func (s *Service) ConfirmSignUp(email, confirmationCode string) error {
 		ConfirmationCode: aws.String(confirmationCode),
 	}
 
-	_, err = s.client.ConfirmSignUp(context.TODO(), input)
+	_, err = s.client.ConfirmSignUp(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to confirm sign up: %w", err)
 	}
@@ -0,0 +1,79 @@
+package cognito
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+// CustomChallenge はCUSTOM_CHALLENGEの状態
+// Sessionは次のRespondToCustomChallengeに渡し、Parametersはクライアントに表示する公開パラメーターです
+type CustomChallenge struct {
+	Session    string
+	Parameters map[string]string
+}
+
+// StartCustomAuth はCUSTOM_AUTHフローを開始し、最初のCUSTOM_CHALLENGEを返します
+// 確認コードの生成と送信はCreateAuthChallengeトリガーが行います
+func (s *Service) StartCustomAuth(ctx context.Context, email string) (challenge *CustomChallenge, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.StartCustomAuth")
+	defer func() { endSpan(span, err) }()
+
+	secretHash, err := generateSecretHash(email, s.clientId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret hash: %v", err)
+	}
+
+	output, err := s.client.InitiateAuth(ctx, &cognitoidentityprovider.InitiateAuthInput{
+		AuthFlow: types.AuthFlowTypeCustomAuth,
+		AuthParameters: map[string]string{
+			"USERNAME":    email,
+			"SECRET_HASH": secretHash,
+		},
+		ClientId: aws.String(s.clientId),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initiate custom auth: %w", err)
+	}
+	if output.ChallengeName != types.ChallengeNameTypeCustomChallenge {
+		return nil, fmt.Errorf("unexpected challenge: %s", output.ChallengeName)
+	}
+	return &CustomChallenge{Session: aws.ToString(output.Session), Parameters: output.ChallengeParameters}, nil
+}
+
+// RespondToCustomChallenge はCUSTOM_CHALLENGEに確認コードで応答します
+// コードが誤っていて再試行できる場合はresultがnilで、次のチャレンジを返します
+// 再試行の上限に達した場合はNotAuthorizedExceptionが返されます
+func (s *Service) RespondToCustomChallenge(ctx context.Context, email, session, answer string) (result *types.AuthenticationResultType, next *CustomChallenge, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.RespondToCustomChallenge")
+	defer func() { endSpan(span, err) }()
+
+	secretHash, err := generateSecretHash(email, s.clientId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate secret hash: %v", err)
+	}
+
+	output, err := s.client.RespondToAuthChallenge(ctx, &cognitoidentityprovider.RespondToAuthChallengeInput{
+		ChallengeName: types.ChallengeNameTypeCustomChallenge,
+		ChallengeResponses: map[string]string{
+			"USERNAME":    email,
+			"ANSWER":      answer,
+			"SECRET_HASH": secretHash,
+		},
+		ClientId: aws.String(s.clientId),
+		Session:  aws.String(session),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to respond to custom challenge: %w", err)
+	}
+
+	if output.AuthenticationResult != nil {
+		return output.AuthenticationResult, nil, nil
+	}
+	if output.ChallengeName == types.ChallengeNameTypeCustomChallenge {
+		return nil, &CustomChallenge{Session: aws.ToString(output.Session), Parameters: output.ChallengeParameters}, nil
+	}
+	return nil, nil, fmt.Errorf("unexpected challenge: %s", output.ChallengeName)
+}
@@ -0,0 +1,41 @@
+package cognito
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"strings"
+)
+
+// ValidateConfig はクライアントID・シークレット・ユーザープールIDの形式を検証します
+func (s *Service) ValidateConfig() error {
+	if s.clientId == "" {
+		return fmt.Errorf("client id is not set")
+	}
+	if s.clientSecret == "" {
+		return fmt.Errorf("client secret is not set")
+	}
+	if region, name, ok := strings.Cut(s.poolId, "_"); !ok || region == "" || name == "" {
+		return fmt.Errorf("invalid Cognito User Pool ID (%s), must be in format: '<region>_<pool name>'", s.poolId)
+	}
+	return nil
+}
+
+// DescribeUserPoolClient はアプリクライアントの設定を取得し、ユーザープールに到達できることを確認します
+func (s *Service) DescribeUserPoolClient(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.DescribeUserPoolClient")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.DescribeUserPoolClientInput{
+		ClientId:   aws.String(s.clientId),
+		UserPoolId: aws.String(s.poolId),
+	}
+
+	_, err = s.client.DescribeUserPoolClient(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to describe user pool client: %w", err)
+	}
+
+	return nil
+}
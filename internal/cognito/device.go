@@ -0,0 +1,148 @@
+package cognito
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"time"
+)
+
+// DeviceCredentials は記憶済みデバイスでのサインインに必要な情報
+// ConfirmDevice時に生成し、クライアント側で保存します
+type DeviceCredentials struct {
+	Key      string
+	GroupKey string
+	Password string
+}
+
+// ConfirmedDevice はConfirmDeviceで登録したデバイス
+type ConfirmedDevice struct {
+	DeviceCredentials
+	// UserConfirmationNecessary がtrueの場合、UpdateDeviceStatusで記憶するまでデバイス認証に使用できません
+	UserConfirmationNecessary bool
+}
+
+// Device はユーザーに登録されているデバイス
+type Device struct {
+	Key                 string
+	Name                string
+	Remembered          bool
+	LastIP              string
+	CreatedAt           time.Time
+	LastModifiedAt      time.Time
+	LastAuthenticatedAt time.Time
+}
+
+// ConfirmDevice はサインイン時に発行された新しいデバイスを、生成したパスワード検証子で登録します
+// デバイス名はここでのみ設定できます。Cognitoにはデバイス名を変更するAPIがありません
+func (s *Service) ConfirmDevice(ctx context.Context, accessToken string, metadata *types.NewDeviceMetadataType, deviceName string) (device *ConfirmedDevice, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ConfirmDevice")
+	defer func() { endSpan(span, err) }()
+
+	creds := DeviceCredentials{
+		Key:      aws.ToString(metadata.DeviceKey),
+		GroupKey: aws.ToString(metadata.DeviceGroupKey),
+	}
+	password, verifier, salt, err := GenerateDeviceVerifier(creds.GroupKey, creds.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate device verifier: %w", err)
+	}
+	creds.Password = password
+
+	input := &cognitoidentityprovider.ConfirmDeviceInput{
+		AccessToken: aws.String(accessToken),
+		DeviceKey:   aws.String(creds.Key),
+		DeviceSecretVerifierConfig: &types.DeviceSecretVerifierConfigType{
+			PasswordVerifier: aws.String(verifier),
+			Salt:             aws.String(salt),
+		},
+	}
+	if deviceName != "" {
+		input.DeviceName = aws.String(deviceName)
+	}
+
+	output, err := s.client.ConfirmDevice(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm device: %w", err)
+	}
+	return &ConfirmedDevice{DeviceCredentials: creds, UserConfirmationNecessary: output.UserConfirmationNecessary}, nil
+}
+
+// ListDevices はユーザーのデバイスを返します。次のページがある場合はnextTokenを返します
+func (s *Service) ListDevices(ctx context.Context, accessToken string, limit int32, paginationToken string) (devices []Device, nextToken string, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ListDevices")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.ListDevicesInput{AccessToken: aws.String(accessToken)}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+	if paginationToken != "" {
+		input.PaginationToken = aws.String(paginationToken)
+	}
+
+	output, err := s.client.ListDevices(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	devices = make([]Device, 0, len(output.Devices))
+	for _, d := range output.Devices {
+		device := Device{
+			Key:                 aws.ToString(d.DeviceKey),
+			CreatedAt:           aws.ToTime(d.DeviceCreateDate),
+			LastModifiedAt:      aws.ToTime(d.DeviceLastModifiedDate),
+			LastAuthenticatedAt: aws.ToTime(d.DeviceLastAuthenticatedDate),
+		}
+		for _, attr := range d.DeviceAttributes {
+			switch aws.ToString(attr.Name) {
+			case "device_name":
+				device.Name = aws.ToString(attr.Value)
+			case "last_ip_used":
+				device.LastIP = aws.ToString(attr.Value)
+			case "dev:device_remembered_status":
+				device.Remembered = aws.ToString(attr.Value) == string(types.DeviceRememberedStatusTypeRemembered)
+			}
+		}
+		devices = append(devices, device)
+	}
+	return devices, aws.ToString(output.PaginationToken), nil
+}
+
+// UpdateDeviceStatus はデバイスを記憶するかどうかを設定します
+func (s *Service) UpdateDeviceStatus(ctx context.Context, accessToken, deviceKey string, remembered bool) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.UpdateDeviceStatus")
+	defer func() { endSpan(span, err) }()
+
+	status := types.DeviceRememberedStatusTypeNotRemembered
+	if remembered {
+		status = types.DeviceRememberedStatusTypeRemembered
+	}
+
+	_, err = s.client.UpdateDeviceStatus(ctx, &cognitoidentityprovider.UpdateDeviceStatusInput{
+		AccessToken:            aws.String(accessToken),
+		DeviceKey:              aws.String(deviceKey),
+		DeviceRememberedStatus: status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update device status: %w", err)
+	}
+	return nil
+}
+
+// ForgetDevice はデバイスの登録を削除します
+func (s *Service) ForgetDevice(ctx context.Context, accessToken, deviceKey string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ForgetDevice")
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.client.ForgetDevice(ctx, &cognitoidentityprovider.ForgetDeviceInput{
+		AccessToken: aws.String(accessToken),
+		DeviceKey:   aws.String(deviceKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to forget device: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package cognito
+
+import (
+	"errors"
+
+	"github.com/aws/smithy-go"
+)
+
+// ErrorCode はCognito APIのエラーコード（例: "NotAuthorizedException"）を返します
+// トークンエンドポイントのエラーの場合はOAuth2のエラーコード（例: "invalid_grant"）を返し、
+// どちらでもない場合は空文字を返します
+func ErrorCode(err error) string {
+	var awsErr smithy.APIError
+	if errors.As(err, &awsErr) {
+		return awsErr.ErrorCode()
+	}
+	var oauthErr *OAuthError
+	if errors.As(err, &oauthErr) {
+		return oauthErr.Code
+	}
+	return ""
+}
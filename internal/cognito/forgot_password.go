@@ -7,7 +7,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 )
 
-func (s *Service) ForgotPassword(email string) error {
+func (s *Service) ForgotPassword(ctx context.Context, email string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ForgotPassword")
+	defer func() { endSpan(span, err) }()
+
 	secretHash, err := generateSecretHash(email, s.clientId)
 	if err != nil {
 		return fmt.Errorf("failed to generate secret hash: %v", err)
@@ -19,7 +22,7 @@ func (s *Service) ForgotPassword(email string) error {
 		Username:   aws.String(email),
 	}
 
-	_, err = s.client.ForgotPassword(context.TODO(), input)
+	_, err = s.client.ForgotPassword(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to request password reset: %w", err)
 	}
@@ -0,0 +1,168 @@
+package cognito
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"time"
+)
+
+// Group はユーザープールのグループ
+type Group struct {
+	Name        string
+	Description string
+	// Precedence は複数のグループに所属する場合の優先順位（小さいほど優先）。nilの場合は未設定
+	Precedence     *int32
+	RoleArn        string
+	CreatedAt      time.Time
+	LastModifiedAt time.Time
+}
+
+func toGroup(g types.GroupType) Group {
+	return Group{
+		Name:           aws.ToString(g.GroupName),
+		Description:    aws.ToString(g.Description),
+		Precedence:     g.Precedence,
+		RoleArn:        aws.ToString(g.RoleArn),
+		CreatedAt:      aws.ToTime(g.CreationDate),
+		LastModifiedAt: aws.ToTime(g.LastModifiedDate),
+	}
+}
+
+func toGroups(groups []types.GroupType) []Group {
+	result := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, toGroup(g))
+	}
+	return result
+}
+
+// CreateGroup はグループを作成します。Name以外は省略できます
+func (s *Service) CreateGroup(ctx context.Context, group Group) (created *Group, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.CreateGroup")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.CreateGroupInput{
+		UserPoolId: aws.String(s.poolId),
+		GroupName:  aws.String(group.Name),
+		Precedence: group.Precedence,
+	}
+	if group.Description != "" {
+		input.Description = aws.String(group.Description)
+	}
+	if group.RoleArn != "" {
+		input.RoleArn = aws.String(group.RoleArn)
+	}
+
+	output, err := s.client.CreateGroup(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+	g := toGroup(*output.Group)
+	return &g, nil
+}
+
+// ListGroups はユーザープールのグループを返します。次のページがある場合はnextTokenを返します
+func (s *Service) ListGroups(ctx context.Context, limit int32, nextToken string) (groups []Group, next string, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ListGroups")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.ListGroupsInput{UserPoolId: aws.String(s.poolId)}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+	if nextToken != "" {
+		input.NextToken = aws.String(nextToken)
+	}
+
+	output, err := s.client.ListGroups(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list groups: %w", err)
+	}
+	return toGroups(output.Groups), aws.ToString(output.NextToken), nil
+}
+
+// AdminAddUserToGroup はユーザーをグループに追加します
+// 追加したグループは次に発行されるトークンの cognito:groups から反映されます
+func (s *Service) AdminAddUserToGroup(ctx context.Context, username, groupName string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminAddUserToGroup")
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.client.AdminAddUserToGroup(ctx, &cognitoidentityprovider.AdminAddUserToGroupInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(username),
+		GroupName:  aws.String(groupName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add user to group: %w", err)
+	}
+	return nil
+}
+
+// AdminRemoveUserFromGroup はユーザーをグループから外します
+func (s *Service) AdminRemoveUserFromGroup(ctx context.Context, username, groupName string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminRemoveUserFromGroup")
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.client.AdminRemoveUserFromGroup(ctx, &cognitoidentityprovider.AdminRemoveUserFromGroupInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(username),
+		GroupName:  aws.String(groupName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove user from group: %w", err)
+	}
+	return nil
+}
+
+// AdminListGroupsForUser はユーザーが所属するグループを返します。次のページがある場合はnextTokenを返します
+func (s *Service) AdminListGroupsForUser(ctx context.Context, username string, limit int32, nextToken string) (groups []Group, next string, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.AdminListGroupsForUser")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.AdminListGroupsForUserInput{
+		UserPoolId: aws.String(s.poolId),
+		Username:   aws.String(username),
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+	if nextToken != "" {
+		input.NextToken = aws.String(nextToken)
+	}
+
+	output, err := s.client.AdminListGroupsForUser(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list groups for user: %w", err)
+	}
+	return toGroups(output.Groups), aws.ToString(output.NextToken), nil
+}
+
+// ListUsersInGroup はグループに所属するユーザーを返します。次のページがある場合はnextTokenを返します
+func (s *Service) ListUsersInGroup(ctx context.Context, groupName string, limit int32, nextToken string) (users []User, next string, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ListUsersInGroup")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.ListUsersInGroupInput{
+		UserPoolId: aws.String(s.poolId),
+		GroupName:  aws.String(groupName),
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+	if nextToken != "" {
+		input.NextToken = aws.String(nextToken)
+	}
+
+	output, err := s.client.ListUsersInGroup(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list users in group: %w", err)
+	}
+	users = make([]User, 0, len(output.Users))
+	for _, u := range output.Users {
+		users = append(users, toUser(u))
+	}
+	return users, aws.ToString(output.NextToken), nil
+}
@@ -0,0 +1,95 @@
+package cognito
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AuthorizationCodeRequest はホストUIから受け取った認可コードの交換に必要な値
+type AuthorizationCodeRequest struct {
+	TokenURL     string // ユーザープールドメインの /oauth2/token
+	Code         string
+	RedirectURI  string
+	CodeVerifier string // PKCEのcode_verifier
+}
+
+// OAuthError はトークンエンドポイントが返したOAuth2のエラー
+type OAuthError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth2 token error (%d): %s: %s", e.StatusCode, e.Code, e.Description)
+	}
+	return fmt.Sprintf("oauth2 token error (%d): %s", e.StatusCode, e.Code)
+}
+
+// ExchangeAuthorizationCode は認可コードをトークンエンドポイントで交換します
+// クライアントの認証にはサービスが保持するクライアントシークレットをBasic認証で使用します
+func (s *Service) ExchangeAuthorizationCode(ctx context.Context, req AuthorizationCodeRequest) (result *types.AuthenticationResultType, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ExchangeAuthorizationCode")
+	defer func() { endSpan(span, err) }()
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {s.clientId},
+		"code":          {req.Code},
+		"redirect_uri":  {req.RedirectURI},
+		"code_verifier": {req.CodeVerifier},
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.SetBasicAuth(url.QueryEscape(s.clientId), url.QueryEscape(s.clientSecret))
+
+	client := s.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &OAuthError{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(oauthErr); err != nil || oauthErr.Code == "" {
+			oauthErr.Code = "server_error"
+		}
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", oauthErr)
+	}
+
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		IdToken      string `json:"id_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int32  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokens.AccessToken == "" || tokens.IdToken == "" {
+		return nil, fmt.Errorf("token response is missing access_token or id_token")
+	}
+
+	return &types.AuthenticationResultType{
+		AccessToken:  aws.String(tokens.AccessToken),
+		IdToken:      aws.String(tokens.IdToken),
+		RefreshToken: aws.String(tokens.RefreshToken),
+		TokenType:    aws.String(tokens.TokenType),
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package cognito
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"strings"
+)
+
+// RefreshTokens はリフレッシュトークンで新しいアクセストークンとIDトークンを取得します
+// usernameはSECRET_HASHの計算に使用するCognito上のユーザー名（sub）です
+func (s *Service) RefreshTokens(ctx context.Context, username, refreshToken string) (result *types.AuthenticationResultType, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.RefreshTokens")
+	defer func() { endSpan(span, err) }()
+
+	secretHash, err := generateSecretHash(username, s.clientId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret hash: %v", err)
+	}
+
+	input := &cognitoidentityprovider.InitiateAuthInput{
+		AuthFlow: types.AuthFlowTypeRefreshTokenAuth,
+		AuthParameters: map[string]string{
+			"REFRESH_TOKEN": refreshToken,
+			"SECRET_HASH":   secretHash,
+		},
+		ClientId: aws.String(s.clientId),
+	}
+
+	output, err := s.client.InitiateAuth(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh tokens: %w", err)
+	}
+	if output.AuthenticationResult == nil {
+		return nil, fmt.Errorf("unexpected challenge: %s", output.ChallengeName)
+	}
+	return output.AuthenticationResult, nil
+}
+
+// RevokeToken はリフレッシュトークンと、それから発行されたアクセストークンを無効化します
+func (s *Service) RevokeToken(ctx context.Context, refreshToken string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.RevokeToken")
+	defer func() { endSpan(span, err) }()
+
+	input := &cognitoidentityprovider.RevokeTokenInput{
+		ClientId:     aws.String(s.clientId),
+		ClientSecret: aws.String(s.clientSecret),
+		Token:        aws.String(refreshToken),
+	}
+
+	_, err = s.client.RevokeToken(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// UsernameFromAccessToken はアクセストークンのusernameクレームを返します
+// 署名は検証しないため、Cognitoから直接受け取ったトークンにのみ使用します
+func UsernameFromAccessToken(accessToken string) (string, error) {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed access token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed access token payload: %w", err)
+	}
+	var claims struct {
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("malformed access token payload: %w", err)
+	}
+	if claims.Username == "" {
+		return "", fmt.Errorf("access token has no username claim")
+	}
+	return claims.Username, nil
+}
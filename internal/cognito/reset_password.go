@@ -7,7 +7,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 )
 
-func (s *Service) ResetPassword(email, confirmationCode, newPassword string) error {
+func (s *Service) ResetPassword(ctx context.Context, email, confirmationCode, newPassword string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.ResetPassword")
+	defer func() { endSpan(span, err) }()
+
 	secretHash, err := generateSecretHash(email, s.clientId)
 	if err != nil {
 		return fmt.Errorf("failed to generate secret hash: %v", err)
@@ -21,7 +24,7 @@ func (s *Service) ResetPassword(email, confirmationCode, newPassword string) err
 		Password:         aws.String(newPassword),
 	}
 
-	_, err = s.client.ConfirmForgotPassword(context.TODO(), input)
+	_, err = s.client.ConfirmForgotPassword(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to reset password: %w", err)
 	}
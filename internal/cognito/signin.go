@@ -2,19 +2,39 @@ package cognito
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"strings"
 	"time"
 )
 
-// SignIn 全てreturnする場合は*types.AuthenticationResultType
-func (s *Service) SignIn(email, password string) (string, error) {
-	// SRPオブジェクトの作成
+// NewPasswordChallenge はNEW_PASSWORD_REQUIREDチャレンジの状態
+// 管理者が作成したユーザーの初回サインインで、一時パスワードの変更を求められた場合に返されます
+// UsernameとSessionは次のRespondToNewPasswordChallengeに渡し、RequiredAttributesは新しいパスワードとともに入力が必要な属性です
+// Usernameはチャレンジを発行したユーザー名（USER_ID_FOR_SRP）で、メールアドレスでサインインした場合もsubなどのユーザー名になります
+type NewPasswordChallenge struct {
+	Username           string
+	Session            string
+	RequiredAttributes []string
+}
+
+// SignIn はSRP認証でサインインし、アクセストークン・IDトークン・リフレッシュトークンを返します
+// deviceに記憶済みデバイスを指定すると、DEVICE_SRP_AUTHでデバイスも認証します（MFAを省略できます）
+// 新しいデバイスが発行された場合はresult.NewDeviceMetadataが設定されるため、ConfirmDeviceで登録します
+// パスワードの変更が必要な場合はresultがnilで、NEW_PASSWORD_REQUIREDチャレンジを返します
+func (s *Service) SignIn(ctx context.Context, email, password string, device *DeviceCredentials) (result *types.AuthenticationResultType, challenge *NewPasswordChallenge, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.SignIn")
+	defer func() { endSpan(span, err) }()
+
+	// SRPオブジェクトの作成（乱数aとA = g^a mod Nの計算）
+	_, srpSpan := tracer.Start(ctx, "cognito.srp.NewCognitoSRP")
 	srp, err := NewCognitoSRP(email, password, s.poolId, s.clientId, s.clientSecret)
+	endSpan(srpSpan, err)
 	if err != nil {
-		return "", fmt.Errorf("failed to create SRP object: %w", err)
+		return nil, nil, fmt.Errorf("failed to create SRP object: %w", err)
 	}
 
 	// InitiateAuthリクエストを作成
@@ -23,17 +43,25 @@ func (s *Service) SignIn(email, password string) (string, error) {
 		AuthParameters: srp.GetAuthParams(),
 		ClientId:       aws.String(s.clientId),
 	}
+	if device != nil {
+		input.AuthParameters["DEVICE_KEY"] = device.Key
+	}
 
 	// InitiateAuthの呼び出し
-	output, err := s.client.InitiateAuth(context.TODO(), input)
+	output, err := s.client.InitiateAuth(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to initiate auth: %w", err)
+		return nil, nil, fmt.Errorf("failed to initiate auth: %w", err)
 	}
 
-	// チャレンジレスポンスを処理
+	// チャレンジレスポンスを処理（getPasswordAuthenticationKeyによる鍵の導出を含む）
+	_, verifierSpan := tracer.Start(ctx, "cognito.srp.PasswordVerifierChallenge")
 	challengeResponse, err := srp.PasswordVerifierChallenge(output.ChallengeParameters, time.Now())
+	endSpan(verifierSpan, err)
 	if err != nil {
-		return "", fmt.Errorf("failed to calculate challenge response: %w", err)
+		return nil, nil, fmt.Errorf("failed to calculate challenge response: %w", err)
+	}
+	if device != nil {
+		challengeResponse["DEVICE_KEY"] = device.Key
 	}
 
 	// チャレンジに応答
@@ -43,10 +71,120 @@ func (s *Service) SignIn(email, password string) (string, error) {
 		ClientId:           aws.String(s.clientId),
 	}
 
-	authResult, err := s.client.RespondToAuthChallenge(context.TODO(), respondInput)
+	authResult, err := s.client.RespondToAuthChallenge(ctx, respondInput)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to respond to auth challenge: %w", err)
+	}
+
+	if authResult.ChallengeName == types.ChallengeNameTypeDeviceSrpAuth && device != nil {
+		authResult, err = s.deviceSRPAuth(ctx, challengeResponse["USERNAME"], *device, authResult.Session)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if authResult.ChallengeName == types.ChallengeNameTypeNewPasswordRequired {
+		challenge := newPasswordChallenge(authResult)
+		if challenge.Username == "" {
+			challenge.Username = challengeResponse["USERNAME"]
+		}
+		return nil, challenge, nil
+	}
+	if authResult.AuthenticationResult == nil {
+		return nil, nil, fmt.Errorf("unexpected challenge: %s", authResult.ChallengeName)
+	}
+	return authResult.AuthenticationResult, nil, nil
+}
+
+// RespondToNewPasswordChallenge はNEW_PASSWORD_REQUIREDチャレンジに新しいパスワードと必須の属性で応答します
+// usernameはNewPasswordChallenge.Usernameで、USERNAMEとSECRET_HASHの両方に使用します
+// attributesのキーは属性名（例: name、phone_number）です
+func (s *Service) RespondToNewPasswordChallenge(ctx context.Context, username, session, newPassword string, attributes map[string]string) (result *types.AuthenticationResultType, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.RespondToNewPasswordChallenge")
+	defer func() { endSpan(span, err) }()
+
+	secretHash, err := generateSecretHash(username, s.clientId)
 	if err != nil {
-		return "", fmt.Errorf("failed to respond to auth challenge: %w", err)
+		return nil, fmt.Errorf("failed to generate secret hash: %v", err)
 	}
 
-	return *authResult.AuthenticationResult.AccessToken, nil
+	responses := map[string]string{
+		"USERNAME":     username,
+		"NEW_PASSWORD": newPassword,
+		"SECRET_HASH":  secretHash,
+	}
+	for name, value := range attributes {
+		responses["userAttributes."+name] = value
+	}
+	output, err := s.client.RespondToAuthChallenge(ctx, &cognitoidentityprovider.RespondToAuthChallengeInput{
+		ChallengeName:      types.ChallengeNameTypeNewPasswordRequired,
+		ChallengeResponses: responses,
+		ClientId:           aws.String(s.clientId),
+		Session:            aws.String(session),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to respond to new password challenge: %w", err)
+	}
+	if output.AuthenticationResult == nil {
+		return nil, fmt.Errorf("unexpected challenge: %s", output.ChallengeName)
+	}
+	return output.AuthenticationResult, nil
+}
+
+// newPasswordChallenge はNEW_PASSWORD_REQUIREDのチャレンジパラメーターからユーザー名と必須の属性を取り出します
+// requiredAttributesは "userAttributes.name" 形式の名前のJSON配列です
+func newPasswordChallenge(output *cognitoidentityprovider.RespondToAuthChallengeOutput) *NewPasswordChallenge {
+	challenge := &NewPasswordChallenge{
+		Username:           output.ChallengeParameters["USER_ID_FOR_SRP"],
+		Session:            aws.ToString(output.Session),
+		RequiredAttributes: []string{},
+	}
+	if challenge.Username == "" {
+		challenge.Username = output.ChallengeParameters["USERNAME"]
+	}
+	var required []string
+	if err := json.Unmarshal([]byte(output.ChallengeParameters["requiredAttributes"]), &required); err == nil {
+		for _, name := range required {
+			challenge.RequiredAttributes = append(challenge.RequiredAttributes, strings.TrimPrefix(name, "userAttributes."))
+		}
+	}
+	return challenge
+}
+
+// deviceSRPAuth はDEVICE_SRP_AUTHとDEVICE_PASSWORD_VERIFIERチャレンジに応答します
+// usernameはPASSWORD_VERIFIERで返されたCognito内部のユーザー名です
+func (s *Service) deviceSRPAuth(ctx context.Context, username string, device DeviceCredentials, session *string) (output *cognitoidentityprovider.RespondToAuthChallengeOutput, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.srp.DeviceSRPAuth")
+	defer func() { endSpan(span, err) }()
+
+	srp, err := NewDeviceSRP(username, device, s.poolId, s.clientId, s.clientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create device SRP object: %w", err)
+	}
+
+	output, err = s.client.RespondToAuthChallenge(ctx, &cognitoidentityprovider.RespondToAuthChallengeInput{
+		ChallengeName:      types.ChallengeNameTypeDeviceSrpAuth,
+		ChallengeResponses: srp.DeviceSRPAuthChallenge(device.Key),
+		ClientId:           aws.String(s.clientId),
+		Session:            session,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to respond to device SRP challenge: %w", err)
+	}
+
+	challengeResponse, err := srp.DevicePasswordVerifierChallenge(output.ChallengeParameters, device.Key, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate device challenge response: %w", err)
+	}
+
+	output, err = s.client.RespondToAuthChallenge(ctx, &cognitoidentityprovider.RespondToAuthChallengeInput{
+		ChallengeName:      types.ChallengeNameTypeDevicePasswordVerifier,
+		ChallengeResponses: challengeResponse,
+		ClientId:           aws.String(s.clientId),
+		Session:            output.Session,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to respond to device password verifier challenge: %w", err)
+	}
+	return output, nil
 }
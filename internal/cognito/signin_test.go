@@ -0,0 +1,33 @@
+package cognito
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+// NEW_PASSWORD_REQUIREDのチャレンジパラメーターから必須の属性名を取り出せることを確認
+func TestNewPasswordChallenge(t *testing.T) {
+	challenge := newPasswordChallenge(&cognitoidentityprovider.RespondToAuthChallengeOutput{
+		Session: aws.String("session"),
+		ChallengeParameters: map[string]string{
+			"USER_ID_FOR_SRP":    "a36036a8-9061-424d-a737-56d57dae7bc6",
+			"requiredAttributes": `["userAttributes.name","userAttributes.phone_number"]`,
+			"userAttributes":     `{"email":"user@example.com"}`,
+		},
+	})
+	assert.Equal(t, "a36036a8-9061-424d-a737-56d57dae7bc6", challenge.Username)
+	assert.Equal(t, "session", challenge.Session)
+	assert.Equal(t, []string{"name", "phone_number"}, challenge.RequiredAttributes)
+
+	// 必須の属性がない場合は空の配列を返す
+	challenge = newPasswordChallenge(&cognitoidentityprovider.RespondToAuthChallengeOutput{
+		Session:             aws.String("session"),
+		ChallengeParameters: map[string]string{"USERNAME": "staff", "requiredAttributes": "[]"},
+	})
+	assert.Equal(t, "staff", challenge.Username)
+	assert.Empty(t, challenge.RequiredAttributes)
+	assert.NotNil(t, challenge.RequiredAttributes)
+}
@@ -8,7 +8,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
 )
 
-func (s *Service) SignUp(email, password, phoneNumber, givenName, familyName string) error {
+func (s *Service) SignUp(ctx context.Context, email, password, phoneNumber, givenName, familyName string) (err error) {
+	ctx, span := tracer.Start(ctx, "cognito.SignUp")
+	defer func() { endSpan(span, err) }()
+
 	secretHash, err := generateSecretHash(email, s.clientId)
 	if err != nil {
 		return fmt.Errorf("failed to generate secret hash: %v", err)
@@ -27,7 +30,7 @@ func (s *Service) SignUp(email, password, phoneNumber, givenName, familyName str
 		},
 	}
 
-	_, err = s.client.SignUp(context.TODO(), input)
+	_, err = s.client.SignUp(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to sign up user: %w", err)
 	}
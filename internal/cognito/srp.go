@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"math/big"
 	"strings"
 	"time"
@@ -126,7 +127,7 @@ func (csrp *SRP) GetAuthParams() map[string]string {
 
 	secret, err := csrp.GetSecretHash(csrp.Username)
 	if err != nil {
-		fmt.Printf("Failed to generate SECRET_HASH: %v\n", err)
+		slog.Warn("Failed to generate SECRET_HASH", "error", err)
 	} else {
 		params["SECRET_HASH"] = secret
 	}
@@ -202,6 +203,123 @@ func (csrp *SRP) PasswordVerifierChallenge(challengeParms map[string]string, ts
 	return response, nil
 }
 
+// NewDeviceSRP は記憶済みデバイスでのDEVICE_SRP_AUTH用のSRPオブジェクトを作成
+// デバイスではユーザープール名の代わりにデバイスグループキーを、ユーザー名の代わりにデバイスキーを使用します
+func NewDeviceSRP(username string, device DeviceCredentials, poolId, clientId, clientSecret string) (*SRP, error) {
+	c, err := NewCognitoSRP(username, device.Password, poolId, clientId, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	c.PoolName = device.GroupKey
+	return c, nil
+}
+
+// DeviceSRPAuthChallenge はDEVICE_SRP_AUTHチャレンジに応答するChallengeResponsesを返却
+func (csrp *SRP) DeviceSRPAuthChallenge(deviceKey string) map[string]string {
+	response := map[string]string{
+		"USERNAME":   csrp.Username,
+		"DEVICE_KEY": deviceKey,
+		"SRP_A":      bigToHex(csrp.BigA),
+	}
+	if secret, err := csrp.GetSecretHash(csrp.Username); err == nil {
+		response["SECRET_HASH"] = secret
+	}
+	return response
+}
+
+// DevicePasswordVerifierChallenge はDEVICE_PASSWORD_VERIFIERチャレンジを完了するために使用するChallengeResponsesを返却
+func (csrp *SRP) DevicePasswordVerifierChallenge(challengeParms map[string]string, deviceKey string, ts time.Time) (map[string]string, error) {
+	var (
+		saltHex        = challengeParms["SALT"]
+		srpBHex        = challengeParms["SRP_B"]
+		secretBlockB64 = challengeParms["SECRET_BLOCK"]
+
+		timestamp = ts.In(time.UTC).Format("Mon Jan 2 03:04:05 MST 2006")
+	)
+
+	srpB, err := hexToBig(srpBHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert SRP_B to big.Int: %w", err)
+	}
+
+	salt, err := hexToBig(saltHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert SALT to big.Int: %w", err)
+	}
+
+	// デバイスの認証キーはデバイスグループキー + デバイスキー + ":" + デバイスパスワードから導出する
+	hkdf, err := csrp.getPasswordAuthenticationKey(deviceKey, csrp.Password, srpB, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device authentication key: %w", err)
+	}
+
+	secretBlockBytes, err := base64.StdEncoding.DecodeString(secretBlockB64)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode challenge parameter 'SECRET_BLOCK', %s", err.Error())
+	}
+
+	msg := csrp.PoolName + deviceKey + string(secretBlockBytes) + timestamp
+	hmacObj := hmac.New(sha256.New, hkdf)
+	hmacObj.Write([]byte(msg))
+	signature := base64.StdEncoding.EncodeToString(hmacObj.Sum(nil))
+
+	response := map[string]string{
+		"TIMESTAMP":                   timestamp,
+		"USERNAME":                    csrp.Username,
+		"DEVICE_KEY":                  deviceKey,
+		"PASSWORD_CLAIM_SECRET_BLOCK": secretBlockB64,
+		"PASSWORD_CLAIM_SIGNATURE":    signature,
+	}
+	if secret, err := csrp.GetSecretHash(csrp.Username); err == nil {
+		response["SECRET_HASH"] = secret
+	}
+
+	return response, nil
+}
+
+// GenerateDeviceVerifier はConfirmDeviceに渡すデバイスのパスワード検証子とソルトを生成
+// ユーザーのSRPと同じNとgを使用し、v = g^x mod N（x = H(salt | H(deviceGroupKey | deviceKey | ":" | password))）を計算します
+// 戻り値のpasswordはデバイスで保存し、DEVICE_SRP_AUTHで使用します
+func GenerateDeviceVerifier(deviceGroupKey, deviceKey string) (password, verifierB64, saltB64 string, err error) {
+	bigN, err := hexToBig(nHex)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to convert nHex to big.Int: %w", err)
+	}
+	g, err := hexToBig(gHex)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to convert gHex to big.Int: %w", err)
+	}
+
+	passwordBytes := make([]byte, 40)
+	if _, err := rand.Read(passwordBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate device password: %w", err)
+	}
+	password = base64.StdEncoding.EncodeToString(passwordBytes)
+
+	saltInt, err := getRandom(16)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to generate device salt: %w", err)
+	}
+	saltHex := padHex(saltInt.Text(16))
+
+	hashed := hashSha256([]byte(deviceGroupKey + deviceKey + ":" + password))
+	x, err := hexToBig(hexHash(saltHex + hashed))
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to calculate x value: %w", err)
+	}
+	verifier := big.NewInt(0).Exp(g, x, bigN)
+
+	verifierBytes, err := hex.DecodeString(padHex(verifier.Text(16)))
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to encode verifier: %w", err)
+	}
+	saltBytes, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to encode salt: %w", err)
+	}
+	return password, base64.StdEncoding.EncodeToString(verifierBytes), base64.StdEncoding.EncodeToString(saltBytes), nil
+}
+
 // generateRandomSmallA はランダムなa値を生成
 func (csrp *SRP) generateRandomSmallA() (*big.Int, error) {
 	randomLongInt, err := getRandom(128)
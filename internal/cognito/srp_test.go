@@ -0,0 +1,64 @@
+package cognito
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ConfirmDeviceで登録した検証子に対して、DEVICE_PASSWORD_VERIFIERの署名がサーバー側の計算と一致することを確認
+func TestDeviceSRP_VerifierRoundTrip(t *testing.T) {
+	const (
+		groupKey  = "-AbCdEfGh"
+		deviceKey = "ap-northeast-1_0f5c1a7e-1234-5678-9abc-def012345678"
+	)
+
+	password, verifierB64, saltB64, err := GenerateDeviceVerifier(groupKey, deviceKey)
+	assert.NoError(t, err)
+
+	verifierBytes, err := base64.StdEncoding.DecodeString(verifierB64)
+	assert.NoError(t, err)
+	saltBytes, err := base64.StdEncoding.DecodeString(saltB64)
+	assert.NoError(t, err)
+	v := new(big.Int).SetBytes(verifierBytes)
+	salt := new(big.Int).SetBytes(saltBytes)
+
+	device := DeviceCredentials{Key: deviceKey, GroupKey: groupKey, Password: password}
+	srp, err := NewDeviceSRP("user-sub", device, "ap-northeast-1_Pool", "client", "secret")
+	assert.NoError(t, err)
+
+	auth := srp.DeviceSRPAuthChallenge(deviceKey)
+	assert.Equal(t, deviceKey, auth["DEVICE_KEY"])
+	assert.Equal(t, "user-sub", auth["USERNAME"])
+	assert.NotEmpty(t, auth["SECRET_HASH"])
+
+	// サーバー側: B = k*v + g^b, S = (A * v^u)^b
+	b, err := getRandom(32)
+	assert.NoError(t, err)
+	bigB := new(big.Int).Add(new(big.Int).Mul(srp.K, v), new(big.Int).Exp(srp.G, b, srp.BigN))
+	bigB.Mod(bigB, srp.BigN)
+	u, err := calculateU(srp.BigA, bigB)
+	assert.NoError(t, err)
+	s := new(big.Int).Exp(new(big.Int).Mul(srp.BigA, new(big.Int).Exp(v, u, srp.BigN)), b, srp.BigN)
+	key := computeHKDF(padHex(s.Text(16)), padHex(bigToHex(u)))
+
+	secretBlock := base64.StdEncoding.EncodeToString([]byte("secret-block"))
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	resp, err := srp.DevicePasswordVerifierChallenge(map[string]string{
+		"SALT":         hex.EncodeToString(salt.Bytes()),
+		"SRP_B":        bigB.Text(16),
+		"SECRET_BLOCK": secretBlock,
+	}, deviceKey, ts)
+	assert.NoError(t, err)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(groupKey + deviceKey + "secret-block" + resp["TIMESTAMP"]))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), resp["PASSWORD_CLAIM_SIGNATURE"])
+	assert.Equal(t, deviceKey, resp["DEVICE_KEY"])
+}
@@ -0,0 +1,23 @@
+package cognito
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+)
+
+// GetCSVHeader はユーザープールのインポートジョブ（CreateUserImportJob）で使うCSVの列名を返します
+// 列はcognito:usernameとcognito:mfa_enabledのほか、ユーザープールに定義された属性です
+func (s *Service) GetCSVHeader(ctx context.Context) (header []string, err error) {
+	ctx, span := tracer.Start(ctx, "cognito.GetCSVHeader")
+	defer func() { endSpan(span, err) }()
+
+	output, err := s.client.GetCSVHeader(ctx, &cognitoidentityprovider.GetCSVHeaderInput{
+		UserPoolId: aws.String(s.poolId),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CSV header: %w", err)
+	}
+	return output.CSVHeader, nil
+}
@@ -0,0 +1,182 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Config はCORSの設定
+type Config struct {
+	// AllowedOrigins は許可するオリジン（例: "https://app.example.com"）
+	// "https://*.example.com" のようにサブドメインのワイルドカード、"*" ですべてのオリジンを指定できます
+	AllowedOrigins []string
+	// AllowCredentials はCookieや認証ヘッダー付きのリクエストを許可します。"*" とは併用できません
+	AllowCredentials bool
+	// AllowedHeaders はプリフライトで許可するリクエストヘッダー
+	AllowedHeaders []string
+	// ExposedHeaders はブラウザのスクリプトから参照を許可するレスポンスヘッダー
+	ExposedHeaders []string
+	// MaxAge はプリフライトの結果をブラウザがキャッシュする時間
+	MaxAge time.Duration
+}
+
+// Policy はmuxルーターに登録されたルートに対してCORSヘッダーを付与します
+type Policy struct {
+	cfg     Config
+	mu      sync.RWMutex
+	methods map[string][]string // パステンプレートごとの許可メソッド
+}
+
+// New は設定を検証してPolicyを作成します
+func New(cfg Config) (*Policy, error) {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil, fmt.Errorf("no allowed origins")
+	}
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" && cfg.AllowCredentials {
+			return nil, fmt.Errorf("wildcard origin cannot be used with credentials")
+		}
+	}
+	return &Policy{cfg: cfg, methods: map[string][]string{}}, nil
+}
+
+// Register は登録済みのルートからパスごとの許可メソッドを集め、プリフライト用のOPTIONSルートを追加します
+// すべてのルートを登録した後に呼び出します。呼び出すたびに許可メソッドをルーターのルートから作り直します
+func (p *Policy) Register(router *mux.Router) error {
+	seen := map[string]map[string]bool{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, m := range methods {
+			if m == http.MethodOptions {
+				continue
+			}
+			if seen[path] == nil {
+				seen[path] = map[string]bool{}
+			}
+			seen[path][m] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	methods := make(map[string][]string, len(seen))
+	for path, set := range seen {
+		for m := range set {
+			methods[path] = append(methods[path], m)
+		}
+		sort.Strings(methods[path])
+		// 実際の応答はMiddlewareが返すため、ここではルートにマッチさせるだけ
+		router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodOptions)
+	}
+
+	p.mu.Lock()
+	p.methods = methods
+	p.mu.Unlock()
+	return nil
+}
+
+// Middleware はmuxルーターに登録するCORSミドルウェア
+// プリフライトはここで応答するため、レート制限などより外側に登録します
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		w.Header().Add("Vary", "Origin")
+		allowed := p.allowOrigin(origin)
+		if allowed {
+			if p.cfg.AllowCredentials || !p.wildcard() {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+			if p.cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if !preflight {
+			if allowed && len(p.cfg.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.cfg.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// 許可されていないオリジンにはCORSヘッダーなしで応答し、ブラウザ側で拒否させる
+		if allowed {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.routeMethods(r), ", "))
+			if len(p.cfg.AllowedHeaders) > 0 {
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(p.cfg.AllowedHeaders, ", "))
+			}
+			if p.cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.cfg.MaxAge.Seconds())))
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowOrigin はオリジンが許可リストに含まれるかを返します
+func (p *Policy) allowOrigin(origin string) bool {
+	for _, o := range p.cfg.AllowedOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+		if scheme, domain, ok := strings.Cut(o, "://*."); ok {
+			rest, found := strings.CutPrefix(strings.ToLower(origin), strings.ToLower(scheme)+"://")
+			if found && strings.HasSuffix(rest, "."+strings.ToLower(domain)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Policy) wildcard() bool {
+	for _, o := range p.cfg.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// routeMethods はリクエストにマッチしたルートのパスで許可されているメソッドを返します
+func (p *Policy) routeMethods(r *http.Request) []string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.methods[path]
+}
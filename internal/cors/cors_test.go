@@ -0,0 +1,126 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRouter(t *testing.T, cfg Config) *mux.Router {
+	p, err := New(cfg)
+	assert.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.Use(p.Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r.HandleFunc("/signin", ok).Methods("POST")
+	r.HandleFunc("/users/{id}", ok).Methods("GET")
+	r.HandleFunc("/users/{id}", ok).Methods("DELETE")
+	assert.NoError(t, p.Register(r))
+	return r
+}
+
+func preflight(path, origin, method string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	return req
+}
+
+// プリフライトに対してルートごとの許可メソッドとキャッシュ時間を返すことを確認
+func TestPolicy_Preflight(t *testing.T) {
+	r := newRouter(t, Config{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		AllowedHeaders:   []string{"Content-Type"},
+		MaxAge:           10 * time.Minute,
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, preflight("/users/123", "https://app.example.com", "DELETE"))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "DELETE, GET", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, preflight("/signin", "https://app.example.com", "POST"))
+	assert.Equal(t, "POST", rec.Header().Get("Access-Control-Allow-Methods"))
+}
+
+// 許可されていないオリジンにはCORSヘッダーを付与しないことを確認
+func TestPolicy_DisallowedOrigin(t *testing.T) {
+	r := newRouter(t, Config{AllowedOrigins: []string{"https://app.example.com"}})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, preflight("/signin", "https://evil.example.net", "POST"))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+
+	req := httptest.NewRequest(http.MethodPost, "/signin", nil)
+	req.Header.Set("Origin", "https://evil.example.net")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rec.Header().Get("Vary"))
+}
+
+// サブドメインのワイルドカードと "*" の扱いを確認
+func TestPolicy_WildcardOrigins(t *testing.T) {
+	r := newRouter(t, Config{AllowedOrigins: []string{"https://*.example.com"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/signin", nil)
+	req.Header.Set("Origin", "https://preview.example.com")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "https://preview.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	req.Header.Set("Origin", "https://example.com.evil.net")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	r = newRouter(t, Config{AllowedOrigins: []string{"*"}})
+	req.Header.Set("Origin", "https://any.example.org")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	_, err := New(Config{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	assert.Error(t, err)
+}
+
+// Registerを繰り返し呼び出しても許可メソッドが重複しないことを確認
+func TestPolicy_RegisterTwice(t *testing.T) {
+	p, err := New(Config{AllowedOrigins: []string{"https://app.example.com"}})
+	assert.NoError(t, err)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	var r *mux.Router
+	for i := 0; i < 2; i++ {
+		r = mux.NewRouter()
+		r.Use(p.Middleware)
+		r.HandleFunc("/signin", ok).Methods("POST")
+		r.HandleFunc("/users/{id}", ok).Methods("GET", "DELETE")
+		r.HandleFunc("/users/{id}", ok).Methods("GET")
+		assert.NoError(t, p.Register(r))
+	}
+	// 同じルーターに再登録してもOPTIONSを許可メソッドに含めない
+	assert.NoError(t, p.Register(r))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, preflight("/signin", "https://app.example.com", "POST"))
+	assert.Equal(t, "POST", rec.Header().Get("Access-Control-Allow-Methods"))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, preflight("/users/1", "https://app.example.com", "GET"))
+	assert.Equal(t, "DELETE, GET", rec.Header().Get("Access-Control-Allow-Methods"))
+}
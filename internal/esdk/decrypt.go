@@ -0,0 +1,216 @@
+// Package esdk はAWS Encryption SDKのメッセージ形式で暗号化されたデータを復号します
+// CognitoのカスタムEメール送信者トリガーとカスタムSMS送信者トリガーが渡す確認コードの復号に使います
+package esdk
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// PublicKeyContextKey は署名の検証に使う公開鍵を格納する暗号化コンテキストのキー
+const PublicKeyContextKey = "aws-crypto-public-key"
+
+// ErrNoDataKey はKeyProviderが復号できるデータキーがない場合のエラー
+var ErrNoDataKey = errors.New("no data key could be decrypted")
+
+// KeyProvider はメッセージのヘッダーに含まれる暗号化データキーを復号します
+// 復号できるデータキーがない場合はErrNoDataKeyを返します
+type KeyProvider interface {
+	DecryptDataKey(ctx context.Context, keys []EncryptedDataKey, encryptionContext map[string]string) ([]byte, error)
+}
+
+const (
+	frameAAD       = "AWSKMSEncryptionClient Frame"
+	finalFrameAAD  = "AWSKMSEncryptionClient Final Frame"
+	singleBlockAAD = "AWSKMSEncryptionClient Single Block"
+	finalFrameMark = 0xFFFFFFFF
+)
+
+// Decrypt はメッセージを復号し、平文と暗号化コンテキストを返します
+// ヘッダーの認証、キーコミットメント、署名を検証してから平文を返します
+func Decrypt(ctx context.Context, provider KeyProvider, message []byte) ([]byte, map[string]string, error) {
+	r := &reader{b: message}
+	h, err := parseHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataKey, err := provider.DecryptDataKey(ctx, h.dataKeys, h.context)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(dataKey) != h.suite.keyLen {
+		return nil, nil, fmt.Errorf("data key length %d does not match algorithm suite", len(dataKey))
+	}
+
+	key, err := deriveKey(h, dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := aead.Open(nil, h.authIV, h.authTag, h.raw); err != nil {
+		return nil, nil, errors.New("header authentication failed")
+	}
+
+	plaintext, err := decryptBody(r, h, aead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if h.suite.curve != nil {
+		if err := verifyFooter(r, h); err != nil {
+			return nil, nil, err
+		}
+	}
+	if r.pos != len(message) {
+		return nil, nil, fmt.Errorf("%w: trailing data", ErrMalformed)
+	}
+	return plaintext, h.context, nil
+}
+
+// deriveKey はデータキーからコンテンツの暗号化キーを導出し、キーコミットメントを検証します
+func deriveKey(h *header, dataKey []byte) ([]byte, error) {
+	s := h.suite
+	suiteID := binary.BigEndian.AppendUint16(nil, s.id)
+
+	if !s.commit {
+		key := make([]byte, s.keyLen)
+		if _, err := io.ReadFull(hkdf.New(s.kdf, dataKey, nil, append(suiteID, h.messageID...)), key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+
+	key := make([]byte, s.keyLen)
+	if _, err := io.ReadFull(hkdf.New(s.kdf, dataKey, h.messageID, append(suiteID, "DERIVEKEY"...)), key); err != nil {
+		return nil, err
+	}
+	commitment := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(s.kdf, dataKey, h.messageID, []byte("COMMITKEY")), commitment); err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(commitment, h.commitment) != 1 {
+		return nil, errors.New("key commitment does not match")
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// bodyAAD はフレームの認証に使う追加データを返します
+func bodyAAD(messageID []byte, kind string, seq uint32, length uint64) []byte {
+	aad := append([]byte{}, messageID...)
+	aad = append(aad, kind...)
+	aad = binary.BigEndian.AppendUint32(aad, seq)
+	return binary.BigEndian.AppendUint64(aad, length)
+}
+
+// decryptBody はフレーム形式または非フレーム形式の本文を復号します
+func decryptBody(r *reader, h *header, aead cipher.AEAD) ([]byte, error) {
+	if h.contentType == contentNonFramed {
+		iv := r.bytes(ivLen)
+		length := r.u64()
+		if r.err != nil || length > uint64(len(r.b)-r.pos) {
+			return nil, ErrMalformed
+		}
+		content := r.bytes(int(length) + tagLen)
+		if r.err != nil {
+			return nil, r.err
+		}
+		return open(aead, iv, content, bodyAAD(h.messageID, singleBlockAAD, 1, length))
+	}
+
+	if h.frameLen == 0 {
+		return nil, fmt.Errorf("%w: frame length is zero", ErrMalformed)
+	}
+	var plaintext []byte
+	for seq := uint32(1); ; seq++ {
+		n := r.u32()
+		final := n == finalFrameMark
+		if final {
+			n = r.u32()
+		}
+		if r.err == nil && n != seq {
+			return nil, fmt.Errorf("%w: unexpected frame sequence", ErrMalformed)
+		}
+		iv := r.bytes(ivLen)
+		length := uint64(h.frameLen)
+		kind := frameAAD
+		if final {
+			kind = finalFrameAAD
+			if length = uint64(r.u32()); length > uint64(h.frameLen) {
+				return nil, fmt.Errorf("%w: final frame is too long", ErrMalformed)
+			}
+		}
+		content := r.bytes(int(length) + tagLen)
+		if r.err != nil {
+			return nil, r.err
+		}
+		frame, err := open(aead, iv, content, bodyAAD(h.messageID, kind, seq, length))
+		if err != nil {
+			return nil, err
+		}
+		plaintext = append(plaintext, frame...)
+		if final {
+			return plaintext, nil
+		}
+	}
+}
+
+func open(aead cipher.AEAD, iv, content, aad []byte) ([]byte, error) {
+	plaintext, err := aead.Open(nil, iv, content, aad)
+	if err != nil {
+		return nil, errors.New("content authentication failed")
+	}
+	return plaintext, nil
+}
+
+// verifyFooter は暗号化コンテキストの公開鍵でヘッダーと本文の署名を検証します
+func verifyFooter(r *reader, h *header) error {
+	signed := r.b[:r.pos]
+	signature := r.bytes(int(r.u16()))
+	if r.err != nil {
+		return fmt.Errorf("%w: missing signature", ErrMalformed)
+	}
+
+	encoded, ok := h.context[PublicKeyContextKey]
+	if !ok {
+		return fmt.Errorf("%w: missing public key", ErrMalformed)
+	}
+	point, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: invalid public key", ErrMalformed)
+	}
+	x, y := elliptic.UnmarshalCompressed(h.suite.curve, point)
+	if x == nil {
+		return fmt.Errorf("%w: invalid public key", ErrMalformed)
+	}
+	pub := &ecdsa.PublicKey{Curve: h.suite.curve, X: x, Y: y}
+
+	digest := h.suite.sigHash.New()
+	digest.Write(signed)
+	if !ecdsa.VerifyASN1(pub, digest.Sum(nil), signature) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
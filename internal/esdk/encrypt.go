@@ -0,0 +1,173 @@
+package esdk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// DefaultSuite はEncryptが使うアルゴリズムスイート（キーコミットメントと署名あり）
+const DefaultSuite uint16 = 0x0578
+
+// defaultFrameLen はEncryptが使うフレームの長さ
+const defaultFrameLen = 4096
+
+// Encrypt は平文をDefaultSuiteで暗号化し、データキーをこのキーで暗号化したメッセージを返します
+// 開発やテストでトリガーのイベントを作成するために使います
+func (p *RawAESProvider) Encrypt(plaintext []byte, encryptionContext map[string]string) ([]byte, error) {
+	return p.encrypt(suites[DefaultSuite], plaintext, encryptionContext, defaultFrameLen)
+}
+
+// encrypt はメッセージを暗号化します。frameLen が0の場合は非フレーム形式の本文を作成します
+func (p *RawAESProvider) encrypt(s *suite, plaintext []byte, encryptionContext map[string]string, frameLen uint32) ([]byte, error) {
+	ctx := maps.Clone(encryptionContext)
+	if ctx == nil {
+		ctx = map[string]string{}
+	}
+
+	var signer *ecdsa.PrivateKey
+	if s.curve != nil {
+		var err error
+		if signer, err = ecdsa.GenerateKey(s.curve, rand.Reader); err != nil {
+			return nil, err
+		}
+		ctx[PublicKeyContextKey] = base64.StdEncoding.EncodeToString(elliptic.MarshalCompressed(s.curve, signer.X, signer.Y))
+	}
+
+	dataKey, err := randomBytes(s.keyLen)
+	if err != nil {
+		return nil, err
+	}
+	edk, err := p.wrap(dataKey, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &header{suite: s}
+	if s.version == 1 {
+		h.messageID, err = randomBytes(16)
+	} else {
+		h.messageID, err = randomBytes(32)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := []byte{s.version}
+	if s.version == 1 {
+		b = append(b, 0x80)
+	}
+	b = binary.BigEndian.AppendUint16(b, s.id)
+	b = append(b, h.messageID...)
+	aad := serializeContext(ctx)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(aad)))
+	b = append(b, aad...)
+	b = binary.BigEndian.AppendUint16(b, 1)
+	for _, field := range [][]byte{[]byte(edk.ProviderID), edk.ProviderInfo, edk.Ciphertext} {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(field)))
+		b = append(b, field...)
+	}
+	if frameLen == 0 {
+		b = append(b, contentNonFramed)
+	} else {
+		b = append(b, contentFramed)
+	}
+	if s.version == 1 {
+		b = append(b, 0, 0, 0, 0, ivLen)
+	}
+	b = binary.BigEndian.AppendUint32(b, frameLen)
+	if s.commit {
+		h.commitment = make([]byte, 32)
+		if _, err := io.ReadFull(hkdf.New(s.kdf, dataKey, h.messageID, []byte("COMMITKEY")), h.commitment); err != nil {
+			return nil, err
+		}
+		b = append(b, h.commitment...)
+	}
+
+	key, err := deriveKey(h, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	// メッセージ形式2のヘッダーの認証は0で埋めたIVを使い、IVを書き込まない
+	authIV := make([]byte, ivLen)
+	if s.version == 1 {
+		if authIV, err = randomBytes(ivLen); err != nil {
+			return nil, err
+		}
+	}
+	tag := aead.Seal(nil, authIV, nil, b)
+	if s.version == 1 {
+		b = append(b, authIV...)
+	}
+	b = append(b, tag...)
+
+	seal := func(kind string, seq uint32, content []byte) error {
+		iv, err := randomBytes(ivLen)
+		if err != nil {
+			return err
+		}
+		if kind == finalFrameAAD {
+			b = binary.BigEndian.AppendUint32(b, finalFrameMark)
+		}
+		if kind != singleBlockAAD {
+			b = binary.BigEndian.AppendUint32(b, seq)
+		}
+		b = append(b, iv...)
+		switch kind {
+		case finalFrameAAD:
+			b = binary.BigEndian.AppendUint32(b, uint32(len(content)))
+		case singleBlockAAD:
+			b = binary.BigEndian.AppendUint64(b, uint64(len(content)))
+		}
+		b = aead.Seal(b, iv, content, bodyAAD(h.messageID, kind, seq, uint64(len(content))))
+		return nil
+	}
+	if frameLen == 0 {
+		if err := seal(singleBlockAAD, 1, plaintext); err != nil {
+			return nil, err
+		}
+	} else {
+		rest := plaintext
+		seq := uint32(1)
+		for ; len(rest) >= int(frameLen); seq++ {
+			if err := seal(frameAAD, seq, rest[:frameLen]); err != nil {
+				return nil, err
+			}
+			rest = rest[frameLen:]
+		}
+		if err := seal(finalFrameAAD, seq, rest); err != nil {
+			return nil, err
+		}
+	}
+
+	if signer != nil {
+		digest := s.sigHash.New()
+		digest.Write(b)
+		sig, err := ecdsa.SignASN1(rand.Reader, signer, digest.Sum(nil))
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign message: %w", err)
+		}
+		b = binary.BigEndian.AppendUint16(b, uint16(len(sig)))
+		b = append(b, sig...)
+	}
+	return b, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
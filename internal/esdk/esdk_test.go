@@ -0,0 +1,120 @@
+package esdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/stretchr/testify/assert"
+)
+
+func testProvider(t *testing.T) *RawAESProvider {
+	key, err := randomBytes(32)
+	assert.NoError(t, err)
+	return &RawAESProvider{Namespace: "cognito-test", Name: "code-key", Key: key}
+}
+
+// TestDecrypt はアルゴリズムスイートと本文の形式ごとに復号できることを確認します
+func TestDecrypt(t *testing.T) {
+	p := testProvider(t)
+	encCtx := map[string]string{"userpool-id": "ap-northeast-1_example"}
+	plaintext := []byte("123456")
+
+	cases := []struct {
+		name     string
+		suite    uint16
+		frameLen uint32
+	}{
+		{"committing signed framed", 0x0578, 4096},
+		{"committing framed multiple frames", 0x0478, 4},
+		{"committing exact frame boundary", 0x0478, 3},
+		{"hkdf signed p256", 0x0214, 4096},
+		{"hkdf signed p384 non-framed", 0x0378, 0},
+		{"hkdf aes-192", 0x0146, 4096},
+		{"hkdf aes-128 non-framed", 0x0114, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			message, err := p.encrypt(suites[c.suite], plaintext, encCtx, c.frameLen)
+			assert.NoError(t, err)
+
+			got, ctx, err := Decrypt(context.Background(), p, message)
+			assert.NoError(t, err)
+			assert.Equal(t, plaintext, got)
+			assert.Equal(t, "ap-northeast-1_example", ctx["userpool-id"])
+		})
+	}
+}
+
+// TestDecryptRejectsTampering は改ざんされたメッセージや異なるキーを拒否することを確認します
+func TestDecryptRejectsTampering(t *testing.T) {
+	p := testProvider(t)
+	message, err := p.Encrypt([]byte("123456"), nil)
+	assert.NoError(t, err)
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, _, err := Decrypt(context.Background(), testProvider(t), message)
+		assert.ErrorIs(t, err, ErrNoDataKey)
+	})
+
+	t.Run("modified byte", func(t *testing.T) {
+		// ヘッダー、本文、署名のいずれを書き換えても検証に失敗する
+		for _, i := range []int{4, len(message) / 2, len(message) - 1} {
+			tampered := bytes.Clone(message)
+			tampered[i] ^= 0x01
+			_, _, err := Decrypt(context.Background(), p, tampered)
+			assert.Error(t, err, "offset %d", i)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, _, err := Decrypt(context.Background(), p, message[:len(message)-10])
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported suite", func(t *testing.T) {
+		// 未知のスイートと、KDFを使わない非推奨のスイート（メッセージ形式1）を拒否する
+		v1, err := p.encrypt(suites[0x0178], []byte("123456"), nil, 0)
+		assert.NoError(t, err)
+		for _, id := range []uint16{0x9999, 0x0014, 0x0046, 0x0078} {
+			tampered := bytes.Clone(v1)
+			// 形式1ではバージョンと種類の後ろにスイートが続く
+			binary.BigEndian.PutUint16(tampered[2:4], id)
+			_, _, err := Decrypt(context.Background(), p, tampered)
+			assert.ErrorContains(t, err, "unsupported algorithm suite", "suite %04x", id)
+		}
+	})
+}
+
+type fakeKMS struct {
+	input *kms.DecryptInput
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	f.input = params
+	return &kms.DecryptOutput{Plaintext: []byte("data-key")}, nil
+}
+
+// TestKMSProvider はKMSのデータキーだけをキーのARNと暗号化コンテキストを指定して復号することを確認します
+func TestKMSProvider(t *testing.T) {
+	arn := "arn:aws:kms:ap-northeast-1:123456789012:key/example"
+	client := &fakeKMS{}
+	p := &KMSProvider{Client: client, KeyIDs: []string{arn}}
+
+	keys := []EncryptedDataKey{
+		{ProviderID: "cognito-test", ProviderInfo: []byte("code-key"), Ciphertext: []byte("raw")},
+		{ProviderID: KMSProviderID, ProviderInfo: []byte("arn:aws:kms:ap-northeast-1:123456789012:key/other"), Ciphertext: []byte("other")},
+		{ProviderID: KMSProviderID, ProviderInfo: []byte(arn), Ciphertext: []byte("blob")},
+	}
+	key, err := p.DecryptDataKey(context.Background(), keys, map[string]string{"userpool-id": "example"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("data-key"), key)
+	assert.Equal(t, arn, *client.input.KeyId)
+	assert.Equal(t, []byte("blob"), client.input.CiphertextBlob)
+	assert.Equal(t, "example", client.input.EncryptionContext["userpool-id"])
+
+	_, err = p.DecryptDataKey(context.Background(), keys[:2], nil)
+	assert.ErrorIs(t, err, ErrNoDataKey)
+}
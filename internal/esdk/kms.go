@@ -0,0 +1,50 @@
+package esdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// KMSProviderID はKMSで暗号化したデータキーのプロバイダーID
+const KMSProviderID = "aws-kms"
+
+// KMSClient はKMSProviderが使うKMSのAPI
+type KMSClient interface {
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// KMSProvider はKMSでデータキーを復号するKeyProvider
+type KMSProvider struct {
+	Client KMSClient
+	// KeyIDs が空でない場合は、プロバイダー情報（キーのARN）が一致するデータキーだけを復号します
+	KeyIDs []string
+}
+
+// DecryptDataKey はKMSのデータキーを順に復号します
+func (p *KMSProvider) DecryptDataKey(ctx context.Context, keys []EncryptedDataKey, encryptionContext map[string]string) ([]byte, error) {
+	var lastErr error
+	for _, k := range keys {
+		keyID := string(k.ProviderInfo)
+		if k.ProviderID != KMSProviderID || (len(p.KeyIDs) > 0 && !slices.Contains(p.KeyIDs, keyID)) {
+			continue
+		}
+		out, err := p.Client.Decrypt(ctx, &kms.DecryptInput{
+			KeyId:             aws.String(keyID),
+			CiphertextBlob:    k.Ciphertext,
+			EncryptionContext: encryptionContext,
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return out.Plaintext, nil
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoDataKey, lastErr)
+	}
+	return nil, ErrNoDataKey
+}
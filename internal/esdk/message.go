@@ -0,0 +1,201 @@
+package esdk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrMalformed はメッセージの形式が正しくない場合のエラー
+var ErrMalformed = errors.New("malformed encryption sdk message")
+
+// EncryptedDataKey はメッセージのヘッダーに含まれる暗号化されたデータキー
+type EncryptedDataKey struct {
+	ProviderID   string // "aws-kms" やRaw AESキーの名前空間
+	ProviderInfo []byte // KMSキーのARNなど
+	Ciphertext   []byte
+}
+
+const (
+	contentNonFramed byte = 0x01
+	contentFramed    byte = 0x02
+)
+
+// header はメッセージのヘッダー
+type header struct {
+	suite       *suite
+	messageID   []byte
+	context     map[string]string
+	dataKeys    []EncryptedDataKey
+	contentType byte
+	frameLen    uint32
+	commitment  []byte // メッセージ形式2のキーコミットメント
+	authIV      []byte // メッセージ形式1のみ。形式2では0で埋めたIVを使います
+	authTag     []byte
+	raw         []byte // 認証の対象となるヘッダーのバイト列
+}
+
+// reader はビッグエンディアンのフィールドを順に読み取ります。範囲外を読んだ場合はerrを設定します
+type reader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.b) {
+		r.err = ErrMalformed
+		return nil
+	}
+	v := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return v
+}
+
+func (r *reader) u8() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) u64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// parseHeader はメッセージのヘッダーを読み取ります
+func parseHeader(r *reader) (*header, error) {
+	start := r.pos
+	h := &header{}
+
+	version := r.u8()
+	switch version {
+	case 1:
+		if r.u8() != 0x80 {
+			return nil, fmt.Errorf("%w: unknown message type", ErrMalformed)
+		}
+	case 2:
+	default:
+		return nil, fmt.Errorf("%w: unsupported message version %d", ErrMalformed, version)
+	}
+
+	id := r.u16()
+	s, ok := suites[id]
+	if r.err == nil && (!ok || s.version != version) {
+		return nil, fmt.Errorf("unsupported algorithm suite 0x%04x", id)
+	}
+	h.suite = s
+
+	if version == 1 {
+		h.messageID = r.bytes(16)
+	} else {
+		h.messageID = r.bytes(32)
+	}
+
+	aad := r.bytes(int(r.u16()))
+	var err error
+	if h.context, err = parseContext(aad); err != nil {
+		return nil, err
+	}
+
+	count := int(r.u16())
+	for i := 0; i < count && r.err == nil; i++ {
+		h.dataKeys = append(h.dataKeys, EncryptedDataKey{
+			ProviderID:   string(r.bytes(int(r.u16()))),
+			ProviderInfo: r.bytes(int(r.u16())),
+			Ciphertext:   r.bytes(int(r.u16())),
+		})
+	}
+
+	h.contentType = r.u8()
+	if version == 1 {
+		if reserved := r.u32(); reserved != 0 {
+			return nil, fmt.Errorf("%w: reserved field is not zero", ErrMalformed)
+		}
+		if n := r.u8(); r.err == nil && n != ivLen {
+			return nil, fmt.Errorf("%w: unexpected IV length %d", ErrMalformed, n)
+		}
+	}
+	h.frameLen = r.u32()
+	if h.suite != nil && h.suite.commit {
+		h.commitment = r.bytes(32)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	h.raw = r.b[start:r.pos]
+
+	if version == 1 {
+		h.authIV = r.bytes(ivLen)
+	} else {
+		h.authIV = make([]byte, ivLen)
+	}
+	h.authTag = r.bytes(tagLen)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if h.contentType != contentNonFramed && h.contentType != contentFramed {
+		return nil, fmt.Errorf("%w: unknown content type %d", ErrMalformed, h.contentType)
+	}
+	if len(h.dataKeys) == 0 {
+		return nil, fmt.Errorf("%w: no encrypted data keys", ErrMalformed)
+	}
+	return h, nil
+}
+
+// parseContext はヘッダーのAADから暗号化コンテキストを読み取ります
+func parseContext(aad []byte) (map[string]string, error) {
+	ctx := map[string]string{}
+	if len(aad) == 0 {
+		return ctx, nil
+	}
+	r := &reader{b: aad}
+	count := int(r.u16())
+	for i := 0; i < count && r.err == nil; i++ {
+		key := string(r.bytes(int(r.u16())))
+		ctx[key] = string(r.bytes(int(r.u16())))
+	}
+	if r.err != nil || r.pos != len(aad) {
+		return nil, fmt.Errorf("%w: invalid encryption context", ErrMalformed)
+	}
+	return ctx, nil
+}
+
+// serializeContext は暗号化コンテキストをキーの順に並べて直列化します。空の場合は空のバイト列を返します
+func serializeContext(ctx map[string]string) []byte {
+	if len(ctx) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(ctx))
+	for k := range ctx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(keys)))
+	for _, k := range keys {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(k)))
+		b = append(b, k...)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(ctx[k])))
+		b = append(b, ctx[k]...)
+	}
+	return b
+}
@@ -0,0 +1,61 @@
+package esdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+)
+
+// RawAESProvider はローカルのAESキーでデータキーを復号するKeyProvider（Raw AESキーリング）
+// 開発やテストでKMSを使わずにメッセージを復号する場合に使います
+type RawAESProvider struct {
+	Namespace string // 暗号化データキーのプロバイダーID
+	Name      string // キーの名前
+	Key       []byte // 16、24、32バイトのAESキー
+}
+
+// DecryptDataKey は名前空間と名前が一致するデータキーを順に復号します
+// プロバイダー情報はキーの名前、タグの長さ（ビット）、IVの長さ、IVの順に並びます
+func (p *RawAESProvider) DecryptDataKey(ctx context.Context, keys []EncryptedDataKey, encryptionContext map[string]string) ([]byte, error) {
+	aad := serializeContext(encryptionContext)
+	for _, k := range keys {
+		if k.ProviderID != p.Namespace || !bytes.HasPrefix(k.ProviderInfo, []byte(p.Name)) {
+			continue
+		}
+		info := k.ProviderInfo[len(p.Name):]
+		if len(info) != 8+ivLen ||
+			binary.BigEndian.Uint32(info[0:4]) != tagLen*8 ||
+			binary.BigEndian.Uint32(info[4:8]) != ivLen {
+			continue
+		}
+		aead, err := newGCM(p.Key)
+		if err != nil {
+			return nil, err
+		}
+		dataKey, err := aead.Open(nil, info[8:], k.Ciphertext, aad)
+		if err != nil {
+			continue
+		}
+		return dataKey, nil
+	}
+	return nil, ErrNoDataKey
+}
+
+// wrap はデータキーをこのキーで暗号化します
+func (p *RawAESProvider) wrap(dataKey []byte, encryptionContext map[string]string) (EncryptedDataKey, error) {
+	iv, err := randomBytes(ivLen)
+	if err != nil {
+		return EncryptedDataKey{}, err
+	}
+	aead, err := newGCM(p.Key)
+	if err != nil {
+		return EncryptedDataKey{}, err
+	}
+	info := append([]byte(p.Name), binary.BigEndian.AppendUint32(nil, tagLen*8)...)
+	info = binary.BigEndian.AppendUint32(info, ivLen)
+	return EncryptedDataKey{
+		ProviderID:   p.Namespace,
+		ProviderInfo: append(info, iv...),
+		Ciphertext:   aead.Seal(nil, iv, dataKey, serializeContext(encryptionContext)),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package esdk
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+)
+
+// suite はAWS Encryption SDKのアルゴリズムスイート
+// いずれもAES-GCM（IV 12バイト、認証タグ 16バイト）でコンテンツを暗号化します
+type suite struct {
+	id      uint16
+	version byte             // メッセージ形式のバージョン
+	keyLen  int              // データキーと暗号化キーの長さ
+	kdf     func() hash.Hash // データキーから暗号化キーを導出するHKDFのハッシュ
+	commit  bool             // キーコミットメントの有無（メッセージ形式2）
+	curve   elliptic.Curve   // nilの場合は署名なし
+	sigHash crypto.Hash
+}
+
+const (
+	ivLen  = 12
+	tagLen = 16
+)
+
+// suites は復号できるアルゴリズムスイート
+// データキーをそのまま暗号化キーに使う非推奨のスイート（0x0014、0x0046、0x0078）は受け付けません
+var suites = map[uint16]*suite{
+	0x0578: {id: 0x0578, version: 2, keyLen: 32, kdf: sha512.New, commit: true, curve: elliptic.P384(), sigHash: crypto.SHA384},
+	0x0478: {id: 0x0478, version: 2, keyLen: 32, kdf: sha512.New, commit: true},
+	0x0378: {id: 0x0378, version: 1, keyLen: 32, kdf: sha512.New384, curve: elliptic.P384(), sigHash: crypto.SHA384},
+	0x0346: {id: 0x0346, version: 1, keyLen: 24, kdf: sha512.New384, curve: elliptic.P384(), sigHash: crypto.SHA384},
+	0x0214: {id: 0x0214, version: 1, keyLen: 16, kdf: sha256.New, curve: elliptic.P256(), sigHash: crypto.SHA256},
+	0x0178: {id: 0x0178, version: 1, keyLen: 32, kdf: sha256.New},
+	0x0146: {id: 0x0146, version: 1, keyLen: 24, kdf: sha256.New},
+	0x0114: {id: 0x0114, version: 1, keyLen: 16, kdf: sha256.New},
+}
@@ -0,0 +1,142 @@
+package esdk
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// vectorsDir はaws-encryption-sdk-test-vectorsの復号マニフェストを置くディレクトリ
+// https://github.com/awslabs/aws-encryption-sdk-test-vectors の vectors/awses-decrypt 以下の
+// いずれかのディレクトリ（manifest.json、keys.json、ciphertexts、plaintexts）を展開して配置します
+var vectorsDir = filepath.Join("testdata", "awses-decrypt")
+
+// decryptManifest はawses-decryptマニフェスト（バージョン1、2）
+type decryptManifest struct {
+	Keys  string `json:"keys"`
+	Tests map[string]struct {
+		Ciphertext string `json:"ciphertext"`
+		Plaintext  string `json:"plaintext"` // バージョン1
+		Result     struct {
+			Output *struct {
+				Plaintext string `json:"plaintext"`
+			} `json:"output"`
+			Error json.RawMessage `json:"error"`
+		} `json:"result"` // バージョン2
+		MasterKeys []struct {
+			Type                string `json:"type"`
+			Key                 string `json:"key"`
+			ProviderID          string `json:"provider-id"`
+			EncryptionAlgorithm string `json:"encryption-algorithm"`
+		} `json:"master-keys"`
+	} `json:"tests"`
+}
+
+// keyManifest はマニフェストが参照するキーの定義
+type keyManifest struct {
+	Keys map[string]struct {
+		Type      string `json:"type"`
+		Algorithm string `json:"algorithm"`
+		Encoding  string `json:"encoding"`
+		Material  string `json:"material"`
+		KeyID     string `json:"key-id"`
+	} `json:"keys"`
+}
+
+// readVectorFile はマニフェストの file:// のURIをvectorsDirからの相対パスとして読み込みます
+func readVectorFile(t *testing.T, uri string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(vectorsDir, filepath.FromSlash(strings.TrimPrefix(uri, "file://"))))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", uri, err)
+	}
+	return b
+}
+
+// messageSuite はメッセージのヘッダーからアルゴリズムスイートのIDを返します
+func messageSuite(message []byte) uint16 {
+	if len(message) >= 4 && message[0] == 1 {
+		return binary.BigEndian.Uint16(message[2:4])
+	}
+	if len(message) >= 3 {
+		return binary.BigEndian.Uint16(message[1:3])
+	}
+	return 0
+}
+
+// TestDecrypt_KnownAnswerVectors はAWS Encryption SDKの公式のテストベクターのうち、
+// Raw AESキーで暗号化したメッセージを復号し、期待する平文と一致することを確認します
+// KDFを使わない非推奨のスイートのメッセージは拒否することを確認します
+func TestDecrypt_KnownAnswerVectors(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join(vectorsDir, "manifest.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skipf("official test vectors are not present in %s", vectorsDir)
+	}
+	assert.NoError(t, err)
+
+	var manifest decryptManifest
+	assert.NoError(t, json.Unmarshal(b, &manifest))
+	var keys keyManifest
+	assert.NoError(t, json.Unmarshal(readVectorFile(t, manifest.Keys), &keys))
+
+	names := make([]string, 0, len(manifest.Tests))
+	for name := range manifest.Tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ran := 0
+	for _, name := range names {
+		test := manifest.Tests[name]
+		var providers []*RawAESProvider
+		for _, mk := range test.MasterKeys {
+			key, ok := keys.Keys[mk.Key]
+			if mk.Type != "raw" || mk.EncryptionAlgorithm != "aes" || !ok || key.Encoding != "base64" {
+				continue
+			}
+			material, err := base64.StdEncoding.DecodeString(key.Material)
+			assert.NoError(t, err, name)
+			providers = append(providers, &RawAESProvider{Namespace: mk.ProviderID, Name: key.KeyID, Key: material})
+		}
+		if len(providers) == 0 {
+			continue
+		}
+
+		plaintextURI := test.Plaintext
+		if test.Result.Output != nil {
+			plaintextURI = test.Result.Output.Plaintext
+		}
+		message := readVectorFile(t, test.Ciphertext)
+		ran++
+		t.Run(name, func(t *testing.T) {
+			var got []byte
+			for _, p := range providers {
+				if got, _, err = Decrypt(context.Background(), p, message); !errors.Is(err, ErrNoDataKey) {
+					break
+				}
+			}
+			switch id := messageSuite(message); {
+			case id == 0x0014 || id == 0x0046 || id == 0x0078:
+				assert.ErrorContains(t, err, "unsupported algorithm suite")
+			case plaintextURI == "":
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, readVectorFile(t, plaintextURI), got)
+			}
+		})
+	}
+	if ran == 0 {
+		t.Skip("no raw AES vectors in the manifest")
+	}
+}
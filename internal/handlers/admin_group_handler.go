@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/cognito"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateGroupRequest はグループを作成するリクエスト
+type CreateGroupRequest struct {
+	Name        string `json:"name" example:"support"`
+	Description string `json:"description,omitempty"`
+	Precedence  *int32 `json:"precedence,omitempty" description:"複数のグループに所属する場合の優先順位（小さいほど優先）"`
+	RoleArn     string `json:"role_arn,omitempty" description:"IDプールで所属ユーザーに引き受けさせるIAMロール"`
+}
+
+func toGroupResponse(g cognito.Group) GroupResponse {
+	return GroupResponse{
+		Name:           g.Name,
+		Description:    g.Description,
+		Precedence:     g.Precedence,
+		RoleArn:        g.RoleArn,
+		CreatedAt:      g.CreatedAt,
+		LastModifiedAt: g.LastModifiedAt,
+	}
+}
+
+func toListGroupsResponse(groups []cognito.Group, next string) ListGroupsResponse {
+	resp := ListGroupsResponse{Groups: make([]GroupResponse, 0, len(groups)), NextToken: next}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, toGroupResponse(g))
+	}
+	return resp
+}
+
+// AdminListGroupsHandler はグループの一覧を返します
+func AdminListGroupsHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+	limit, ok := adminLimit(w, r)
+	if !ok {
+		return
+	}
+
+	groups, next, err := cognitoService.ListGroups(r.Context(), limit, r.URL.Query().Get("pagination_token"))
+	recordGroupAudit(r, audit.EventAdminListGroups, admin.Username, "", "", err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to list groups", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, toListGroupsResponse(groups, next))
+}
+
+// AdminCreateGroupHandler はグループを作成します
+func AdminCreateGroupHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+
+	var req CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.Precedence != nil && *req.Precedence < 0 {
+		http.Error(w, "Invalid precedence", http.StatusBadRequest)
+		return
+	}
+
+	group, err := cognitoService.CreateGroup(r.Context(), cognito.Group{
+		Name:        req.Name,
+		Description: req.Description,
+		Precedence:  req.Precedence,
+		RoleArn:     req.RoleArn,
+	})
+	recordGroupAudit(r, audit.EventAdminCreateGroup, admin.Username, "", req.Name, err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to create group", err)
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, toGroupResponse(*group))
+}
+
+// AdminListUsersInGroupHandler はグループに所属するユーザーの一覧を返します
+func AdminListUsersInGroupHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+	limit, ok := adminLimit(w, r)
+	if !ok {
+		return
+	}
+
+	group := mux.Vars(r)["group"]
+	users, next, err := cognitoService.ListUsersInGroup(r.Context(), group, limit, r.URL.Query().Get("pagination_token"))
+	recordGroupAudit(r, audit.EventAdminListGroupUsers, admin.Username, "", group, err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to list users in group", err)
+		return
+	}
+
+	resp := ListUsersResponse{Users: make([]AdminUserResponse, 0, len(users)), NextToken: next}
+	for _, u := range users {
+		resp.Users = append(resp.Users, toAdminUserResponse(u))
+	}
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+// AdminListGroupsForUserHandler はユーザーが所属するグループの一覧を返します
+func AdminListGroupsForUserHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+	limit, ok := adminLimit(w, r)
+	if !ok {
+		return
+	}
+
+	username := mux.Vars(r)["username"]
+	groups, next, err := cognitoService.AdminListGroupsForUser(r.Context(), username, limit, r.URL.Query().Get("pagination_token"))
+	recordGroupAudit(r, audit.EventAdminListUserGroups, admin.Username, username, "", err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to list groups for user", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, toListGroupsResponse(groups, next))
+}
+
+// AdminAddUserToGroupHandler はユーザーをグループに追加します
+func AdminAddUserToGroupHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	err := cognitoService.AdminAddUserToGroup(r.Context(), vars["username"], vars["group"])
+	recordGroupAudit(r, audit.EventAdminAddToGroup, admin.Username, vars["username"], vars["group"], err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to add user to group", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, MessageResponse{Message: "User added to group"})
+}
+
+// AdminRemoveUserFromGroupHandler はユーザーをグループから外します
+func AdminRemoveUserFromGroupHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	err := cognitoService.AdminRemoveUserFromGroup(r.Context(), vars["username"], vars["group"])
+	recordGroupAudit(r, audit.EventAdminRemoveFromGroup, admin.Username, vars["username"], vars["group"], err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to remove user from group", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, MessageResponse{Message: "User removed from group"})
+}
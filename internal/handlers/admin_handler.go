@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/cognito"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/smithy-go"
+	"github.com/gorilla/mux"
+)
+
+// UpdateUserAttributesRequest はユーザーの属性を更新するリクエスト
+type UpdateUserAttributesRequest struct {
+	Attributes map[string]string `json:"attributes" description:"更新する属性名と値"`
+}
+
+// AdminCreateUserRequest は管理者がユーザーを招待するリクエスト
+type AdminCreateUserRequest struct {
+	Username          string            `json:"username" example:"user@example.com"`
+	Attributes        map[string]string `json:"attributes,omitempty" description:"ユーザーの属性名と値"`
+	TemporaryPassword string            `json:"temporary_password,omitempty" description:"初回サインインで使う一時パスワード。省略時はCognitoが生成します"`
+	// MessageAction はRESEND（招待メッセージの再送）またはSUPPRESS（送信しない）
+	MessageAction          string   `json:"message_action,omitempty" example:"SUPPRESS"`
+	DesiredDeliveryMediums []string `json:"desired_delivery_mediums,omitempty" description:"招待メッセージの送信方法（EMAIL、SMS）" example:"EMAIL"`
+}
+
+func toAdminUserResponse(u cognito.User) AdminUserResponse {
+	return AdminUserResponse{
+		Username:       u.Username,
+		Status:         u.Status,
+		Enabled:        u.Enabled,
+		Attributes:     u.Attributes,
+		CreatedAt:      u.CreatedAt,
+		LastModifiedAt: u.LastModifiedAt,
+	}
+}
+
+// AdminListUsersHandler はユーザーの一覧を返します
+// filterにはCognitoのフィルター式（例: email ^= "taro"）を指定できます
+func AdminListUsersHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+
+	limit, ok := adminLimit(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := query.Get("filter")
+	users, next, err := cognitoService.ListUsers(r.Context(), filter, limit, query.Get("pagination_token"))
+	recordAdminAudit(r, audit.EventAdminListUsers, admin.Username, filter, err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to list users", err)
+		return
+	}
+
+	resp := ListUsersResponse{Users: make([]AdminUserResponse, 0, len(users)), NextToken: next}
+	for _, u := range users {
+		resp.Users = append(resp.Users, toAdminUserResponse(u))
+	}
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+// AdminCreateUserHandler はユーザーを作成し、一時パスワードを記載した招待メッセージを送信します
+// ユーザーは初回サインインで /signin/new-password から新しいパスワードを設定します
+func AdminCreateUserHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+
+	var req AdminCreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	switch req.MessageAction {
+	case "", "RESEND", "SUPPRESS":
+	default:
+		http.Error(w, "Invalid message action", http.StatusBadRequest)
+		return
+	}
+	for _, medium := range req.DesiredDeliveryMediums {
+		if medium != "EMAIL" && medium != "SMS" {
+			http.Error(w, "Invalid delivery medium", http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, err := cognitoService.AdminCreateUser(r.Context(), cognito.CreateUserInput{
+		Username:               req.Username,
+		Attributes:             req.Attributes,
+		TemporaryPassword:      req.TemporaryPassword,
+		MessageAction:          req.MessageAction,
+		DesiredDeliveryMediums: req.DesiredDeliveryMediums,
+	})
+	recordAdminAudit(r, audit.EventAdminCreateUser, admin.Username, req.Username, err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to create user", err)
+		return
+	}
+	status := http.StatusCreated
+	if req.MessageAction == "RESEND" {
+		status = http.StatusOK
+	}
+	writeJSON(w, r, status, toAdminUserResponse(*user))
+}
+
+// AdminGetUserHandler はユーザーを返します
+func AdminGetUserHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+
+	username := mux.Vars(r)["username"]
+	user, err := cognitoService.AdminGetUser(r.Context(), username)
+	recordAdminAudit(r, audit.EventAdminGetUser, admin.Username, username, err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to get user", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, toAdminUserResponse(*user))
+}
+
+// AdminDisableUserHandler はユーザーを無効化します
+func AdminDisableUserHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	adminAction(w, r, cognitoService, opts, audit.EventAdminDisableUser, "User disabled", "Failed to disable user", cognitoService.AdminDisableUser)
+}
+
+// AdminEnableUserHandler は無効化されたユーザーを有効化します
+func AdminEnableUserHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	adminAction(w, r, cognitoService, opts, audit.EventAdminEnableUser, "User enabled", "Failed to enable user", cognitoService.AdminEnableUser)
+}
+
+// AdminDeleteUserHandler はユーザーを削除します
+func AdminDeleteUserHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	adminAction(w, r, cognitoService, opts, audit.EventAdminDeleteUser, "User deleted", "Failed to delete user", cognitoService.AdminDeleteUser)
+}
+
+// AdminResetUserPasswordHandler はユーザーのパスワードを無効にし、パスワードリセットの確認コードを送信します
+func AdminResetUserPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	adminAction(w, r, cognitoService, opts, audit.EventAdminResetPassword, "Password reset", "Failed to reset password", cognitoService.AdminResetUserPassword)
+}
+
+// AdminUserGlobalSignOutHandler はユーザーのすべてのセッションのリフレッシュトークンを無効にします
+func AdminUserGlobalSignOutHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	adminAction(w, r, cognitoService, opts, audit.EventAdminGlobalSignOut, "User signed out", "Failed to sign out user", cognitoService.AdminUserGlobalSignOut)
+}
+
+// AdminUpdateUserAttributesHandler はユーザーの属性を更新します
+func AdminUpdateUserAttributesHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserAttributesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Attributes) == 0 {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	username := mux.Vars(r)["username"]
+	err := cognitoService.AdminUpdateUserAttributes(r.Context(), username, req.Attributes)
+	recordAdminAudit(r, audit.EventAdminUpdateAttributes, admin.Username, username, err)
+	if err != nil {
+		writeAdminError(w, r, "Failed to update user attributes", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, MessageResponse{Message: "User attributes updated"})
+}
+
+// adminAction はパスのユーザーに対する本文のない管理操作を実行し、監査ログに記録します
+func adminAction(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options, eventType audit.EventType, success, failure string, action func(context.Context, string) error) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	admin, ok := requireAdmin(w, r, opts)
+	if !ok {
+		return
+	}
+
+	username := mux.Vars(r)["username"]
+	err := action(r.Context(), username)
+	recordAdminAudit(r, eventType, admin.Username, username, err)
+	if err != nil {
+		writeAdminError(w, r, failure, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, MessageResponse{Message: success})
+}
+
+// adminLimit は一覧を返す管理APIのlimitパラメーター（1〜60）を返します。未指定の場合は0を返します
+func adminLimit(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || n <= 0 || n > 60 {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(n), true
+}
+
+// writeAdminError は管理操作のCognitoエラーをレスポンスに変換します
+func writeAdminError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	var awsErr smithy.APIError
+	if ok := errors.As(err, &awsErr); ok {
+		switch awsErr.ErrorCode() {
+		case "UserNotFoundException":
+			http.Error(w, "User not found", http.StatusNotFound)
+		case "ResourceNotFoundException":
+			http.Error(w, "Group not found", http.StatusNotFound)
+		case "GroupExistsException":
+			http.Error(w, "Group already exists", http.StatusConflict)
+		case "InvalidParameterException":
+			http.Error(w, "Invalid input parameters", http.StatusBadRequest)
+		case "AliasExistsException":
+			http.Error(w, "Email or phone number is already in use", http.StatusConflict)
+		case "UsernameExistsException":
+			http.Error(w, "User already exists", http.StatusConflict)
+		case "InvalidPasswordException":
+			http.Error(w, "Password does not meet requirements", http.StatusBadRequest)
+		case "UnsupportedUserStateException":
+			http.Error(w, "User has already signed in", http.StatusConflict)
+		case "CodeDeliveryFailureException":
+			http.Error(w, "Failed to deliver invitation", http.StatusBadGateway)
+		case "TooManyRequestsException", "LimitExceededException":
+			http.Error(w, "Request limit exceeded", http.StatusTooManyRequests)
+		default:
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	} else {
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+	logCognitoError(r, msg, "", err)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/cognito"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryAuditSink は書き込まれた監査レコードを保持するSink
+type memoryAuditSink struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (s *memoryAuditSink) Write(event audit.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// adminRouter はroutesと同じく/admin以下に管理APIを登録したルーターを作成します
+func adminRouter(service *cognito.Service, opts Options, sink *memoryAuditSink) http.Handler {
+	r := mux.NewRouter()
+	r.Use(audit.NewLogger(sink, audit.Head{}).Middleware)
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(RequireAdmin(opts))
+	route := func(path, method string, h func(http.ResponseWriter, *http.Request, *cognito.Service, Options)) {
+		admin.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) { h(w, r, service, opts) }).Methods(method)
+	}
+	route("/users", "GET", AdminListUsersHandler)
+	route("/users", "POST", AdminCreateUserHandler)
+	route("/users/{username}", "GET", AdminGetUserHandler)
+	route("/users/{username}", "DELETE", AdminDeleteUserHandler)
+	route("/users/{username}/attributes", "PUT", AdminUpdateUserAttributesHandler)
+	route("/users/{username}/disable", "POST", AdminDisableUserHandler)
+	route("/users/{username}/enable", "POST", AdminEnableUserHandler)
+	route("/users/{username}/reset-password", "POST", AdminResetUserPasswordHandler)
+	route("/users/{username}/sign-out", "POST", AdminUserGlobalSignOutHandler)
+	route("/users/{username}/groups", "GET", AdminListGroupsForUserHandler)
+	route("/users/{username}/groups/{group}", "PUT", AdminAddUserToGroupHandler)
+	route("/users/{username}/groups/{group}", "DELETE", AdminRemoveUserFromGroupHandler)
+	route("/groups", "GET", AdminListGroupsHandler)
+	route("/groups", "POST", AdminCreateGroupHandler)
+	route("/groups/{group}/users", "GET", AdminListUsersInGroupHandler)
+	return r
+}
+
+// 管理者以外のユーザー・トークンなし・管理APIが無効な場合は、Cognitoを呼び出さずに拒否することを確認
+func TestAdminHandlers_Unauthorized(t *testing.T) {
+	tokens := newTestTokens(t)
+	calls := 0
+	service := newStubCognito(t, func(op string, in map[string]any) (int, any) {
+		calls++
+		return http.StatusOK, map[string]any{}
+	})
+
+	cases := []struct {
+		name  string
+		opts  Options
+		token string
+		want  int
+	}{
+		{"no token", Options{TokenVerifier: tokens.verifier}, "", http.StatusUnauthorized},
+		{"not admin", Options{TokenVerifier: tokens.verifier}, tokens.token("staff", "support"), http.StatusForbidden},
+		{"custom admin group", Options{TokenVerifier: tokens.verifier, AdminGroup: "operators"}, tokens.token("taro", "admin"), http.StatusForbidden},
+		{"no verifier", Options{}, tokens.token("taro", "admin"), http.StatusNotFound},
+	}
+	for _, c := range cases {
+		sink := &memoryAuditSink{}
+		router := adminRouter(service, c.opts, sink)
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/admin/users", nil),
+			httptest.NewRequest(http.MethodDelete, "/admin/users/hanako", nil),
+			httptest.NewRequest(http.MethodPut, "/admin/users/hanako/groups/admin", nil),
+		} {
+			if c.token != "" {
+				bearer(req, c.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, c.want, rec.Code, "%s: %s %s", c.name, req.Method, req.URL.Path)
+		}
+		assert.Empty(t, sink.events, c.name)
+	}
+	assert.Zero(t, calls)
+}
+
+// 管理者による各操作がCognitoを呼び出し、操作したユーザーをactorとして監査ログに記録することを確認
+func TestAdminHandlers_Audit(t *testing.T) {
+	tokens := newTestTokens(t)
+	var ops []string
+	service := newStubCognito(t, func(op string, in map[string]any) (int, any) {
+		ops = append(ops, op)
+		switch op {
+		case "AdminCreateUser":
+			return http.StatusOK, map[string]any{"User": map[string]any{"Username": in["Username"], "Enabled": true}}
+		case "CreateGroup":
+			return http.StatusOK, map[string]any{"Group": map[string]any{"GroupName": in["GroupName"]}}
+		case "AdminDeleteUser":
+			return http.StatusBadRequest, map[string]string{"__type": "UserNotFoundException", "message": "User does not exist."}
+		}
+		return http.StatusOK, map[string]any{}
+	})
+	sink := &memoryAuditSink{}
+	router := adminRouter(service, Options{TokenVerifier: tokens.verifier}, sink)
+	token := tokens.token("taro", "admin")
+
+	cases := []struct {
+		method, path, body string
+		op                 string
+		event              audit.EventType
+		status             int
+	}{
+		{"GET", "/admin/users?limit=10", "", "ListUsers", audit.EventAdminListUsers, http.StatusOK},
+		{"POST", "/admin/users", `{"username":"hanako"}`, "AdminCreateUser", audit.EventAdminCreateUser, http.StatusCreated},
+		{"GET", "/admin/users/hanako", "", "AdminGetUser", audit.EventAdminGetUser, http.StatusOK},
+		{"DELETE", "/admin/users/hanako", "", "AdminDeleteUser", audit.EventAdminDeleteUser, http.StatusNotFound},
+		{"PUT", "/admin/users/hanako/attributes", `{"attributes":{"name":"Hanako"}}`, "AdminUpdateUserAttributes", audit.EventAdminUpdateAttributes, http.StatusOK},
+		{"POST", "/admin/users/hanako/disable", "", "AdminDisableUser", audit.EventAdminDisableUser, http.StatusOK},
+		{"POST", "/admin/users/hanako/enable", "", "AdminEnableUser", audit.EventAdminEnableUser, http.StatusOK},
+		{"POST", "/admin/users/hanako/reset-password", "", "AdminResetUserPassword", audit.EventAdminResetPassword, http.StatusOK},
+		{"POST", "/admin/users/hanako/sign-out", "", "AdminUserGlobalSignOut", audit.EventAdminGlobalSignOut, http.StatusOK},
+		{"GET", "/admin/users/hanako/groups", "", "AdminListGroupsForUser", audit.EventAdminListUserGroups, http.StatusOK},
+		{"PUT", "/admin/users/hanako/groups/support", "", "AdminAddUserToGroup", audit.EventAdminAddToGroup, http.StatusOK},
+		{"DELETE", "/admin/users/hanako/groups/support", "", "AdminRemoveUserFromGroup", audit.EventAdminRemoveFromGroup, http.StatusOK},
+		{"GET", "/admin/groups", "", "ListGroups", audit.EventAdminListGroups, http.StatusOK},
+		{"POST", "/admin/groups", `{"name":"support"}`, "CreateGroup", audit.EventAdminCreateGroup, http.StatusCreated},
+		{"GET", "/admin/groups/support/users", "", "ListUsersInGroup", audit.EventAdminListGroupUsers, http.StatusOK},
+	}
+	for i, c := range cases {
+		req := bearer(httptest.NewRequest(c.method, c.path, strings.NewReader(c.body)), token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, c.status, rec.Code, "%s %s: %s", c.method, c.path, rec.Body.String())
+		if assert.Len(t, ops, i+1, c.path) {
+			assert.Equal(t, c.op, ops[i])
+		}
+		if assert.Len(t, sink.events, i+1, c.path) {
+			event := sink.events[i]
+			assert.Equal(t, c.event, event.Event)
+			assert.Equal(t, "taro", event.Actor, c.path)
+			if c.status >= http.StatusBadRequest {
+				assert.Equal(t, audit.OutcomeFailure, event.Outcome)
+				assert.Equal(t, "UserNotFoundException", event.ErrorCode)
+			} else {
+				assert.Equal(t, audit.OutcomeSuccess, event.Outcome, c.path)
+			}
+		}
+	}
+}
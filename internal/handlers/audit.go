@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/cognito"
+	"net/http"
+)
+
+// recordAudit は認証操作の結果を監査ログに記録します
+func recordAudit(r *http.Request, eventType audit.EventType, email string, err error) {
+	event := audit.Event{Event: eventType, Subject: email, Outcome: audit.OutcomeSuccess}
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		event.ErrorCode = cognito.ErrorCode(err)
+	}
+	audit.Emit(r.Context(), event)
+}
+
+// recordChallengeAudit は認証情報は正しいものの、追加のチャレンジに応答するまでサインインが完了しないことを監査ログに記録します
+func recordChallengeAudit(r *http.Request, eventType audit.EventType, email string) {
+	audit.Emit(r.Context(), audit.Event{Event: eventType, Subject: email, Outcome: audit.OutcomeChallenge})
+}
+
+// recordAdminAudit は管理操作の結果を、操作したユーザーとともに監査ログに記録します
+func recordAdminAudit(r *http.Request, eventType audit.EventType, actor, subject string, err error) {
+	event := audit.Event{Event: eventType, Subject: subject, Actor: actor, Outcome: audit.OutcomeSuccess}
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		event.ErrorCode = cognito.ErrorCode(err)
+	}
+	audit.Emit(r.Context(), event)
+}
+
+// recordGroupAudit はグループの管理操作の結果を、対象のグループとともに監査ログに記録します
+func recordGroupAudit(r *http.Request, eventType audit.EventType, actor, subject, group string, err error) {
+	event := audit.Event{Event: eventType, Subject: subject, Actor: actor, Group: group, Outcome: audit.OutcomeSuccess}
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		event.ErrorCode = cognito.ErrorCode(err)
+	}
+	audit.Emit(r.Context(), event)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/jwks"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type claimsContextKey struct{}
+
+// ClaimsFromContext はRequireGroupsで検証したアクセストークンのクレームを返します
+func ClaimsFromContext(ctx context.Context) (*jwks.Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*jwks.Claims)
+	return claims, ok
+}
+
+// RequireGroups は、アクセストークンのユーザーがいずれかのグループに所属する場合のみ次のハンドラーを呼び出すミドルウェアを返します
+// ルーターのUseやルートごとのハンドラーのラップに使えます。検証したクレームはClaimsFromContextで取得できます
+func RequireGroups(opts Options, groups ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := authorize(w, r, opts, groups...)
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims)))
+		})
+	}
+}
+
+// RequireGroup はgroupに所属するユーザーのみ次のハンドラーを呼び出すミドルウェアを返します
+func RequireGroup(opts Options, group string) func(http.Handler) http.Handler {
+	return RequireGroups(opts, group)
+}
+
+// RequireAdmin は管理者グループ（Options.AdminGroup）のユーザーのみ次のハンドラーを呼び出すミドルウェアを返します
+// TokenVerifierが設定されていない場合は管理APIが無効なため、すべてのリクエストに404を返します
+func RequireAdmin(opts Options) func(http.Handler) http.Handler {
+	if opts.TokenVerifier == nil {
+		return func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Admin API is not enabled", http.StatusNotFound)
+			})
+		}
+	}
+	return RequireGroup(opts, opts.adminGroup())
+}
+
+// accessToken はAuthorizationヘッダーのBearerトークン、なければCookieセッションのアクセストークンを返します
+// Cookieから取得した場合、GET以外のリクエストではCSRFトークンも確認します
+func accessToken(w http.ResponseWriter, r *http.Request, opts Options) (string, bool) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		return token, true
+	}
+	if opts.Session != nil {
+		if token := opts.Session.AccessToken(r); token != "" {
+			if r.Method != http.MethodGet && !opts.Session.CheckCSRF(r) {
+				http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+				return "", false
+			}
+			return token, true
+		}
+	}
+	http.Error(w, "Missing access token", http.StatusUnauthorized)
+	return "", false
+}
+
+// authorize はアクセストークンの署名を検証し、いずれかのグループに所属するユーザーのクレームを返します
+func authorize(w http.ResponseWriter, r *http.Request, opts Options, groups ...string) (*jwks.Claims, bool) {
+	// RequireGroupsで検証済みの場合は、グループの所属のみ確認します
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		if claims, ok = verifyAccessToken(w, r, opts); !ok {
+			return nil, false
+		}
+	}
+	if !claims.HasGroup(groups...) {
+		slog.WarnContext(r.Context(), "Access denied", "username", claims.Username, "required_groups", groups)
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return nil, false
+	}
+	return claims, true
+}
+
+// verifyAccessToken はリクエストのアクセストークンの署名とクレームを検証します
+func verifyAccessToken(w http.ResponseWriter, r *http.Request, opts Options) (*jwks.Claims, bool) {
+	if opts.TokenVerifier == nil {
+		http.Error(w, "Access token verification is not enabled", http.StatusInternalServerError)
+		return nil, false
+	}
+	token, ok := accessToken(w, r, opts)
+	if !ok {
+		return nil, false
+	}
+	claims, err := opts.TokenVerifier.Verify(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, jwks.ErrInvalidToken) {
+			http.Error(w, "Invalid or expired access token", http.StatusUnauthorized)
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to verify access token", "error", err)
+			http.Error(w, "Failed to verify access token", http.StatusServiceUnavailable)
+		}
+		return nil, false
+	}
+	return claims, true
+}
+
+// requireAdmin は管理APIのリクエストが管理者グループのユーザーからのものであることを確認します
+// RequireAdminで検証済みの場合は、コンテキストのクレームをそのまま使用します
+func requireAdmin(w http.ResponseWriter, r *http.Request, opts Options) (*jwks.Claims, bool) {
+	if opts.TokenVerifier == nil {
+		http.Error(w, "Admin API is not enabled", http.StatusNotFound)
+		return nil, false
+	}
+	return authorize(w, r, opts, opts.adminGroup())
+}
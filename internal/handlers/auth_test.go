@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/jwks"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testIssuer = "https://cognito-idp.ap-northeast-1.amazonaws.com/ap-northeast-1_EXAMPLE"
+
+// testTokens はテスト用のJWKSサーバーを使うVerifierと、その鍵で署名したアクセストークンを発行します
+type testTokens struct {
+	t        *testing.T
+	key      *rsa.PrivateKey
+	verifier *jwks.Verifier
+}
+
+func newTestTokens(t *testing.T) *testTokens {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "key1",
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(srv.Close)
+	return &testTokens{t: t, key: key, verifier: &jwks.Verifier{URL: srv.URL, Issuer: testIssuer, ClientID: "client"}}
+}
+
+// token はusernameとgroupsのクレームを持つRS256のアクセストークンを作成します
+func (tt *testTokens) token(username string, groups ...string) string {
+	tt.t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "key1"})
+	payload, _ := json.Marshal(map[string]any{
+		"sub": username + "-sub", "username": username, "client_id": "client", "token_use": "access",
+		"iss": testIssuer, "exp": time.Now().Add(time.Hour).Unix(), "cognito:groups": groups,
+	})
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, tt.key, crypto.SHA256, digest[:])
+	assert.NoError(tt.t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// bearer はAuthorizationヘッダーにtokenを設定したリクエストを作成します
+func bearer(req *http.Request, token string) *http.Request {
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// トークンの有無とグループの所属に応じて401・403・通過となり、通過した場合はクレームを参照できることを確認
+func TestRequireGroups(t *testing.T) {
+	tokens := newTestTokens(t)
+	opts := Options{TokenVerifier: tokens.verifier}
+
+	var got *jwks.Claims
+	handler := RequireGroups(opts, "support", "admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ClaimsFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"no token", httptest.NewRequest(http.MethodGet, "/", nil), http.StatusUnauthorized},
+		{"invalid token", bearer(httptest.NewRequest(http.MethodGet, "/", nil), "not-a-token"), http.StatusUnauthorized},
+		{"wrong group", bearer(httptest.NewRequest(http.MethodGet, "/", nil), tokens.token("hanako", "staff")), http.StatusForbidden},
+		{"allowed group", bearer(httptest.NewRequest(http.MethodGet, "/", nil), tokens.token("taro", "staff", "support")), http.StatusNoContent},
+	}
+	for _, c := range cases {
+		got = nil
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, c.req)
+		assert.Equal(t, c.want, rec.Code, c.name)
+		if c.want == http.StatusNoContent {
+			if assert.NotNil(t, got, c.name) {
+				assert.Equal(t, "taro", got.Username)
+			}
+		} else {
+			assert.Nil(t, got, c.name)
+		}
+	}
+}
+
+// 外側のRequireGroupsで検証済みのクレームを再利用し、内側ではグループの所属のみ確認することを確認
+func TestRequireGroups_AlreadyVerified(t *testing.T) {
+	tokens := newTestTokens(t)
+	called := false
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// 内側はTokenVerifierがないため、トークンを検証しようとすると500になる
+	inner := RequireGroup(Options{}, "admin")
+	handler := RequireGroup(Options{TokenVerifier: tokens.verifier}, "staff")(inner(ok))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, bearer(httptest.NewRequest(http.MethodGet, "/", nil), tokens.token("taro", "staff", "admin")))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.True(t, called)
+
+	// 検証済みでも内側のグループに所属しなければ403
+	called = false
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, bearer(httptest.NewRequest(http.MethodGet, "/", nil), tokens.token("hanako", "staff")))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.False(t, called)
+}
+
+// TokenVerifierがない場合、管理APIは無効として404を返すことを確認
+func TestRequireAdmin_Disabled(t *testing.T) {
+	rec := httptest.NewRecorder()
+	RequireAdmin(Options{})(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Admin API is not enabled")
+}
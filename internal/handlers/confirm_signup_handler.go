@@ -1,18 +1,19 @@
 package handlers
 
 import (
+	"cognito-lambda-handler/internal/audit"
 	"cognito-lambda-handler/internal/cognito"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/aws/smithy-go"
 )
 
 type ConfirmSignUpRequest struct {
-	Email string `json:"email"`
-	Code  string `json:"code"`
+	Email string `json:"email" example:"user@example.com"`
+	Code  string `json:"code" example:"123456"`
 }
 
 func ConfirmSignUpHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service) {
@@ -28,7 +29,8 @@ func ConfirmSignUpHandler(w http.ResponseWriter, r *http.Request, cognitoService
 		return
 	}
 
-	err = cognitoService.ConfirmSignUp(req.Email, req.Code)
+	err = cognitoService.ConfirmSignUp(r.Context(), req.Email, req.Code)
+	recordAudit(r, audit.EventConfirmSignUp, req.Email, err)
 	if err != nil {
 		var awsErr smithy.APIError
 		if ok := errors.As(err, &awsErr); ok {
@@ -43,14 +45,14 @@ func ConfirmSignUpHandler(w http.ResponseWriter, r *http.Request, cognitoService
 		} else {
 			http.Error(w, "Failed to confirm sign up", http.StatusInternalServerError)
 		}
-		log.Printf("Error confirming sign up for user %s: %v", req.Email, err)
+		logCognitoError(r, "Error confirming sign up", req.Email, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]string{"message": "User confirmed"}); err != nil {
-		log.Printf("Error encoding response for user %s: %v", req.Email, err)
+	if err := json.NewEncoder(w).Encode(MessageResponse{Message: "User confirmed"}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "email", req.Email, "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
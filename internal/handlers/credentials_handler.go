@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/identity"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/aws/smithy-go"
+)
+
+type CredentialsRequest struct {
+	IdToken string `json:"id_token" description:"サインインで取得したIDトークン"`
+}
+
+// CredentialsHandler はIDトークンをIDプールの一時的なAWS認証情報に交換します
+func CredentialsHandler(w http.ResponseWriter, r *http.Request, identityService *identity.Service) {
+	if identityService == nil {
+		http.Error(w, "Identity pool is not configured", http.StatusNotFound)
+		return
+	}
+
+	var req CredentialsRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.IdToken == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	creds, err := identityService.GetCredentials(r.Context(), req.IdToken)
+	subject := ""
+	if creds != nil {
+		subject = creds.IdentityID
+	}
+	recordAudit(r, audit.EventCredentials, subject, err)
+	if err != nil {
+		var awsErr smithy.APIError
+		if ok := errors.As(err, &awsErr); ok {
+			switch awsErr.ErrorCode() {
+			case "NotAuthorizedException":
+				http.Error(w, "Invalid or expired ID token", http.StatusUnauthorized)
+			case "TooManyRequestsException", "LimitExceededException":
+				http.Error(w, "Request limit exceeded", http.StatusTooManyRequests)
+			default:
+				http.Error(w, "Failed to get credentials", http.StatusInternalServerError)
+			}
+		} else {
+			http.Error(w, "Failed to get credentials", http.StatusInternalServerError)
+		}
+		logCognitoError(r, "Error getting identity pool credentials", "", err)
+		return
+	}
+
+	resp := CredentialsResponse{
+		IdentityID:      creds.IdentityID,
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretKey,
+		SessionToken:    creds.SessionToken,
+		Expiration:      creds.Expiration,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "identity_id", creds.IdentityID, "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
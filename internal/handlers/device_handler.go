@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/cognito"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/aws/smithy-go"
+	"github.com/gorilla/mux"
+)
+
+type UpdateDeviceStatusRequest struct {
+	Remembered bool `json:"remembered"`
+}
+
+// confirmNewDevice はサインイン時に発行された新しいデバイスを登録します
+// 登録に失敗した場合はログを出力してnilを返します
+func confirmNewDevice(r *http.Request, cognitoService *cognito.Service, authResult *types.AuthenticationResultType, email, deviceName string) *NewDeviceResponse {
+	if deviceName == "" {
+		deviceName = r.UserAgent()
+	}
+	device, err := cognitoService.ConfirmDevice(r.Context(), aws.ToString(authResult.AccessToken), authResult.NewDeviceMetadata, deviceName)
+	recordAudit(r, audit.EventDeviceConfirm, email, err)
+	if err != nil {
+		logCognitoError(r, "Error confirming device", email, err)
+		return nil
+	}
+	return &NewDeviceResponse{
+		DeviceCredentials:     DeviceCredentials{Key: device.Key, GroupKey: device.GroupKey, Password: device.Password},
+		ConfirmationNecessary: device.UserConfirmationNecessary,
+	}
+}
+
+// ListDevicesHandler はサインイン中のユーザーのデバイス一覧を返します
+func ListDevicesHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	token, ok := accessToken(w, r, opts)
+	if !ok {
+		return
+	}
+
+	var limit int32
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = int32(n)
+	}
+
+	devices, next, err := cognitoService.ListDevices(r.Context(), token, limit, r.URL.Query().Get("pagination_token"))
+	if err != nil {
+		writeDeviceError(w, r, "Failed to list devices", err)
+		return
+	}
+
+	resp := ListDevicesResponse{Devices: make([]DeviceResponse, 0, len(devices)), NextToken: next}
+	for _, d := range devices {
+		resp.Devices = append(resp.Devices, DeviceResponse{
+			Key:                 d.Key,
+			Name:                d.Name,
+			Remembered:          d.Remembered,
+			LastIP:              d.LastIP,
+			CreatedAt:           d.CreatedAt,
+			LastModifiedAt:      d.LastModifiedAt,
+			LastAuthenticatedAt: d.LastAuthenticatedAt,
+		})
+	}
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+// UpdateDeviceStatusHandler はデバイスを記憶するかどうかを設定します
+func UpdateDeviceStatusHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	token, ok := accessToken(w, r, opts)
+	if !ok {
+		return
+	}
+
+	var req UpdateDeviceStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	deviceKey := mux.Vars(r)["device_key"]
+	err := cognitoService.UpdateDeviceStatus(r.Context(), token, deviceKey, req.Remembered)
+	recordAudit(r, audit.EventDeviceUpdate, deviceKey, err)
+	if err != nil {
+		writeDeviceError(w, r, "Failed to update device status", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, MessageResponse{Message: "Device status updated"})
+}
+
+// ForgetDeviceHandler はデバイスの登録を削除します
+func ForgetDeviceHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	token, ok := accessToken(w, r, opts)
+	if !ok {
+		return
+	}
+
+	deviceKey := mux.Vars(r)["device_key"]
+	err := cognitoService.ForgetDevice(r.Context(), token, deviceKey)
+	recordAudit(r, audit.EventDeviceForget, deviceKey, err)
+	if err != nil {
+		writeDeviceError(w, r, "Failed to forget device", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, MessageResponse{Message: "Device forgotten"})
+}
+
+// writeDeviceError はデバイス操作のCognitoエラーをレスポンスに変換します
+func writeDeviceError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	var awsErr smithy.APIError
+	if ok := errors.As(err, &awsErr); ok {
+		switch awsErr.ErrorCode() {
+		case "NotAuthorizedException":
+			http.Error(w, "Invalid or expired access token", http.StatusUnauthorized)
+		case "ResourceNotFoundException":
+			http.Error(w, "Device not found", http.StatusNotFound)
+		case "InvalidParameterException":
+			http.Error(w, "Invalid input parameters", http.StatusBadRequest)
+		case "TooManyRequestsException":
+			http.Error(w, "Request limit exceeded", http.StatusTooManyRequests)
+		default:
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	} else {
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+	logCognitoError(r, msg, "", err)
+}
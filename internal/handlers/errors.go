@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/cognito"
+	"cognito-lambda-handler/internal/requestinfo"
+	"log/slog"
+	"net/http"
+)
+
+// logCognitoError はCognitoのエラーコードをリクエスト情報に記録し、エラーログを出力します
+// メールアドレスはロガー側でマスクされます
+func logCognitoError(r *http.Request, msg, email string, err error) {
+	code := cognito.ErrorCode(err)
+	requestinfo.SetCognitoErrorCode(r.Context(), code)
+	slog.ErrorContext(r.Context(), msg, "email", email, "cognito_error_code", code, "error", err)
+}
@@ -1,20 +1,23 @@
 package handlers
 
 import (
+	"cognito-lambda-handler/internal/audit"
 	"cognito-lambda-handler/internal/cognito"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/aws/smithy-go"
 )
 
 type ForgotPasswordRequest struct {
-	Email string `json:"email"`
+	Email string `json:"email" example:"user@example.com"`
 }
 
-func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service) {
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	start := time.Now()
 	if cognitoService == nil {
 		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
 		return
@@ -27,7 +30,14 @@ func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoServic
 		return
 	}
 
-	err = cognitoService.ForgotPassword(req.Email)
+	err = cognitoService.ForgotPassword(r.Context(), req.Email)
+	recordAudit(r, audit.EventForgotPassword, req.Email, err)
+	opts.padResponseTime(r.Context(), start)
+	if err != nil && opts.EnumerationSafe {
+		// ユーザーの存在有無を判別できないよう、失敗時も成功レスポンスを返す
+		logCognitoError(r, "Error requesting password reset", req.Email, err)
+		err = nil
+	}
 	if err != nil {
 		var awsErr smithy.APIError
 		if ok := errors.As(err, &awsErr); ok {
@@ -42,14 +52,14 @@ func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoServic
 		} else {
 			http.Error(w, "Failed to request password reset", http.StatusInternalServerError)
 		}
-		log.Printf("Error requesting password reset for user %s: %v", req.Email, err)
+		logCognitoError(r, "Error requesting password reset", req.Email, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]string{"message": "Password reset requested"}); err != nil {
-		log.Printf("Error encoding response for user %s: %v", req.Email, err)
+	if err := json.NewEncoder(w).Encode(MessageResponse{Message: "Password reset requested"}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "email", req.Email, "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
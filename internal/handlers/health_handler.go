@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/health"
+	"cognito-lambda-handler/internal/version"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// HealthzHandler はプロセスが応答できることだけを返すライブネスチェック
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, HealthResponse{Status: health.StatusOK})
+}
+
+// ReadyzHandler は設定・Cognito・JWKSなどの依存関係を確認するレディネスチェック
+// いずれかが失敗した場合は503を返します
+func ReadyzHandler(w http.ResponseWriter, r *http.Request, checker *health.Checker) {
+	if checker == nil {
+		http.Error(w, "Readiness checker is not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	report := checker.Run(r.Context())
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+		for name, result := range report.Checks {
+			if result.Status != health.StatusOK {
+				slog.WarnContext(r.Context(), "Readiness check failed", "check", name, "error", result.Error, "latency_ms", result.LatencyMs)
+			}
+		}
+	}
+	writeJSON(w, r, status, report)
+}
+
+// VersionHandler はビルド時に埋め込まれたバージョン情報を返します
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, version.Get())
+}
+
+// writeJSON はステータスコードとJSONレスポンスを書き込みます
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
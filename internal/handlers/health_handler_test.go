@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/health"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 依存関係のエラーの内容をレスポンスに含めず、チェックごとの結果だけを返すことを確認
+func TestReadyzHandler_HidesErrors(t *testing.T) {
+	checker := health.NewChecker(time.Second,
+		health.Check{Name: "config", Run: func(ctx context.Context) error { return nil }},
+		health.Check{Name: "cognito", Run: func(ctx context.Context) error {
+			return errors.New("dial tcp 10.0.0.1:443: connection refused")
+		}},
+	)
+
+	rec := httptest.NewRecorder()
+	ReadyzHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil), checker)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"cognito":{"status":"failed"`)
+	assert.NotContains(t, rec.Body.String(), "10.0.0.1")
+	assert.NotContains(t, rec.Body.String(), "error")
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/cognito"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// OAuthAuthorizeHandler はstate・nonce・PKCEを付けたホストUIの認可URLへリダイレクトします
+// クエリの identity_provider（例: Google, SignInWithApple）で外部IdPを指定できます
+func OAuthAuthorizeHandler(w http.ResponseWriter, r *http.Request, opts Options) {
+	if opts.OAuth == nil {
+		http.Error(w, "OAuth is not enabled", http.StatusNotFound)
+		return
+	}
+
+	authURL, err := opts.OAuth.Start(w, r.URL.Query().Get("identity_provider"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error starting OAuth authorization", "error", err)
+		http.Error(w, "Failed to start authorization", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OAuthCallbackHandler はホストUIからのコールバックでstateを確認し、認可コードをトークンに交換します
+// IDトークンのnonceが認可リクエストと一致しない場合は拒否します
+func OAuthCallbackHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	if opts.OAuth == nil {
+		http.Error(w, "OAuth is not enabled", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	pending, err := opts.OAuth.Verify(r)
+	opts.OAuth.Clear(w)
+	if err != nil {
+		slog.WarnContext(r.Context(), "OAuth state verification failed", "error", err)
+		http.Error(w, "Invalid state", http.StatusBadRequest)
+		return
+	}
+	if e := query.Get("error"); e != "" {
+		// ユーザーがキャンセルした場合などはホストUIがerrorを付けてリダイレクトする
+		slog.WarnContext(r.Context(), "OAuth authorization failed", "oauth_error", e, "description", query.Get("error_description"))
+		http.Error(w, "Authorization failed", http.StatusUnauthorized)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	authResult, err := cognitoService.ExchangeAuthorizationCode(r.Context(), cognito.AuthorizationCodeRequest{
+		TokenURL:     opts.OAuth.TokenURL(),
+		Code:         code,
+		RedirectURI:  opts.OAuth.RedirectURI(),
+		CodeVerifier: pending.CodeVerifier,
+	})
+	if err == nil {
+		err = opts.OAuth.CheckIDToken(aws.ToString(authResult.IdToken), pending.Nonce)
+	}
+	username := ""
+	if authResult != nil {
+		username, _ = cognito.UsernameFromAccessToken(aws.ToString(authResult.AccessToken))
+	}
+	recordAudit(r, audit.EventOAuthSignIn, username, err)
+	if err != nil {
+		var oauthErr *cognito.OAuthError
+		if errors.As(err, &oauthErr) && oauthErr.Code == "invalid_grant" {
+			http.Error(w, "Invalid or expired authorization code", http.StatusUnauthorized)
+		} else if authResult != nil {
+			http.Error(w, "Invalid ID token", http.StatusUnauthorized)
+		} else {
+			http.Error(w, "Failed to exchange authorization code", http.StatusBadGateway)
+		}
+		logCognitoError(r, "Error completing OAuth sign in", username, err)
+		return
+	}
+
+	resp := SignInResponse{
+		Token:     aws.ToString(authResult.AccessToken),
+		IdToken:   aws.ToString(authResult.IdToken),
+		ExpiresIn: authResult.ExpiresIn,
+	}
+	if opts.Session != nil {
+		if err := setSessionCookies(w, opts.Session, username, authResult, true); err != nil {
+			slog.ErrorContext(r.Context(), "Error setting session cookies", "username", username, "error", err)
+			http.Error(w, "Failed to sign in user", http.StatusInternalServerError)
+			return
+		}
+		if opts.Session.AccessTokenInCookie() {
+			resp.Token = ""
+		}
+		if redirect := opts.OAuth.PostLoginRedirect(); redirect != "" {
+			http.Redirect(w, r, redirect, http.StatusFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "username", username, "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
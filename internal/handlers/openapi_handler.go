@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// OpenAPIHandler はルート定義から生成したOpenAPIドキュメント（JSON）を返します
+// documentは生成済みのドキュメントを返し、前回の生成に失敗していた場合は生成し直します
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request, document func() ([]byte, error)) {
+	doc, err := document()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error generating OpenAPI document", "error", err)
+		http.Error(w, "OpenAPI document is not available", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(doc); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing OpenAPI document", "error", err)
+	}
+}
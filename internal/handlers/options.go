@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/jwks"
+	"cognito-lambda-handler/internal/oauth"
+	"cognito-lambda-handler/internal/session"
+	"cognito-lambda-handler/internal/signuppolicy"
+	"context"
+	"time"
+)
+
+// Options はハンドラーの挙動を切り替える設定
+type Options struct {
+	// EnumerationSafe が有効な場合、レスポンスの内容からユーザーの存在有無を判別できないようにします
+	// サインインではUserNotFoundExceptionとNotAuthorizedExceptionを同じ401にまとめ、
+	// パスワードリセット要求は常に成功を返します
+	EnumerationSafe bool
+	// MinResponseTime はEnumerationSafe時にレスポンスを返すまでの最短時間
+	// 処理時間の差からユーザーの存在有無を推測されないよう、これより早く終わった場合は待機します
+	MinResponseTime time.Duration
+	// Session が設定されている場合、サインインしたトークンをCookieで返すCookieセッションモードになります
+	Session *session.Manager
+	// OAuth が設定されている場合、ホストUIを利用した認可コードフローを有効にします
+	OAuth *oauth.Flow
+	// SignUpPolicy が設定されている場合、サインアップできるメールアドレスをPreSignUpトリガーと同じ条件で制限します
+	SignUpPolicy *signuppolicy.Policy
+	// TokenVerifier が設定されている場合、アクセストークンの署名とグループを確認する管理APIを有効にします
+	// 署名はローカルで検証するため、グループからの削除やサインアウトはアクセストークンの有効期限まで反映されません
+	TokenVerifier *jwks.Verifier
+	// AdminGroup は管理APIを使えるユーザーのグループ（cognito:groups）。空の場合は "admin"
+	AdminGroup string
+}
+
+// adminGroup は管理APIを使えるグループを返します
+func (o Options) adminGroup() string {
+	if o.AdminGroup == "" {
+		return "admin"
+	}
+	return o.AdminGroup
+}
+
+// padResponseTime はEnumerationSafe時にstartからMinResponseTimeが経過するまで待機します
+func (o Options) padResponseTime(ctx context.Context, start time.Time) {
+	if !o.EnumerationSafe || o.MinResponseTime <= 0 {
+		return
+	}
+	wait := o.MinResponseTime - time.Since(start)
+	if wait <= 0 {
+		return
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
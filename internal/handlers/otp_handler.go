@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/cognito"
+	"cognito-lambda-handler/internal/mail"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+type OTPStartRequest struct {
+	Email string `json:"email" example:"user@example.com"`
+}
+
+type OTPVerifyRequest struct {
+	Email   string `json:"email" example:"user@example.com"`
+	Session string `json:"session" example:"AYABeD..."`
+	Code    string `json:"code" example:"123456"`
+	// DeviceName は新しいデバイスを登録する場合の名前。省略時はUser-Agentを使用します
+	DeviceName string `json:"device_name,omitempty" example:"iPhone 15"`
+}
+
+// OTPChallengeResponse は確認コードの送信先と、検証時に渡すセッション
+type OTPChallengeResponse struct {
+	Session     string `json:"session" example:"AYABeD..."`
+	Delivery    string `json:"delivery,omitempty" example:"EMAIL"`
+	Destination string `json:"destination,omitempty" example:"u***@example.com"`
+}
+
+// OTPRetryResponse はコードが誤っていて再入力できる場合のレスポンス
+type OTPRetryResponse struct {
+	Message string `json:"message" example:"Incorrect code"`
+	Session string `json:"session" example:"AYABeD..."`
+}
+
+// OTPStartHandler はメールの確認コードによるパスワードレスサインインを開始します
+func OTPStartHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	start := time.Now()
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	var req OTPStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	challenge, err := cognitoService.StartCustomAuth(r.Context(), req.Email)
+	recordAudit(r, audit.EventOTPStart, req.Email, err)
+	opts.padResponseTime(r.Context(), start)
+	if err != nil {
+		logCognitoError(r, "Error starting passwordless sign in", req.Email, err)
+		var awsErr smithy.APIError
+		if ok := errors.As(err, &awsErr); ok {
+			switch awsErr.ErrorCode() {
+			case "UserNotFoundException":
+				if opts.EnumerationSafe {
+					// 存在しないユーザーにもダミーのセッションを返し、検証時に失敗させる
+					writeFakeOTPChallenge(w, r, req.Email)
+				} else {
+					http.Error(w, "User does not exist", http.StatusNotFound)
+				}
+			case "NotAuthorizedException":
+				http.Error(w, "Passwordless sign-in is not available", http.StatusUnauthorized)
+			case "InvalidParameterException":
+				http.Error(w, "Invalid input parameters", http.StatusBadRequest)
+			case "TooManyRequestsException", "LimitExceededException":
+				http.Error(w, "Request limit exceeded", http.StatusTooManyRequests)
+			default:
+				http.Error(w, "Failed to start sign in", http.StatusInternalServerError)
+			}
+		} else {
+			http.Error(w, "Failed to start sign in", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	observeSession(challenge.Session, req.Email)
+	writeJSON(w, r, http.StatusOK, OTPChallengeResponse{
+		Session:     challenge.Session,
+		Delivery:    challenge.Parameters["delivery"],
+		Destination: challenge.Parameters["email"],
+	})
+}
+
+// OTPVerifyHandler は確認コードを検証し、正しければサインインと同じレスポンスを返します
+// コードが誤っていて再入力できる場合は、次のセッションを含む401を返します
+func OTPVerifyHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	var req OTPVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" || req.Session == "" || req.Code == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	authResult, next, err := cognitoService.RespondToCustomChallenge(r.Context(), req.Email, req.Session, req.Code)
+	if err == nil && authResult == nil {
+		recordAudit(r, audit.EventOTPSignIn, req.Email, errors.New("incorrect code"))
+		writeJSON(w, r, http.StatusUnauthorized, OTPRetryResponse{Message: "Incorrect code", Session: next.Session})
+		return
+	}
+	recordAudit(r, audit.EventOTPSignIn, req.Email, err)
+	if err != nil {
+		var awsErr smithy.APIError
+		if ok := errors.As(err, &awsErr); ok {
+			switch awsErr.ErrorCode() {
+			case "NotAuthorizedException", "UserNotFoundException", "CodeMismatchException", "ExpiredCodeException":
+				http.Error(w, "Incorrect code or session expired", http.StatusUnauthorized)
+			case "InvalidParameterException":
+				http.Error(w, "Invalid input parameters", http.StatusBadRequest)
+			case "TooManyRequestsException":
+				http.Error(w, "Request limit exceeded", http.StatusTooManyRequests)
+			default:
+				http.Error(w, "Failed to sign in user", http.StatusInternalServerError)
+			}
+		} else {
+			http.Error(w, "Failed to sign in user", http.StatusInternalServerError)
+		}
+		logCognitoError(r, "Error verifying sign-in code", req.Email, err)
+		return
+	}
+
+	writeSignInResult(w, r, cognitoService, opts, req.Email, req.DeviceName, authResult)
+}
+
+// sessionShape はCognitoが返すセッションの形式
+// セッションはAWS Encryption SDKで暗号化したメッセージをBase64でエンコードしたもので、
+// 暗号文の長さはユーザー名の長さに応じて変わります
+type sessionShape struct {
+	encoding *base64.Encoding
+	// header はメッセージの先頭のバージョン・種類・アルゴリズムで、同じユーザープールでは変わりません
+	header []byte
+	// overhead はデコードしたセッションの長さからユーザー名の長さを引いた値
+	overhead int
+}
+
+// sessionHeaderLen はsessionShape.headerとして実際のセッションから写す長さ
+const sessionHeaderLen = 4
+
+var (
+	sessionShapeMu sync.RWMutex
+	// lastSessionShape はCognitoから最後に受け取ったセッションの形式
+	// 受け取るまではESDKのバージョン1・AES-256-GCM（0x0178）のおおよその長さを使います
+	lastSessionShape = sessionShape{
+		encoding: base64.URLEncoding,
+		header:   []byte{0x01, 0x80, 0x01, 0x78},
+		overhead: 680,
+	}
+)
+
+// observeSession はCognitoが返したセッションの形式を記録し、ダミーのセッションを同じ形式にします
+func observeSession(session, username string) {
+	for _, enc := range []*base64.Encoding{base64.URLEncoding, base64.RawURLEncoding, base64.StdEncoding, base64.RawStdEncoding} {
+		b, err := enc.DecodeString(session)
+		if err != nil || len(b) < sessionHeaderLen+len(username) {
+			continue
+		}
+		sessionShapeMu.Lock()
+		lastSessionShape = sessionShape{
+			encoding: enc,
+			header:   b[:sessionHeaderLen],
+			overhead: len(b) - len(username),
+		}
+		sessionShapeMu.Unlock()
+		return
+	}
+}
+
+// fakeSession はusernameに対してCognitoが返すセッションと同じ形式・長さのダミーのセッションを生成します
+func fakeSession(username string) (string, error) {
+	sessionShapeMu.RLock()
+	shape := lastSessionShape
+	sessionShapeMu.RUnlock()
+
+	b := make([]byte, shape.overhead+len(username))
+	copy(b, shape.header)
+	if _, err := rand.Read(b[len(shape.header):]); err != nil {
+		return "", err
+	}
+	return shape.encoding.EncodeToString(b), nil
+}
+
+// writeFakeOTPChallenge は実在するユーザーと見分けがつかないダミーのチャレンジを返します
+// 送信先はCreateAuthChallengeトリガーと同じ方法で伏せたメールアドレスです
+func writeFakeOTPChallenge(w http.ResponseWriter, r *http.Request, email string) {
+	session, err := fakeSession(email)
+	if err != nil {
+		http.Error(w, "Failed to start sign in", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, OTPChallengeResponse{
+		Session:     session,
+		Delivery:    "EMAIL",
+		Destination: mail.MaskAddress(email),
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"cognito-lambda-handler/internal/cognito"
+	"cognito-lambda-handler/internal/mail"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTripFunc は関数でレスポンスを返すhttp.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newStubCognito はCognitoのAPIをfnで応答するServiceを作成します
+// fnは操作名（例: InitiateAuth）とリクエストのJSONを受け取り、ステータスとレスポンスを返します
+// エラーは {"__type": "UserNotFoundException"} のように返します
+func newStubCognito(t *testing.T, fn func(op string, in map[string]any) (int, any)) *cognito.Service {
+	t.Helper()
+	t.Setenv("AWS_COGNITO_CLIENT_SECRET", "secret")
+	// 独自のTransportを使うため、CAバンドルの設定を無効にする
+	t.Setenv("AWS_CA_BUNDLE", "")
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var in map[string]any
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			return nil, err
+		}
+		op := strings.TrimPrefix(req.Header.Get("X-Amz-Target"), "AWSCognitoIdentityProviderService.")
+		status, out := fn(op, in)
+		b, err := json.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.1"}},
+			Body:       io.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+	service, err := cognito.NewCognitoService("client", "secret", "pool",
+		config.WithRegion("us-east-1"),
+		config.WithCredentialsProvider(aws.AnonymousCredentials{}),
+		config.WithHTTPClient(&http.Client{Transport: transport}),
+		config.WithRetryMaxAttempts(1),
+	)
+	assert.NoError(t, err)
+	return service
+}
+
+// 存在しないユーザーへのダミーのチャレンジが、実在するユーザーへのレスポンスと同じキー・長さになることを確認
+func TestOTPStartHandler_FakeChallenge(t *testing.T) {
+	// Cognitoのセッションと同じくESDKのヘッダーで始まるURLセーフなBase64
+	sessionBytes := make([]byte, 900)
+	copy(sessionBytes, []byte{0x01, 0x80, 0x01, 0x78})
+	rand.Read(sessionBytes[4:])
+	realSession := base64.RawURLEncoding.EncodeToString(sessionBytes)
+
+	service := newStubCognito(t, func(op string, in map[string]any) (int, any) {
+		email := in["AuthParameters"].(map[string]any)["USERNAME"].(string)
+		if email != "taro@example.com" {
+			return http.StatusBadRequest, map[string]string{"__type": "UserNotFoundException", "message": "User does not exist."}
+		}
+		return http.StatusOK, map[string]any{
+			"ChallengeName":       "CUSTOM_CHALLENGE",
+			"Session":             realSession,
+			"ChallengeParameters": map[string]string{"delivery": "EMAIL", "email": mail.MaskAddress(email)},
+		}
+	})
+	opts := Options{EnumerationSafe: true}
+
+	start := func(email string) map[string]string {
+		req := httptest.NewRequest(http.MethodPost, "/otp/start", strings.NewReader(`{"email":"`+email+`"}`))
+		rec := httptest.NewRecorder()
+		OTPStartHandler(rec, req, service, opts)
+		assert.Equal(t, http.StatusOK, rec.Code, email)
+		var body map[string]string
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body
+	}
+	want := start("taro@example.com")
+	got := start("hana@example.com")
+
+	assert.Equal(t, realSession, want["session"])
+	assert.Equal(t, "h***@example.com", got["destination"])
+	assert.Len(t, got, len(want))
+	for key, value := range want {
+		assert.Len(t, got[key], len(value), key)
+	}
+	assert.Equal(t, want["session"][:5], got["session"][:5])
+	assert.NotEqual(t, want["session"], got["session"])
+	_, err := base64.RawURLEncoding.DecodeString(got["session"])
+	assert.NoError(t, err)
+}
@@ -1,19 +1,20 @@
 package handlers
 
 import (
+	"cognito-lambda-handler/internal/audit"
 	"cognito-lambda-handler/internal/cognito"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/aws/smithy-go"
 )
 
 type ResetPasswordRequest struct {
-	Email       string `json:"email"`
-	Code        string `json:"code"`
-	NewPassword string `json:"new_password"`
+	Email       string `json:"email" example:"user@example.com"`
+	Code        string `json:"code" example:"123456"`
+	NewPassword string `json:"new_password" example:"NewPassword123!"`
 }
 
 func ResetPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service) {
@@ -29,7 +30,8 @@ func ResetPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoService
 		return
 	}
 
-	err = cognitoService.ResetPassword(req.Email, req.Code, req.NewPassword)
+	err = cognitoService.ResetPassword(r.Context(), req.Email, req.Code, req.NewPassword)
+	recordAudit(r, audit.EventResetPassword, req.Email, err)
 	if err != nil {
 		var awsErr smithy.APIError
 		if ok := errors.As(err, &awsErr); ok {
@@ -44,14 +46,14 @@ func ResetPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoService
 		} else {
 			http.Error(w, "Failed to reset password", http.StatusInternalServerError)
 		}
-		log.Printf("Error resetting password for user %s: %v", req.Email, err)
+		logCognitoError(r, "Error resetting password", req.Email, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]string{"message": "Password reset successful"}); err != nil {
-		log.Printf("Error encoding response for user %s: %v", req.Email, err)
+	if err := json.NewEncoder(w).Encode(MessageResponse{Message: "Password reset successful"}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "email", req.Email, "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"cognito-lambda-handler/internal/audit"
+	"cognito-lambda-handler/internal/cognito"
+	"cognito-lambda-handler/internal/session"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/aws/smithy-go"
+)
+
+// RefreshHandler はCookieのリフレッシュトークンでアクセストークンを更新します
+// CSRFトークンの確認はルート側でsession.Manager.RequireCSRFを適用して行います
+func RefreshHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	if opts.Session == nil {
+		http.Error(w, "Cookie session is not enabled", http.StatusNotFound)
+		return
+	}
+
+	username, refreshToken, err := opts.Session.RefreshToken(r)
+	if err != nil {
+		http.Error(w, "No active session", http.StatusUnauthorized)
+		return
+	}
+
+	authResult, err := cognitoService.RefreshTokens(r.Context(), username, refreshToken)
+	recordAudit(r, audit.EventRefresh, username, err)
+	if err != nil {
+		var awsErr smithy.APIError
+		if errors.As(err, &awsErr) && awsErr.ErrorCode() == "NotAuthorizedException" {
+			// 失効・取り消し済みのリフレッシュトークンはCookieごと破棄する
+			opts.Session.Clear(w)
+			http.Error(w, "Session expired", http.StatusUnauthorized)
+		} else {
+			http.Error(w, "Failed to refresh session", http.StatusInternalServerError)
+		}
+		logCognitoError(r, "Error refreshing session", username, err)
+		return
+	}
+
+	if err := setSessionCookies(w, opts.Session, username, authResult, false); err != nil {
+		slog.ErrorContext(r.Context(), "Error setting session cookies", "username", username, "error", err)
+		http.Error(w, "Failed to refresh session", http.StatusInternalServerError)
+		return
+	}
+
+	resp := SignInResponse{
+		Token:     aws.ToString(authResult.AccessToken),
+		IdToken:   aws.ToString(authResult.IdToken),
+		ExpiresIn: authResult.ExpiresIn,
+	}
+	if opts.Session.AccessTokenInCookie() {
+		resp.Token = ""
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "username", username, "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// LogoutHandler はリフレッシュトークンを取り消し、セッションのCookieを削除します
+func LogoutHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+	if opts.Session == nil {
+		http.Error(w, "Cookie session is not enabled", http.StatusNotFound)
+		return
+	}
+
+	// Cookieは取り消しの成否に関わらず削除する
+	opts.Session.Clear(w)
+	if username, refreshToken, err := opts.Session.RefreshToken(r); err == nil {
+		err = cognitoService.RevokeToken(r.Context(), refreshToken)
+		recordAudit(r, audit.EventLogout, username, err)
+		if err != nil {
+			logCognitoError(r, "Error revoking refresh token", username, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(MessageResponse{Message: "Signed out"}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// setSessionCookies はCognitoの認証結果をCookieに設定します
+func setSessionCookies(w http.ResponseWriter, m *session.Manager, username string, result *types.AuthenticationResultType, start bool) error {
+	return m.SetTokens(w, session.Tokens{
+		Username:     username,
+		AccessToken:  aws.ToString(result.AccessToken),
+		RefreshToken: aws.ToString(result.RefreshToken),
+		ExpiresIn:    time.Duration(result.ExpiresIn) * time.Second,
+	}, start)
+}
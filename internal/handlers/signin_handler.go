@@ -1,21 +1,43 @@
 package handlers
 
 import (
+	"cognito-lambda-handler/internal/audit"
 	"cognito-lambda-handler/internal/cognito"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
 	"github.com/aws/smithy-go"
 )
 
 type SignInRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" example:"user@example.com"`
+	Password string `json:"password" example:"Password123!"`
+	// Device は前回のサインインで登録した記憶済みデバイス
+	Device *DeviceCredentials `json:"device,omitempty"`
+	// DeviceName は新しいデバイスを登録する場合の名前。省略時はUser-Agentを使用します
+	DeviceName string `json:"device_name,omitempty" example:"iPhone 15"`
 }
 
-func SignInHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service) {
+// NewPasswordRequest はNEW_PASSWORD_REQUIREDチャレンジに応答するリクエスト
+type NewPasswordRequest struct {
+	Email string `json:"email" example:"user@example.com"`
+	// Username はサインインのレスポンスのusername。USERNAMEとSECRET_HASHに使用します
+	Username    string `json:"username" example:"a36036a8-9061-424d-a737-56d57dae7bc6"`
+	Session     string `json:"session" example:"AYABeD..."`
+	NewPassword string `json:"new_password" example:"NewPassword123!"`
+	// Attributes はサインインのレスポンスのrequired_attributesに対応する属性名と値
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// DeviceName は新しいデバイスを登録する場合の名前。省略時はUser-Agentを使用します
+	DeviceName string `json:"device_name,omitempty" example:"iPhone 15"`
+}
+
+func SignInHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	start := time.Now()
 	if cognitoService == nil {
 		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
 		return
@@ -28,8 +50,18 @@ func SignInHandler(w http.ResponseWriter, r *http.Request, cognitoService *cogni
 		return
 	}
 
-	token, err := cognitoService.SignIn(req.Email, req.Password)
-	//authResult, err := cognitoService.SignIn(req.Email, req.Password)
+	var device *cognito.DeviceCredentials
+	if req.Device != nil {
+		device = &cognito.DeviceCredentials{Key: req.Device.Key, GroupKey: req.Device.GroupKey, Password: req.Device.Password}
+	}
+	authResult, challenge, err := cognitoService.SignIn(r.Context(), req.Email, req.Password, device)
+	if challenge != nil {
+		// パスワードは正しいが、新しいパスワードを設定するまでサインインは完了しない
+		recordChallengeAudit(r, audit.EventSignIn, req.Email)
+	} else {
+		recordAudit(r, audit.EventSignIn, req.Email, err)
+	}
+	opts.padResponseTime(r.Context(), start)
 	if err != nil {
 		var awsErr smithy.APIError
 		if ok := errors.As(err, &awsErr); ok {
@@ -37,7 +69,12 @@ func SignInHandler(w http.ResponseWriter, r *http.Request, cognitoService *cogni
 			case "NotAuthorizedException":
 				http.Error(w, "Incorrect username or password", http.StatusUnauthorized)
 			case "UserNotFoundException":
-				http.Error(w, "User does not exist", http.StatusNotFound)
+				if opts.EnumerationSafe {
+					// 存在しないユーザーもパスワード誤りと同じレスポンスにする
+					http.Error(w, "Incorrect username or password", http.StatusUnauthorized)
+				} else {
+					http.Error(w, "User does not exist", http.StatusNotFound)
+				}
 			case "InvalidParameterException":
 				http.Error(w, "Invalid input parameters", http.StatusBadRequest)
 			default:
@@ -46,27 +83,96 @@ func SignInHandler(w http.ResponseWriter, r *http.Request, cognitoService *cogni
 		} else {
 			http.Error(w, "Failed to sign in user", http.StatusInternalServerError)
 		}
-		log.Printf("Error signing in user %s: %v", req.Email, err)
+		logCognitoError(r, "Error signing in user", req.Email, err)
+		return
+	}
+
+	if challenge != nil {
+		// 一時パスワードでのサインインは、新しいパスワードを設定するまでトークンを発行しない
+		writeJSON(w, r, http.StatusOK, NewPasswordRequiredResponse{
+			Challenge:          "NEW_PASSWORD_REQUIRED",
+			Username:           challenge.Username,
+			Session:            challenge.Session,
+			RequiredAttributes: challenge.RequiredAttributes,
+		})
+		return
+	}
+
+	writeSignInResult(w, r, cognitoService, opts, req.Email, req.DeviceName, authResult)
+}
+
+// NewPasswordHandler は一時パスワードでのサインインに続けて新しいパスワードを設定し、サインインと同じレスポンスを返します
+func NewPasswordHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
+	if cognitoService == nil {
+		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	var req NewPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" || req.Username == "" || req.Session == "" || req.NewPassword == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	authResult, err := cognitoService.RespondToNewPasswordChallenge(r.Context(), req.Username, req.Session, req.NewPassword, req.Attributes)
+	recordAudit(r, audit.EventNewPassword, req.Email, err)
+	if err != nil {
+		var awsErr smithy.APIError
+		if ok := errors.As(err, &awsErr); ok {
+			switch awsErr.ErrorCode() {
+			case "NotAuthorizedException", "UserNotFoundException", "CodeMismatchException", "ExpiredCodeException":
+				http.Error(w, "Session expired", http.StatusUnauthorized)
+			case "InvalidPasswordException":
+				http.Error(w, "Password does not meet requirements", http.StatusBadRequest)
+			case "InvalidParameterException":
+				http.Error(w, "Invalid input parameters", http.StatusBadRequest)
+			case "TooManyRequestsException":
+				http.Error(w, "Request limit exceeded", http.StatusTooManyRequests)
+			default:
+				http.Error(w, "Failed to set new password", http.StatusInternalServerError)
+			}
+		} else {
+			http.Error(w, "Failed to set new password", http.StatusInternalServerError)
+		}
+		logCognitoError(r, "Error setting new password", req.Email, err)
 		return
 	}
 
+	writeSignInResult(w, r, cognitoService, opts, req.Email, req.DeviceName, authResult)
+}
+
+// writeSignInResult はサインインに成功したトークンをレスポンスに書き込みます
+// 新しいデバイスの登録と、Cookieセッションモードでのクッキーの設定も行います
+func writeSignInResult(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options, email, deviceName string, authResult *types.AuthenticationResultType) {
+	resp := SignInResponse{
+		Token:     aws.ToString(authResult.AccessToken),
+		IdToken:   aws.ToString(authResult.IdToken),
+		ExpiresIn: authResult.ExpiresIn,
+	}
+	if authResult.NewDeviceMetadata != nil {
+		// 新しいデバイスは登録に失敗してもサインイン自体は成功として扱う
+		resp.Device = confirmNewDevice(r, cognitoService, authResult, email, deviceName)
+	}
+	if opts.Session != nil {
+		// リフレッシュトークンはHttpOnlyのCookieでのみ返す
+		username, err := cognito.UsernameFromAccessToken(aws.ToString(authResult.AccessToken))
+		if err == nil {
+			err = setSessionCookies(w, opts.Session, username, authResult, true)
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error setting session cookies", "email", email, "error", err)
+			http.Error(w, "Failed to sign in user", http.StatusInternalServerError)
+			return
+		}
+		if opts.Session.AccessTokenInCookie() {
+			resp.Token = ""
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]string{"token": token}); err != nil {
-		log.Printf("Error encoding response for user %s: %v", req.Email, err)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "email", email, "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
-
-	//response := map[string]interface{}{
-	//	"accessToken":  *authResult.AccessToken,
-	//	"idToken":      *authResult.IdToken,
-	//	"refreshToken": authResult.RefreshToken,
-	//	"tokenType":    authResult.TokenType,
-	//	"expiresIn":    authResult.ExpiresIn,
-	//}
-	//
-	//if err := json.NewEncoder(w).Encode(response); err != nil {
-	//	log.Printf("Error encoding response for user %s: %v", req.Email, err)
-	//	http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	//}
 }
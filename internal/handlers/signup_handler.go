@@ -1,24 +1,26 @@
 package handlers
 
 import (
+	"cognito-lambda-handler/internal/audit"
 	"cognito-lambda-handler/internal/cognito"
+	"cognito-lambda-handler/internal/signuppolicy"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/aws/smithy-go"
 )
 
 type SignUpRequest struct {
-	Email       string `json:"email"`
-	Password    string `json:"password"`
-	PhoneNumber string `json:"phone_number"`
-	GivenName   string `json:"given_name"`
-	FamilyName  string `json:"family_name"`
+	Email       string `json:"email" example:"user@example.com"`
+	Password    string `json:"password" example:"Password123!"`
+	PhoneNumber string `json:"phone_number" example:"+819012345678"`
+	GivenName   string `json:"given_name" example:"Taro"`
+	FamilyName  string `json:"family_name" example:"Yamada"`
 }
 
-func SignUpHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service) {
+func SignUpHandler(w http.ResponseWriter, r *http.Request, cognitoService *cognito.Service, opts Options) {
 	// Cognitoサービスの初期化確認
 	if cognitoService == nil {
 		http.Error(w, "Cognito service is not initialized", http.StatusInternalServerError)
@@ -34,8 +36,17 @@ func SignUpHandler(w http.ResponseWriter, r *http.Request, cognitoService *cogni
 		return
 	}
 
+	// PreSignUpトリガーと同じ条件でメールアドレスを検証
+	if opts.SignUpPolicy != nil {
+		if _, err := opts.SignUpPolicy.Check(req.Email); err != nil {
+			writeSignUpRejection(w, r, req.Email, err)
+			return
+		}
+	}
+
 	// サインアップ処理の呼び出し
-	err = cognitoService.SignUp(req.Email, req.Password, req.PhoneNumber, req.GivenName, req.FamilyName)
+	err = cognitoService.SignUp(r.Context(), req.Email, req.Password, req.PhoneNumber, req.GivenName, req.FamilyName)
+	recordAudit(r, audit.EventSignUp, req.Email, err)
 	if err != nil {
 		var awsErr smithy.APIError
 		// AWSエラーが発生した場合の処理
@@ -59,6 +70,9 @@ func SignUpHandler(w http.ResponseWriter, r *http.Request, cognitoService *cogni
 			case "ResourceNotFoundException":
 				// リソースが見つからない場合
 				http.Error(w, "Resource not found", http.StatusNotFound)
+			case "UserLambdaValidationException":
+				// PreSignUpトリガーで拒否された場合
+				http.Error(w, "Sign up is not allowed", http.StatusForbidden)
 			default:
 				// その他のエラー
 				http.Error(w, "Failed to sign up user", http.StatusInternalServerError)
@@ -67,15 +81,30 @@ func SignUpHandler(w http.ResponseWriter, r *http.Request, cognitoService *cogni
 			// その他のエラー
 			http.Error(w, "Failed to sign up user", http.StatusInternalServerError)
 		}
-		log.Printf("Error signing up user %s: %v", req.Email, err)
+		logCognitoError(r, "Error signing up user", req.Email, err)
 		return
 	}
 
 	// 成功メッセージの返却
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]string{"message": "Sign up successful"}); err != nil {
-		log.Printf("Error encoding response for user %s: %v", req.Email, err)
+	if err := json.NewEncoder(w).Encode(MessageResponse{Message: "Sign up successful"}); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "email", req.Email, "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
+
+// writeSignUpRejection はサインアップの拒否理由をAccept-Languageの言語で返します
+func writeSignUpRejection(w http.ResponseWriter, r *http.Request, email string, err error) {
+	var rejection *signuppolicy.Rejection
+	if !errors.As(err, &rejection) {
+		http.Error(w, "Failed to sign up user", http.StatusInternalServerError)
+		return
+	}
+	recordAudit(r, audit.EventSignUp, email, err)
+	status := http.StatusForbidden
+	if rejection.Reason == signuppolicy.ReasonInvalidEmail {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, rejection.Message(signuppolicy.AcceptLanguage(r.Header.Get("Accept-Language"))...), status)
+}
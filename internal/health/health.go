@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ステータスの値
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusFailed      = "failed"
+)
+
+// Check は1つの依存関係の確認処理
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// CheckResult は1つの確認結果
+// Errorは依存先のエンドポイントなどを含むことがあるため、レスポンスには含めずログにのみ出力します
+type CheckResult struct {
+	Status    string `json:"status"`
+	Error     string `json:"-"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report はレディネスチェック全体の結果
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker は登録された確認処理を並行して実行します
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker は新しいCheckerを作成します
+// timeoutは各確認処理の制限時間です
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Run は全ての確認処理を実行し、1つでも失敗した場合はStatusUnavailableを返します
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(c.checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(checkCtx)
+			result := CheckResult{Status: StatusOK, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusFailed
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if err != nil {
+				report.Status = StatusUnavailable
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
@@ -0,0 +1,32 @@
+package httpx
+
+import (
+	"net/http"
+)
+
+// StatusRecorder は後続ハンドラーが返したステータスコードを記録するResponseWriter
+type StatusRecorder struct {
+	http.ResponseWriter
+	Status int
+}
+
+// NewStatusRecorder はwをラップしたStatusRecorderを作成します
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w}
+}
+
+// WriteHeader はステータスコードを記録してから書き込みます
+func (rec *StatusRecorder) WriteHeader(statusCode int) {
+	if rec.Status == 0 {
+		rec.Status = statusCode
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write はWriteHeaderが呼ばれていない場合に200を記録します
+func (rec *StatusRecorder) Write(b []byte) (int, error) {
+	if rec.Status == 0 {
+		rec.Status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP はリクエスト元のIPアドレスを返します
+// X-Forwarded-Forはクライアントが偽装できるため、RemoteAddr（Lambdaでは API GatewayのsourceIp）を優先します
+func ClientIP(r *http.Request) string {
+	if r.RemoteAddr != "" {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	}
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		parts := strings.Split(fwd, ",")
+		return strings.TrimSpace(parts[len(parts)-1])
+	}
+	return ""
+}
+
+// NormalizeEmail はメールアドレスを比較用に正規化します
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// PeekEmail はJSONボディのemailフィールドを正規化して返します
+// ボディは読み取り後に元へ戻すため、後続のハンドラーでも再度読み取れます
+func PeekEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return NormalizeEmail(payload.Email)
+}
@@ -0,0 +1,92 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentity"
+	"strings"
+	"time"
+)
+
+// Credentials はIDプールから払い出された一時的なAWS認証情報
+type Credentials struct {
+	IdentityID   string
+	AccessKeyID  string
+	SecretKey    string
+	SessionToken string
+	Expiration   time.Time
+}
+
+// Service はユーザープールのIDトークンをIDプールの一時認証情報に交換します
+type Service struct {
+	client         *cognitoidentity.Client
+	identityPoolId string
+	providerKey    string
+}
+
+// NewIdentityService はIDプールのサービスを作成します
+// ログインプロバイダーのキーはユーザープールIDから "cognito-idp.<region>.amazonaws.com/<poolId>" として組み立てます
+func NewIdentityService(identityPoolId, userPoolId string, optFns ...func(*config.LoadOptions) error) (*Service, error) {
+	key, err := ProviderKey(userPoolId)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(), optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %w", err)
+	}
+
+	return &Service{
+		client:         cognitoidentity.NewFromConfig(cfg),
+		identityPoolId: identityPoolId,
+		providerKey:    key,
+	}, nil
+}
+
+// ProviderKey はユーザープールIDからIDプールのLoginsに指定するプロバイダー名を返します
+func ProviderKey(userPoolId string) (string, error) {
+	region, _, ok := strings.Cut(userPoolId, "_")
+	if !ok || region == "" {
+		return "", fmt.Errorf("invalid Cognito User Pool ID (%s), must be in format: '<region>_<pool name>'", userPoolId)
+	}
+	return fmt.Sprintf("cognito-idp.%s.amazonaws.com/%s", region, userPoolId), nil
+}
+
+// GetCredentials はIDトークンからIdentity IDを取得し（GetId）、一時認証情報を払い出します（GetCredentialsForIdentity）
+func (s *Service) GetCredentials(ctx context.Context, idToken string) (creds *Credentials, err error) {
+	ctx, span := tracer.Start(ctx, "identity.GetCredentials")
+	defer func() { endSpan(span, err) }()
+
+	logins := map[string]string{s.providerKey: idToken}
+
+	idOutput, err := s.client.GetId(ctx, &cognitoidentity.GetIdInput{
+		IdentityPoolId: aws.String(s.identityPoolId),
+		Logins:         logins,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity ID: %w", err)
+	}
+
+	credsOutput, err := s.client.GetCredentialsForIdentity(ctx, &cognitoidentity.GetCredentialsForIdentityInput{
+		IdentityId: idOutput.IdentityId,
+		Logins:     logins,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials for identity: %w", err)
+	}
+	if credsOutput.Credentials == nil {
+		return nil, fmt.Errorf("identity pool returned no credentials")
+	}
+
+	c := credsOutput.Credentials
+	return &Credentials{
+		IdentityID:   aws.ToString(credsOutput.IdentityId),
+		AccessKeyID:  aws.ToString(c.AccessKeyId),
+		SecretKey:    aws.ToString(c.SecretKey),
+		SessionToken: aws.ToString(c.SessionToken),
+		Expiration:   aws.ToTime(c.Expiration),
+	}, nil
+}
@@ -0,0 +1,17 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ユーザープールIDからIDプールのログインプロバイダー名を組み立てられることを確認
+func TestProviderKey(t *testing.T) {
+	key, err := ProviderKey("ap-northeast-1_AbCdEf123")
+	assert.NoError(t, err)
+	assert.Equal(t, "cognito-idp.ap-northeast-1.amazonaws.com/ap-northeast-1_AbCdEf123", key)
+
+	_, err = ProviderKey("invalid")
+	assert.Error(t, err)
+}
@@ -0,0 +1,20 @@
+package identity
+
+import (
+	"cognito-lambda-handler/internal/cognito"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer はServiceの各メソッドのスパンを作成します
+var tracer = otel.Tracer("cognito-lambda-handler/internal/identity")
+
+// endSpan はエラーがあればスパンに記録してから終了します
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, cognito.ErrorCode(err))
+	}
+	span.End()
+}
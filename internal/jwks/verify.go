@@ -0,0 +1,162 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken はトークンの形式・署名・クレームが正しくない場合のエラー
+var ErrInvalidToken = errors.New("invalid token")
+
+// refetchInterval は未知のkidのトークンを受け取った場合にJWKSを再取得する最短の間隔
+const refetchInterval = time.Minute
+
+// Claims はCognitoのアクセストークンのクレーム
+type Claims struct {
+	Subject  string   `json:"sub"`
+	Username string   `json:"username"`
+	ClientID string   `json:"client_id"`
+	TokenUse string   `json:"token_use"`
+	Issuer   string   `json:"iss"`
+	Expires  int64    `json:"exp"`
+	Scope    string   `json:"scope"`
+	Groups   []string `json:"cognito:groups"`
+}
+
+// Verifier はユーザープールのアクセストークンの署名（RS256）とクレームを検証します
+// 公開鍵は最初の検証時に取得し、未知のkidのトークンを受け取った場合に再取得します
+type Verifier struct {
+	URL      string // JWKSのURL
+	Issuer   string
+	ClientID string // 空でない場合、client_idが一致するトークンのみ受け付けます
+	Client   *http.Client
+
+	mu        sync.Mutex
+	keys      KeySet
+	fetchedAt time.Time
+	now       func() time.Time
+}
+
+// NewVerifier はユーザープールのJWKSと発行者でトークンを検証するVerifierを作成します
+func NewVerifier(poolId, clientId string) (*Verifier, error) {
+	url, err := URL(poolId)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{
+		URL:      url,
+		Issuer:   strings.TrimSuffix(url, "/.well-known/jwks.json"),
+		ClientID: clientId,
+		now:      time.Now,
+	}, nil
+}
+
+// Verify はアクセストークンを検証してクレームを返します
+// トークンが正しくない場合はErrInvalidTokenを返します
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("%w: signature verification failed", ErrInvalidToken)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	switch {
+	case claims.Issuer != v.Issuer:
+		return nil, fmt.Errorf("%w: issuer mismatch", ErrInvalidToken)
+	case claims.TokenUse != "access":
+		return nil, fmt.Errorf("%w: not an access token", ErrInvalidToken)
+	case v.ClientID != "" && claims.ClientID != v.ClientID:
+		return nil, fmt.Errorf("%w: client id mismatch", ErrInvalidToken)
+	case v.clock().Unix() >= claims.Expires:
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	return &claims, nil
+}
+
+// key はkidの公開鍵を返します。未知のkidの場合はJWKSを再取得します
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if v.keys != nil && v.clock().Sub(v.fetchedAt) < refetchInterval {
+		return nil, fmt.Errorf("%w: unknown key id", ErrInvalidToken)
+	}
+	keys, err := Fetch(ctx, v.Client, v.URL)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetchedAt = keys, v.clock()
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key id", ErrInvalidToken)
+}
+
+func (v *Verifier) clock() time.Time {
+	if v.now == nil {
+		return time.Now()
+	}
+	return v.now()
+}
+
+func decodeSegment(segment string, dst any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	return nil
+}
+
+// HasGroup はトークンのユーザーがいずれかのグループに所属しているかを返します
+func (c *Claims) HasGroup(groups ...string) bool {
+	for _, g := range c.Groups {
+		for _, want := range groups {
+			if g == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testIssuer = "https://cognito-idp.ap-northeast-1.amazonaws.com/ap-northeast-1_EXAMPLE"
+
+// sign はテスト用にRS256のトークンを作成します
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// testVerifier はkey1の公開鍵を返すJWKSサーバーとVerifierを作成します
+func testVerifier(t *testing.T, key *rsa.PrivateKey, now time.Time) (*Verifier, *int) {
+	t.Helper()
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "key1",
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(srv.Close)
+	return &Verifier{URL: srv.URL, Issuer: testIssuer, ClientID: "client", now: func() time.Time { return now }}, &fetches
+}
+
+// 署名とクレームが正しいアクセストークンのみ受け付けることを確認
+func TestVerifier_Verify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	v, fetches := testVerifier(t, key, now)
+
+	valid := map[string]any{
+		"sub": "user-sub", "username": "taro", "client_id": "client", "token_use": "access",
+		"iss": testIssuer, "exp": now.Add(time.Hour).Unix(), "cognito:groups": []string{"admin"},
+	}
+	claims, err := v.Verify(context.Background(), sign(t, key, "key1", valid))
+	assert.NoError(t, err)
+	assert.Equal(t, "taro", claims.Username)
+	assert.True(t, claims.HasGroup("support", "admin"))
+	assert.False(t, claims.HasGroup("support"))
+
+	invalid := map[string]map[string]any{
+		"expired":   {"exp": now.Unix()},
+		"id token":  {"token_use": "id"},
+		"issuer":    {"iss": "https://example.com"},
+		"client id": {"client_id": "other"},
+	}
+	for name, override := range invalid {
+		c := map[string]any{}
+		for k, v := range valid {
+			c[k] = v
+		}
+		for k, v := range override {
+			c[k] = v
+		}
+		_, err := v.Verify(context.Background(), sign(t, key, "key1", c))
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	_, err = v.Verify(context.Background(), sign(t, other, "key1", valid))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// 未知のkidでは直後にJWKSを再取得しない
+	_, err = v.Verify(context.Background(), sign(t, key, "key2", valid))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, 1, *fetches)
+}
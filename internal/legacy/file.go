@@ -0,0 +1,71 @@
+package legacy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileUser はJSONファイルに保存するユーザー
+type FileUser struct {
+	Username     string            `json:"username"`
+	PasswordHash string            `json:"password_hash"` // bcryptのハッシュ
+	Attributes   map[string]string `json:"attributes"`
+}
+
+// FileAuthenticator はJSONファイル（FileUserの配列）でユーザーを確認するAuthenticator
+// ユーザー名は大文字小文字を区別せずに照合します
+type FileAuthenticator struct {
+	users map[string]FileUser
+}
+
+// LoadFile はJSONファイルからFileAuthenticatorを作成します
+func LoadFile(path string) (*FileAuthenticator, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy users: %w", err)
+	}
+	var users []FileUser
+	if err := json.Unmarshal(b, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse legacy users: %w", err)
+	}
+	a := &FileAuthenticator{users: make(map[string]FileUser, len(users))}
+	for _, u := range users {
+		if u.Username == "" || u.PasswordHash == "" {
+			return nil, fmt.Errorf("legacy user requires username and password_hash")
+		}
+		a.users[strings.ToLower(u.Username)] = u
+	}
+	return a, nil
+}
+
+// Authenticate はハッシュとパスワードを比較します
+func (a *FileAuthenticator) Authenticate(ctx context.Context, username, password string) (*User, error) {
+	u, ok := a.users[strings.ToLower(username)]
+	if !ok {
+		return nil, notFound(password)
+	}
+	if err := checkPassword(u.PasswordHash, password); err != nil {
+		return nil, err
+	}
+	return toUser(u), nil
+}
+
+// Lookup はユーザーを返します
+func (a *FileAuthenticator) Lookup(ctx context.Context, username string) (*User, error) {
+	u, ok := a.users[strings.ToLower(username)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return toUser(u), nil
+}
+
+func toUser(u FileUser) *User {
+	attrs := make(map[string]string, len(u.Attributes))
+	for k, v := range u.Attributes {
+		attrs[k] = v
+	}
+	return &User{Username: u.Username, Attributes: attrs}
+}
@@ -0,0 +1,56 @@
+package legacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrNotFound は移行元にユーザーが存在しない場合のエラー
+	ErrNotFound = errors.New("legacy user not found")
+	// ErrInvalidPassword はパスワードが一致しない場合のエラー
+	ErrInvalidPassword = errors.New("invalid legacy password")
+)
+
+// User は移行元のユーザー
+type User struct {
+	Username string
+	// Attributes はユーザープールに作成する属性（email、given_name、custom:tenant_id など）
+	Attributes map[string]string
+}
+
+// Authenticator は移行元のユーザーストアでユーザーを確認します
+type Authenticator interface {
+	// Authenticate はユーザー名とパスワードを確認し、ユーザーを返します
+	Authenticate(ctx context.Context, username, password string) (*User, error)
+	// Lookup はパスワードを確認せずにユーザーを返します。パスワードを忘れた場合の移行に使います
+	Lookup(ctx context.Context, username string) (*User, error)
+}
+
+// checkPassword はbcryptのハッシュとパスワードを比較します
+func checkPassword(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrInvalidPassword
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compare password hash: %w", err)
+	}
+	return nil
+}
+
+// dummyHash は存在しないユーザーでもbcryptの比較を行い、処理時間からユーザーの有無を推測されないようにするためのハッシュ
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("legacy-dummy-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// notFound は存在しないユーザーに対してダミーの比較を行い、ErrNotFoundを返します
+func notFound(password string) error {
+	_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+	return ErrNotFound
+}
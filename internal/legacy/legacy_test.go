@@ -0,0 +1,73 @@
+package legacy
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func hashPassword(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+// JSONファイルのユーザーのパスワード確認と検索を確認
+func TestFileAuthenticator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	b, _ := json.Marshal([]FileUser{{
+		Username:     "Taro@Example.com",
+		PasswordHash: hashPassword(t, "legacy-pass"),
+		Attributes:   map[string]string{"email": "taro@example.com", "given_name": "Taro"},
+	}})
+	assert.NoError(t, os.WriteFile(path, b, 0o600))
+
+	a, err := LoadFile(path)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	user, err := a.Authenticate(ctx, "taro@example.com", "legacy-pass")
+	assert.NoError(t, err)
+	assert.Equal(t, "Taro", user.Attributes["given_name"])
+
+	_, err = a.Authenticate(ctx, "taro@example.com", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+
+	_, err = a.Authenticate(ctx, "jiro@example.com", "legacy-pass")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	user, err = a.Lookup(ctx, "TARO@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "taro@example.com", user.Attributes["email"])
+}
+
+// SQLのクエリ結果の列を属性に変換し、NULLの列を除くことを確認
+func TestSQLAuthenticator(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"email", "password_hash", "given_name", "family_name"}).
+		AddRow("taro@example.com", hashPassword(t, "legacy-pass"), "Taro", nil)
+	mock.ExpectQuery(`SELECT email, password_hash`).WithArgs("taro@example.com").WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT email, password_hash`).WithArgs("jiro@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"email", "password_hash"}))
+
+	a := &SQLAuthenticator{DB: db}
+	user, err := a.Authenticate(context.Background(), "taro@example.com", "legacy-pass")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"email": "taro@example.com", "given_name": "Taro"}, user.Attributes)
+
+	_, err = a.Authenticate(context.Background(), "jiro@example.com", "legacy-pass")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package legacy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// DefaultQuery はSQLAuthenticatorの既定のクエリ（PostgreSQLのプレースホルダー）
+const DefaultQuery = "SELECT email, password_hash, given_name, family_name FROM users WHERE lower(email) = lower($1)"
+
+// HashColumn はクエリの結果のうち、bcryptのハッシュとして扱う列の名前
+const HashColumn = "password_hash"
+
+// SQLAuthenticator はSQLのテーブルに保存したbcryptのハッシュでユーザーを確認するAuthenticator
+// クエリはユーザー名を1つのプレースホルダーで受け取り、password_hash 列とユーザープールの属性名の列を返します
+// NULLの列は属性に含めません
+type SQLAuthenticator struct {
+	DB *sql.DB
+	// Query が空の場合はDefaultQueryを使います。ドライバーに合わせてプレースホルダーを変更します
+	Query string
+}
+
+// Authenticate はハッシュとパスワードを比較します
+func (a *SQLAuthenticator) Authenticate(ctx context.Context, username, password string) (*User, error) {
+	user, hash, err := a.find(ctx, username)
+	if errors.Is(err, ErrNotFound) {
+		return nil, notFound(password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPassword(hash, password); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Lookup はユーザーを返します
+func (a *SQLAuthenticator) Lookup(ctx context.Context, username string) (*User, error) {
+	user, _, err := a.find(ctx, username)
+	return user, err
+}
+
+func (a *SQLAuthenticator) find(ctx context.Context, username string) (*User, string, error) {
+	query := a.Query
+	if query == "" {
+		query = DefaultQuery
+	}
+	rows, err := a.DB.QueryContext(ctx, query, username)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query legacy user: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, "", fmt.Errorf("failed to query legacy user: %w", err)
+		}
+		return nil, "", ErrNotFound
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, "", err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, "", fmt.Errorf("failed to scan legacy user: %w", err)
+	}
+
+	user := &User{Username: username, Attributes: map[string]string{}}
+	hash := ""
+	for i, column := range columns {
+		if !values[i].Valid {
+			continue
+		}
+		if column == HashColumn {
+			hash = values[i].String
+			continue
+		}
+		user.Attributes[column] = values[i].String
+	}
+	if hash == "" {
+		return nil, "", errors.New("legacy user has no password hash")
+	}
+	return user, hash, nil
+}
@@ -0,0 +1,248 @@
+package lockout
+
+import (
+	"cognito-lambda-handler/internal/httpx"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Action はしきい値に達したときの処理
+type Action string
+
+const (
+	// ActionBackoff はバックオフのみを行い、ロックをイベントとして通知します
+	ActionBackoff Action = "backoff"
+	// ActionDisable はAdminDisableUserでユーザーを無効化し、LockDurationの経過後に有効化します
+	// メールアドレスを知っていれば誰でも無効化できるため、明示的に指定した場合のみ使います
+	ActionDisable Action = "disable"
+	// ActionCaptcha は以降のサインインでCAPTCHAトークンを要求します
+	ActionCaptcha Action = "captcha"
+)
+
+// CaptchaHeader はCAPTCHAトークンを受け取るリクエストヘッダー
+const CaptchaHeader = "X-Captcha-Token"
+
+// Disabler はユーザーの無効化/有効化を行います（cognito.Serviceが実装します）
+type Disabler interface {
+	AdminDisableUser(ctx context.Context, email string) error
+	AdminEnableUser(ctx context.Context, email string) error
+}
+
+// Policy はバックオフとロックアウトの設定
+type Policy struct {
+	BaseDelay time.Duration // 1回目の失敗後の待機時間。以降は失敗ごとに倍増します
+	MaxDelay  time.Duration // 待機時間の上限
+	Threshold int           // Actionを実行する連続失敗回数
+	Action    Action
+	// LockDuration はロックを解除するまでの時間。0の場合はサインインに成功するまで解除しません
+	// ActionDisableの場合は必須です
+	LockDuration time.Duration
+}
+
+// Config はTrackerの依存関係
+type Config struct {
+	Store    Store
+	Policy   Policy
+	Disabler Disabler        // ActionDisableの場合に必須
+	Captcha  CaptchaVerifier // ActionCaptchaの場合に必須
+	Notifier Notifier
+}
+
+// Tracker はサインインの連続失敗を記録し、段階的なロックアウトを適用します
+type Tracker struct {
+	cfg Config
+	now func() time.Time
+}
+
+// NewTracker は新しいTrackerを作成します
+func NewTracker(cfg Config) (*Tracker, error) {
+	switch cfg.Policy.Action {
+	case ActionBackoff:
+	case ActionDisable:
+		if cfg.Disabler == nil {
+			return nil, fmt.Errorf("lockout action %q requires a disabler", cfg.Policy.Action)
+		}
+		if cfg.Policy.LockDuration <= 0 {
+			return nil, fmt.Errorf("lockout action %q requires a lock duration", cfg.Policy.Action)
+		}
+	case ActionCaptcha:
+		if cfg.Captcha == nil {
+			return nil, fmt.Errorf("lockout action %q requires a captcha verifier", cfg.Policy.Action)
+		}
+	default:
+		return nil, fmt.Errorf("unknown lockout action %q", cfg.Policy.Action)
+	}
+	if cfg.Notifier == nil {
+		cfg.Notifier = LogNotifier{}
+	}
+	return &Tracker{cfg: cfg, now: time.Now}, nil
+}
+
+// Delay は失敗回数に応じた待機時間を返します
+func (p Policy) Delay(failures int) time.Duration {
+	if failures <= 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(failures-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// Middleware は/signinで失敗回数の記録とバックオフを行い、
+// /signinと/reset-passwordの成功時に失敗回数をクリアします
+// ロックの期限が過ぎている場合は、リクエストを処理する前にロックを解除します
+func (t *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path, _ := route.GetPathTemplate()
+		if path != "/signin" && path != "/reset-password" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		email := httpx.PeekEmail(r)
+		if email == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		state, err := t.cfg.Store.Get(ctx, email)
+		if err != nil {
+			// ストアの障害でサインインを止めないよう、ロックアウト判定なしで処理を続行します
+			slog.ErrorContext(ctx, "Lockout store error", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if state.Locked && !state.LockedUntil.IsZero() && !t.now().Before(state.LockedUntil) {
+			state = t.unlock(ctx, email, httpx.ClientIP(r), state)
+		}
+
+		if path == "/signin" && !t.admit(w, r, state) {
+			return
+		}
+
+		rec := httpx.NewStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		switch {
+		case rec.Status == http.StatusOK:
+			t.reset(ctx, email, httpx.ClientIP(r), state)
+		case path == "/signin" && rec.Status == http.StatusUnauthorized:
+			t.recordFailure(ctx, email, httpx.ClientIP(r), state)
+		}
+	})
+}
+
+// admit はバックオフ中またはCAPTCHA未検証の場合にエラーレスポンスを書き込んでfalseを返します
+func (t *Tracker) admit(w http.ResponseWriter, r *http.Request, state State) bool {
+	if wait := state.LastFailure.Add(t.cfg.Policy.Delay(state.Failures)).Sub(t.now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "Too many failed sign-in attempts", http.StatusTooManyRequests)
+		return false
+	}
+
+	if state.Locked && t.cfg.Policy.Action == ActionCaptcha {
+		token := r.Header.Get(CaptchaHeader)
+		if token == "" {
+			http.Error(w, "CAPTCHA verification required", http.StatusForbidden)
+			return false
+		}
+		ok, err := t.cfg.Captcha.Verify(r.Context(), token, httpx.ClientIP(r))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error verifying captcha", "error", err)
+			http.Error(w, "Failed to verify CAPTCHA", http.StatusInternalServerError)
+			return false
+		}
+		if !ok {
+			http.Error(w, "CAPTCHA verification failed", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// recordFailure は失敗回数を加算し、しきい値に達した場合はロックアウト処理を行います
+func (t *Tracker) recordFailure(ctx context.Context, email, sourceIP string, state State) {
+	state.Failures++
+	state.LastFailure = t.now()
+
+	if !state.Locked && t.cfg.Policy.Threshold > 0 && state.Failures >= t.cfg.Policy.Threshold {
+		if t.cfg.Policy.Action == ActionDisable {
+			if err := t.cfg.Disabler.AdminDisableUser(ctx, email); err != nil {
+				slog.ErrorContext(ctx, "Error disabling locked out user", "email", email, "error", err)
+			}
+		}
+		state.Locked = true
+		if t.cfg.Policy.LockDuration > 0 {
+			state.LockedUntil = state.LastFailure.Add(t.cfg.Policy.LockDuration)
+		}
+		t.cfg.Notifier.Notify(ctx, Event{
+			Type:     EventLocked,
+			Email:    email,
+			SourceIP: sourceIP,
+			Failures: state.Failures,
+			Action:   t.cfg.Policy.Action,
+			Time:     state.LastFailure,
+		})
+	}
+
+	if err := t.cfg.Store.Put(ctx, email, state); err != nil {
+		slog.ErrorContext(ctx, "Lockout store error", "error", err)
+	}
+}
+
+// reset は失敗回数をクリアし、ロック中だった場合は解除を通知します
+func (t *Tracker) reset(ctx context.Context, email, sourceIP string, state State) {
+	if state.Failures == 0 && !state.Locked {
+		return
+	}
+
+	if state.Locked {
+		t.notifyUnlocked(ctx, email, sourceIP, state)
+	}
+
+	if err := t.cfg.Store.Delete(ctx, email); err != nil {
+		slog.ErrorContext(ctx, "Lockout store error", "error", err)
+	}
+}
+
+// unlock は期限が過ぎたロックを解除し、ActionDisableの場合はユーザーを有効化します
+// 有効化に失敗した場合は次のリクエストで再試行できるよう、状態を残します
+func (t *Tracker) unlock(ctx context.Context, email, sourceIP string, state State) State {
+	if t.cfg.Policy.Action == ActionDisable {
+		if err := t.cfg.Disabler.AdminEnableUser(ctx, email); err != nil {
+			slog.ErrorContext(ctx, "Error enabling unlocked user", "email", email, "error", err)
+			return state
+		}
+	}
+	t.notifyUnlocked(ctx, email, sourceIP, state)
+	if err := t.cfg.Store.Delete(ctx, email); err != nil {
+		slog.ErrorContext(ctx, "Lockout store error", "error", err)
+	}
+	return State{}
+}
+
+func (t *Tracker) notifyUnlocked(ctx context.Context, email, sourceIP string, state State) {
+	t.cfg.Notifier.Notify(ctx, Event{
+		Type:     EventUnlocked,
+		Email:    email,
+		SourceIP: sourceIP,
+		Failures: state.Failures,
+		Action:   t.cfg.Policy.Action,
+		Time:     t.now(),
+	})
+}
@@ -0,0 +1,125 @@
+package lockout
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDisabler struct {
+	disabled map[string]bool
+}
+
+func (d *fakeDisabler) AdminDisableUser(_ context.Context, email string) error {
+	d.disabled[email] = true
+	return nil
+}
+
+func (d *fakeDisabler) AdminEnableUser(_ context.Context, email string) error {
+	d.disabled[email] = false
+	return nil
+}
+
+type fakeNotifier struct {
+	events []Event
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, event Event) {
+	n.events = append(n.events, event)
+}
+
+// 失敗回数に応じて待機時間が倍増し、上限で頭打ちになることを確認
+func TestPolicy_Delay(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	assert.Equal(t, time.Duration(0), p.Delay(0))
+	assert.Equal(t, time.Second, p.Delay(1))
+	assert.Equal(t, 4*time.Second, p.Delay(3))
+	assert.Equal(t, 5*time.Second, p.Delay(10))
+}
+
+// しきい値で無効化され、ロックの期限が過ぎた後のリクエストで再有効化されることを確認
+func TestTracker_DisableAndUnlock(t *testing.T) {
+	disabler := &fakeDisabler{disabled: map[string]bool{}}
+	notifier := &fakeNotifier{}
+	tracker, err := NewTracker(Config{
+		Store:    NewMemoryStore(),
+		Policy:   Policy{BaseDelay: time.Second, Threshold: 2, Action: ActionDisable, LockDuration: time.Hour},
+		Disabler: disabler,
+		Notifier: notifier,
+	})
+	assert.NoError(t, err)
+	now := time.Unix(0, 0)
+	tracker.now = func() time.Time { return now }
+
+	status := http.StatusUnauthorized
+	r := mux.NewRouter()
+	r.Use(tracker.Middleware)
+	handler := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(status) }
+	r.HandleFunc("/signin", handler).Methods("POST")
+	r.HandleFunc("/reset-password", handler).Methods("POST")
+
+	send := func(path string) int {
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{"email":"user@example.com"}`))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("/signin"))
+	assert.Equal(t, http.StatusTooManyRequests, send("/signin"), "backoff should reject immediate retry")
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusUnauthorized, send("/signin"))
+	assert.True(t, disabler.disabled["user@example.com"])
+	assert.Len(t, notifier.events, 1)
+	assert.Equal(t, EventLocked, notifier.events[0].Type)
+
+	// 期限前は無効化されたまま
+	now = now.Add(30 * time.Minute)
+	assert.Equal(t, http.StatusUnauthorized, send("/signin"))
+	assert.True(t, disabler.disabled["user@example.com"])
+
+	now = now.Add(30 * time.Minute)
+	status = http.StatusOK
+	assert.Equal(t, http.StatusOK, send("/signin"))
+	assert.False(t, disabler.disabled["user@example.com"])
+	assert.Len(t, notifier.events, 2)
+	assert.Equal(t, EventUnlocked, notifier.events[1].Type)
+
+	status = http.StatusUnauthorized
+	assert.Equal(t, http.StatusUnauthorized, send("/signin"), "counter should be cleared after unlock")
+}
+
+// バックオフのみの場合はユーザーを無効化せず、ロックを通知することを確認
+func TestTracker_Backoff(t *testing.T) {
+	notifier := &fakeNotifier{}
+	tracker, err := NewTracker(Config{
+		Store:    NewMemoryStore(),
+		Policy:   Policy{BaseDelay: time.Second, Threshold: 1, Action: ActionBackoff},
+		Notifier: notifier,
+	})
+	assert.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.Use(tracker.Middleware)
+	r.HandleFunc("/signin", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusUnauthorized) }).Methods("POST")
+	req := httptest.NewRequest("POST", "/signin", strings.NewReader(`{"email":"user@example.com"}`))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(t, notifier.events, 1)
+	assert.Equal(t, ActionBackoff, notifier.events[0].Action)
+}
+
+// 無効化はDisablerとロックの期限がない場合は設定できないことを確認
+func TestNewTracker_DisableRequiresDuration(t *testing.T) {
+	disabler := &fakeDisabler{disabled: map[string]bool{}}
+	_, err := NewTracker(Config{Store: NewMemoryStore(), Policy: Policy{Action: ActionDisable}, Disabler: disabler})
+	assert.ErrorContains(t, err, "requires a lock duration")
+	_, err = NewTracker(Config{Store: NewMemoryStore(), Policy: Policy{Action: ActionDisable, LockDuration: time.Hour}})
+	assert.ErrorContains(t, err, "requires a disabler")
+}
@@ -0,0 +1,39 @@
+package lockout
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// EventType はロックアウトイベントの種類
+type EventType string
+
+const (
+	EventLocked   EventType = "account_locked"
+	EventUnlocked EventType = "account_unlocked"
+)
+
+// Event はSOC向けに通知されるロックアウト/解除イベント
+type Event struct {
+	Type     EventType `json:"type"`
+	Email    string    `json:"email"`
+	SourceIP string    `json:"source_ip,omitempty"`
+	Failures int       `json:"failures"`
+	Action   Action    `json:"action"`
+	Time     time.Time `json:"time"`
+}
+
+// Notifier はロックアウトイベントの通知先
+type Notifier interface {
+	Notify(ctx context.Context, event Event)
+}
+
+// LogNotifier はイベントを構造化ログとして出力するNotifier
+// CloudWatch Logsのサブスクリプションフィルターで msg が "lockout_event" のログを拾ってSOCへ転送します
+type LogNotifier struct{}
+
+// Notify はイベントをログに出力します
+func (LogNotifier) Notify(ctx context.Context, event Event) {
+	slog.WarnContext(ctx, "lockout_event", "event", event)
+}
@@ -0,0 +1,57 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State はユーザーごとのサインイン失敗状況
+type State struct {
+	Failures    int       // 連続失敗回数
+	LastFailure time.Time // 最後に失敗した時刻
+	Locked      bool      // しきい値に達してロックアウト処理を行ったかどうか
+	LockedUntil time.Time // ロックを解除する時刻。ゼロ値の場合はサインインに成功するまで解除しません
+}
+
+// Store は失敗状況の保存先
+// Lambdaで複数コンテナ間の状態を共有する場合はDynamoDBなどでこのインターフェースを実装します
+type Store interface {
+	Get(ctx context.Context, key string) (State, error)
+	Put(ctx context.Context, key string, state State) error
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStore はプロセス内のマップに状態を保持するStore
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]State
+}
+
+// NewMemoryStore は新しいMemoryStoreを作成します
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: map[string]State{}}
+}
+
+// Get はkeyの状態を返します。存在しない場合はゼロ値を返します
+func (s *MemoryStore) Get(_ context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[key], nil
+}
+
+// Put はkeyの状態を保存します
+func (s *MemoryStore) Put(_ context.Context, key string, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = state
+	return nil
+}
+
+// Delete はkeyの状態を削除します
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
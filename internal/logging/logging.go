@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"cognito-lambda-handler/internal/requestinfo"
+	"context"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options はロガーの設定
+type Options struct {
+	Level   slog.Leveler
+	PIIMode PIIMode
+}
+
+// New はJSON形式で出力し、機密情報とPIIを置き換えるロガーを作成します
+// リクエストのコンテキストを渡した場合はLambda/API GatewayのリクエストIDやルートを自動で付与します
+func New(w io.Writer, opts Options) *slog.Logger {
+	if opts.PIIMode == "" {
+		opts.PIIMode = PIIMask
+	}
+	r := redactor{mode: opts.PIIMode}
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       opts.Level,
+		ReplaceAttr: r.replaceAttr,
+	})
+	return slog.New(contextHandler{Handler: h})
+}
+
+// contextHandler はコンテキストのrequestinfo.Infoをログに付与するslog.Handler
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle はリクエスト情報を追加してからレコードを出力します
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info := requestinfo.FromContext(ctx); info != nil {
+		if info.LambdaRequestID != "" {
+			record.AddAttrs(slog.String("lambda_request_id", info.LambdaRequestID))
+		}
+		if info.APIRequestID != "" {
+			record.AddAttrs(slog.String("api_request_id", info.APIRequestID))
+		}
+		if info.Route != "" {
+			record.AddAttrs(slog.String("route", info.Route))
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		record.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs は属性を追加したハンドラーを返します
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup はグループを追加したハンドラーを返します
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
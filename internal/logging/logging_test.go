@@ -0,0 +1,111 @@
+package logging_test
+
+import (
+	"bytes"
+	"cognito-lambda-handler/internal/handlers"
+	"cognito-lambda-handler/internal/logging"
+	"cognito-lambda-handler/internal/requestinfo"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	secretPassword = "S3cretPassw0rd!"
+	secretCode     = "918273"
+	email          = "someone@example.com"
+)
+
+// パスワードや確認コードはどのような形でログに渡されても出力されないことを確認
+func TestLogger_NeverLogsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Options{})
+
+	logger.Info("top-level attrs",
+		"password", secretPassword,
+		"new_password", secretPassword,
+		"NewPassword", secretPassword,
+		"code", secretCode,
+		"confirmation_code", secretCode,
+		"refresh_token", secretPassword,
+		"client_secret", secretPassword,
+	)
+	logger.Info("grouped attrs", slog.Group("request", "password", secretPassword, "code", secretCode))
+	logger.With("password", secretPassword).Info("logger with attrs")
+	logger.WithGroup("req").Info("logger with group", "code", secretCode)
+
+	// ハンドラーのリクエスト型をそのまま渡した場合
+	requests := []any{
+		handlers.SignUpRequest{Email: email, Password: secretPassword, PhoneNumber: "+819012345678"},
+		handlers.SignInRequest{Email: email, Password: secretPassword},
+		handlers.ConfirmSignUpRequest{Email: email, Code: secretCode},
+		handlers.ResetPasswordRequest{Email: email, Code: secretCode, NewPassword: secretPassword},
+		&handlers.SignInRequest{Email: email, Password: secretPassword},
+	}
+	for _, req := range requests {
+		logger.Info("request struct", "request", req)
+	}
+	logger.Info("nested map", "payload", map[string]any{
+		"user":  map[string]string{"password": secretPassword},
+		"codes": []map[string]string{{"code": secretCode}},
+	})
+
+	out := buf.String()
+	assert.NotContains(t, out, secretPassword)
+	assert.NotContains(t, out, secretCode)
+	assert.NotContains(t, out, email)
+	assert.Contains(t, out, logging.Redacted)
+}
+
+// メールアドレスと電話番号がデフォルトでマスクされることを確認
+func TestLogger_MasksPII(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Options{})
+
+	logger.Info("pii", "email", email, "phone_number", "+819012345678", "cognito_error_code", "CodeMismatchException")
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "s***@example.com", entry["email"])
+	assert.Equal(t, "*********5678", entry["phone_number"])
+	assert.Equal(t, "CodeMismatchException", entry["cognito_error_code"])
+}
+
+// ハッシュモードでは同じメールアドレスが同じ値になることを確認
+func TestLogger_HashesPII(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Options{PIIMode: logging.PIIHash})
+
+	logger.Info("first", "email", email)
+	logger.Info("second", "email", strings.ToUpper(email))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var first, second map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, first["email"], second["email"])
+	assert.True(t, strings.HasPrefix(first["email"].(string), "sha256:"))
+}
+
+// コンテキストのリクエストIDがログに付与されることを確認
+func TestLogger_AddsRequestInfo(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Options{})
+
+	ctx := requestinfo.NewContext(context.Background(), &requestinfo.Info{
+		LambdaRequestID: "lambda-id",
+		APIRequestID:    "api-id",
+		Route:           "/signin",
+	})
+	logger.InfoContext(ctx, "with context")
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "lambda-id", entry["lambda_request_id"])
+	assert.Equal(t, "api-id", entry["api_request_id"])
+	assert.Equal(t, "/signin", entry["route"])
+}
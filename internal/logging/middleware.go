@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"cognito-lambda-handler/internal/httpx"
+	"cognito-lambda-handler/internal/requestinfo"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Middleware はリクエストごとにrequestinfo.Infoを用意し、処理完了時にアクセスログを出力します
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		info := requestinfo.FromContext(r.Context())
+		if info == nil {
+			info = &requestinfo.Info{}
+			r = r.WithContext(requestinfo.NewContext(r.Context(), info))
+		}
+		if route := mux.CurrentRoute(r); route != nil {
+			info.Route, _ = route.GetPathTemplate()
+		}
+		info.SourceIP = httpx.ClientIP(r)
+		info.UserAgent = r.UserAgent()
+
+		rec := httpx.NewStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		attrs := []any{
+			"method", r.Method,
+			"status", rec.Status,
+			"latency_ms", time.Since(start).Milliseconds(),
+		}
+		if code := info.CognitoErrorCode(); code != "" {
+			attrs = append(attrs, "cognito_error_code", code)
+		}
+		slog.InfoContext(r.Context(), "request completed", attrs...)
+	})
+}
@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// Redacted は機密情報の代わりに出力される値
+const Redacted = "[REDACTED]"
+
+// PIIMode はメールアドレス・電話番号の出力方法
+type PIIMode string
+
+const (
+	// PIIMask は先頭と末尾の一部だけを残してマスクします（デフォルト）
+	PIIMask PIIMode = "mask"
+	// PIIHash はSHA-256ハッシュの先頭16文字に置き換えます。ユーザー単位での突き合わせが必要な場合に使用します
+	PIIHash PIIMode = "hash"
+)
+
+// secretKeys は値を一切出力しないキー（小文字、区切り文字なしで比較）
+var secretKeys = map[string]bool{
+	"code":             true,
+	"confirmationcode": true,
+	"verificationcode": true,
+	"otp":              true,
+	"session":          true,
+	"authorization":    true,
+	"cookie":           true,
+	"setcookie":        true,
+}
+
+// secretKeyParts はキーに含まれていれば値を出力しない部分文字列
+var secretKeyParts = []string{"password", "secret", "token", "privatekey", "srpa", "srpb"}
+
+// piiKeys はマスクまたはハッシュ化して出力するキー
+var piiKeys = map[string]bool{
+	"email":       true,
+	"username":    true,
+	"phone":       true,
+	"phonenumber": true,
+}
+
+// normalizeKey はキーを小文字にし、区切り文字を取り除きます
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	return strings.NewReplacer("_", "", "-", "", ".", "", " ", "").Replace(key)
+}
+
+// isSecretKey はキーが機密情報を表すかどうかを返します
+func isSecretKey(key string) bool {
+	k := normalizeKey(key)
+	if secretKeys[k] {
+		return true
+	}
+	for _, part := range secretKeyParts {
+		if strings.Contains(k, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactor はslogのReplaceAttrとして機密情報とPIIを置き換えます
+type redactor struct {
+	mode PIIMode
+}
+
+// replaceAttr はslog.HandlerOptions.ReplaceAttrに渡す関数
+func (r redactor) replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if isSecretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	v := a.Value.Resolve()
+	if piiKeys[normalizeKey(a.Key)] {
+		return slog.String(a.Key, r.maskPII(a.Key, v.String()))
+	}
+	if v.Kind() == slog.KindAny {
+		if _, isErr := v.Any().(error); isErr {
+			return a
+		}
+		return slog.Any(a.Key, r.redactValue(v.Any()))
+	}
+	return a
+}
+
+// redactValue は構造体やマップをJSON表現に変換したうえで、ネストしたキーも含めて置き換えます
+func (r redactor) redactValue(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Redacted
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return Redacted
+	}
+	return r.redactGeneric("", generic)
+}
+
+func (r redactor) redactGeneric(key string, v any) any {
+	if key != "" && isSecretKey(key) {
+		return Redacted
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			val[k] = r.redactGeneric(k, child)
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = r.redactGeneric(key, child)
+		}
+		return val
+	case string:
+		if piiKeys[normalizeKey(key)] {
+			return r.maskPII(key, val)
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+// maskPII はPIIModeに従ってメールアドレスや電話番号を置き換えます
+func (r redactor) maskPII(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if r.mode == PIIHash {
+		sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(value))))
+		return "sha256:" + hex.EncodeToString(sum[:])[:16]
+	}
+	if at := strings.LastIndex(value, "@"); at > 0 {
+		return value[:1] + "***" + value[at:]
+	}
+	if strings.Contains(normalizeKey(key), "phone") && len(value) > 4 {
+		return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+	}
+	return value[:1] + "***"
+}
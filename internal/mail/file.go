@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSender はメールを送信せず、JSON Lines形式でファイルに追記するSender（開発用）
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// fileEntry はファイルに書き込む1行
+type fileEntry struct {
+	Time    time.Time `json:"time"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	HTML    string    `json:"html,omitempty"`
+}
+
+// Send はメールをファイルに追記します
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	b, err := json.Marshal(fileEntry{Time: time.Now().UTC(), To: msg.To, Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
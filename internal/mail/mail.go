@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"strings"
+)
+
+// Message は送信するメール
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string // 空の場合はテキストのみ送信します
+}
+
+// MaskAddress はクライアントに返すメールアドレスの一部を伏せます（例: u***@example.com）
+// メールアドレスとして解釈できない場合は空文字を返します
+func MaskAddress(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return ""
+	}
+	return local[:1] + "***@" + domain
+}
+
+// Sender はメールを送信します
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SESSender はAmazon SES（v2 API）でメールを送信するSender
+type SESSender struct {
+	Client *sesv2.Client
+	From   string
+	// ConfigurationSet は送信イベントを記録するSESの設定セット。空の場合は指定しません
+	ConfigurationSet string
+}
+
+// NewSESSender はSDKの設定からSESSenderを作成します
+func NewSESSender(cfg aws.Config, from string) *SESSender {
+	return &SESSender{Client: sesv2.NewFromConfig(cfg), From: from}
+}
+
+// Send はSendEmailでメールを送信します
+func (s *SESSender) Send(ctx context.Context, msg Message) error {
+	body := &types.Body{Text: &types.Content{Data: aws.String(msg.Text), Charset: aws.String("UTF-8")}}
+	if msg.HTML != "" {
+		body.Html = &types.Content{Data: aws.String(msg.HTML), Charset: aws.String("UTF-8")}
+	}
+
+	input := &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(s.From),
+		Destination:      &types.Destination{ToAddresses: []string{msg.To}},
+		Content: &types.EmailContent{
+			Simple: &types.Message{
+				Subject: &types.Content{Data: aws.String(msg.Subject), Charset: aws.String("UTF-8")},
+				Body:    body,
+			},
+		},
+	}
+	if s.ConfigurationSet != "" {
+		input.ConfigurationSetName = aws.String(s.ConfigurationSet)
+	}
+
+	if _, err := s.Client.SendEmail(ctx, input); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
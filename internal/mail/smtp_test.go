@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 件名をエンコードし、HTMLとテキストをmultipart/alternativeで送信することを確認
+func TestBuildMessage(t *testing.T) {
+	b, err := buildMessage("noreply@example.com", Message{
+		To:      "taro@example.com",
+		Subject: "【Example】確認コード",
+		Text:    "確認コード: 123456",
+		HTML:    "<p>確認コード: <b>123456</b></p>",
+	}, time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(b)))
+	assert.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "【Example】確認コード", subject)
+	assert.Equal(t, "taro@example.com", msg.Header.Get("To"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		body, err := io.ReadAll(p)
+		assert.NoError(t, err)
+		parts = append(parts, string(body))
+	}
+	assert.Equal(t, []string{"確認コード: 123456", "<p>確認コード: <b>123456</b></p>"}, parts)
+}
+
+// 宛先に改行を含むメールは送信しないことを確認
+func TestSMTPSender_RejectsHeaderInjection(t *testing.T) {
+	s, err := NewSMTPSender("localhost:25", "", "", "noreply@example.com")
+	assert.NoError(t, err)
+	err = s.Send(context.Background(), Message{To: "taro@example.com\r\nBcc: x@example.com", Subject: "x", Text: "x"})
+	assert.Error(t, err)
+}
+
+// 応答しないSMTPサーバーへの送信が、コンテキストの期限で中断されることを確認
+func TestSMTPSender_Timeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		// 接続を受け付けるだけで挨拶を返さない
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	s, err := NewSMTPSender(ln.Addr().String(), "", "", "noreply@example.com")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = s.Send(ctx, Message{To: "taro@example.com", Subject: "x", Text: "x"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
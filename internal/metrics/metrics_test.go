@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"bytes"
+	"cognito-lambda-handler/internal/requestinfo"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// HTTPステータスとCognitoのエラーコードから結果が決まることを確認
+func TestOutcome(t *testing.T) {
+	assert.Equal(t, OutcomeSuccess, Outcome(http.StatusOK, ""))
+	assert.Equal(t, "CodeMismatch", Outcome(http.StatusBadRequest, "CodeMismatchException"))
+	assert.Equal(t, "NotAuthorized", Outcome(http.StatusUnauthorized, "NotAuthorizedException"))
+	assert.Equal(t, OutcomeThrottled, Outcome(http.StatusTooManyRequests, "LimitExceededException"))
+	assert.Equal(t, OutcomeThrottled, Outcome(http.StatusTooManyRequests, ""))
+	assert.Equal(t, OutcomeClientError, Outcome(http.StatusBadRequest, ""))
+	assert.Equal(t, OutcomeError, Outcome(http.StatusInternalServerError, ""))
+}
+
+// ミドルウェアがルート・結果・テナントごとにリクエスト数を記録することを確認
+func TestRecorder_Middleware(t *testing.T) {
+	sink := &MemorySink{}
+	rec := NewRecorder(sink, "Test", Dimensions{"AppClient": "client"})
+	rec.AllowTenants("acme")
+
+	r := mux.NewRouter()
+	r.Use(rec.Middleware)
+	r.HandleFunc("/signin", func(w http.ResponseWriter, req *http.Request) {
+		requestinfo.SetCognitoErrorCode(req.Context(), "NotAuthorizedException")
+		w.WriteHeader(http.StatusUnauthorized)
+	}).Methods("POST")
+
+	// 許可されていないテナントはunknown、未指定はdefaultとして記録する
+	for _, tenant := range []string{"acme", "acme", "evil-1", "evil-2", ""} {
+		req := httptest.NewRequest("POST", "/signin", nil)
+		req = req.WithContext(requestinfo.NewContext(req.Context(), &requestinfo.Info{}))
+		req.Header.Set(TenantHeader, tenant)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for tenant, want := range map[string]float64{"acme": 2, UnknownTenant: 2, DefaultTenant: 1, "evil-1": 0} {
+		assert.Equal(t, want, sink.Sum("Requests", map[string]string{
+			"Route":     "/signin",
+			"Outcome":   "NotAuthorized",
+			"Tenant":    tenant,
+			"AppClient": "client",
+		}), tenant)
+	}
+}
+
+// SDKの呼び出しがオペレーション名とテナントごとに記録されることを確認
+func TestRecorder_CognitoLatencyMiddleware(t *testing.T) {
+	sink := &MemorySink{}
+	rec := NewRecorder(sink, "Test", nil)
+
+	client := cognitoidentityprovider.New(cognitoidentityprovider.Options{
+		Region:      "us-east-1",
+		Credentials: aws.AnonymousCredentials{},
+		HTTPClient: smithyhttp.ClientDoFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.1"}},
+				Body:       io.NopCloser(strings.NewReader("{}")),
+			}, nil
+		}),
+		APIOptions: []func(*middleware.Stack) error{rec.CognitoLatencyMiddleware},
+	})
+
+	ctx := requestinfo.NewContext(context.Background(), &requestinfo.Info{Tenant: "acme"})
+	_, err := client.InitiateAuth(ctx, &cognitoidentityprovider.InitiateAuthInput{
+		AuthFlow: types.AuthFlowTypeUserPasswordAuth,
+		ClientId: aws.String("client"),
+	})
+	assert.NoError(t, err)
+	rec.Flush()
+
+	records := sink.Records()
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "InitiateAuth", records[0].Dimensions["Operation"])
+		assert.Equal(t, "acme", records[0].Dimensions["Tenant"])
+		assert.Equal(t, "CognitoLatency", records[0].Metrics[0].Name)
+	}
+}
+
+// 所要時間がヒストグラムとしてEMF形式で出力されることを確認
+func TestEMFSink_Histogram(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(NewEMFSink(&buf), "Test", Dimensions{"AppClient": "client"})
+	rec.now = func() time.Time { return time.UnixMilli(1700000000000) }
+
+	dims := Dimensions{"Operation": "InitiateAuth", "Tenant": "default"}
+	rec.ObserveDuration("CognitoLatency", dims, 120*time.Millisecond)
+	rec.ObserveDuration("CognitoLatency", dims, 120*time.Millisecond)
+	rec.ObserveDuration("CognitoLatency", dims, 300*time.Millisecond)
+	rec.Flush()
+
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "InitiateAuth", doc["Operation"])
+	assert.Equal(t, "client", doc["AppClient"])
+	assert.Equal(t, map[string]any{
+		"Values": []any{120.0, 300.0},
+		"Counts": []any{2.0, 1.0},
+	}, doc["CognitoLatency"])
+
+	aws := doc["_aws"].(map[string]any)
+	assert.Equal(t, 1700000000000.0, aws["Timestamp"])
+	directive := aws["CloudWatchMetrics"].([]any)[0].(map[string]any)
+	assert.Equal(t, "Test", directive["Namespace"])
+	assert.Equal(t, []any{[]any{"AppClient", "Operation", "Tenant"}}, directive["Dimensions"])
+}
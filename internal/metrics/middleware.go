@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"cognito-lambda-handler/internal/httpx"
+	"cognito-lambda-handler/internal/requestinfo"
+	"context"
+	"net/http"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// TenantHeader はテナントIDを受け取るリクエストヘッダー
+	TenantHeader = "X-Tenant-Id"
+	// DefaultTenant はテナントが指定されなかった場合の値
+	DefaultTenant = "default"
+	// UnknownTenant は許可されていないテナントが指定された場合の値
+	UnknownTenant = "unknown"
+)
+
+// AllowTenants はディメンションとして記録するテナントを設定します
+// ヘッダーはクライアントが自由に指定できるため、許可されていない値はUnknownTenantにまとめ、ディメンションの数が増え続けないようにします
+func (r *Recorder) AllowTenants(tenants ...string) {
+	r.tenants = make(map[string]bool, len(tenants))
+	for _, t := range tenants {
+		r.tenants[t] = true
+	}
+}
+
+// tenant はリクエストヘッダーからメトリクスに記録するテナントを返します
+func (r *Recorder) tenant(req *http.Request) string {
+	tenant := req.Header.Get(TenantHeader)
+	switch {
+	case tenant == "":
+		return DefaultTenant
+	case r.tenants[tenant]:
+		return tenant
+	default:
+		return UnknownTenant
+	}
+}
+
+// Middleware はルートと結果ごとのリクエスト数を記録し、リクエストの最後にメトリクスを出力します
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		info := requestinfo.FromContext(req.Context())
+		if info == nil {
+			info = &requestinfo.Info{}
+			req = req.WithContext(requestinfo.NewContext(req.Context(), info))
+		}
+		info.Tenant = r.tenant(req)
+
+		route := req.URL.Path
+		if current := mux.CurrentRoute(req); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		rec := httpx.NewStatusRecorder(w)
+		next.ServeHTTP(rec, req)
+
+		r.Count("Requests", Dimensions{
+			"Route":   route,
+			"Outcome": Outcome(rec.Status, info.CognitoErrorCode()),
+			"Tenant":  info.Tenant,
+		}, 1)
+		r.Flush()
+	})
+}
+
+// CognitoLatencyMiddleware はAWS SDKのミドルウェアスタックにAPI呼び出しの所要時間を記録するステップを追加します
+// ユーザープールのクライアントを作成するときにconfig.WithAPIOptionsに渡して使用します
+// オペレーション名はInitializeステップでSDKが設定するため、Finalizeステップ（リトライを含む）で計測します
+func (r *Recorder) CognitoLatencyMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CognitoLatencyMetrics",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			out, md, err := next.HandleFinalize(ctx, in)
+
+			tenant := DefaultTenant
+			if info := requestinfo.FromContext(ctx); info != nil && info.Tenant != "" {
+				tenant = info.Tenant
+			}
+			r.ObserveDuration("CognitoLatency", Dimensions{
+				"Operation": awsmiddleware.GetOperationName(ctx),
+				"Tenant":    tenant,
+			}, time.Since(start))
+			return out, md, err
+		}), middleware.Before)
+}
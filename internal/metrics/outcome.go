@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+)
+
+// 代表的な結果の値
+const (
+	OutcomeSuccess     = "success"
+	OutcomeThrottled   = "throttled"
+	OutcomeClientError = "client_error"
+	OutcomeError       = "error"
+)
+
+// Outcome はHTTPステータスとCognitoのエラーコードから結果のディメンション値を決定します
+// Cognitoのエラーは "CodeMismatch" や "NotAuthorized" のように "Exception" を除いた名前になります
+func Outcome(status int, cognitoErrorCode string) string {
+	switch cognitoErrorCode {
+	case "":
+	case "LimitExceededException", "TooManyRequestsException", "TooManyFailedAttemptsException":
+		return OutcomeThrottled
+	default:
+		return strings.TrimSuffix(cognitoErrorCode, "Exception")
+	}
+
+	switch {
+	case status == http.StatusTooManyRequests:
+		return OutcomeThrottled
+	case status < http.StatusBadRequest:
+		return OutcomeSuccess
+	case status < http.StatusInternalServerError:
+		return OutcomeClientError
+	default:
+		return OutcomeError
+	}
+}
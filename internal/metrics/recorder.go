@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dimensions はメトリクスのディメンション
+type Dimensions map[string]string
+
+type series struct {
+	name string
+	unit Unit
+	dims string // ソート済みのディメンションを連結したキー
+}
+
+// Recorder はカウンターとヒストグラムをメモリ上で集計し、Flush時にまとめてSinkへ出力します
+type Recorder struct {
+	mu        sync.Mutex
+	sink      Sink
+	namespace string
+	base      Dimensions
+	dims      map[string]Dimensions
+	values    map[series]map[float64]float64
+	tenants   map[string]bool
+	now       func() time.Time
+}
+
+// NewRecorder は新しいRecorderを作成します
+// baseは全てのメトリクスに付与されるディメンション（例: AppClient）
+func NewRecorder(sink Sink, namespace string, base Dimensions) *Recorder {
+	return &Recorder{
+		sink:      sink,
+		namespace: namespace,
+		base:      base,
+		dims:      map[string]Dimensions{},
+		values:    map[series]map[float64]float64{},
+		now:       time.Now,
+	}
+}
+
+// Count はカウンターをn加算します
+func (r *Recorder) Count(name string, dims Dimensions, n float64) {
+	r.add(name, UnitCount, dims, 1, n)
+}
+
+// ObserveDuration は所要時間をミリ秒単位のヒストグラムに記録します
+// 値は1ミリ秒単位に丸めて集計されます
+func (r *Recorder) ObserveDuration(name string, dims Dimensions, d time.Duration) {
+	ms := math.Round(float64(d) / float64(time.Millisecond))
+	r.add(name, UnitMilliseconds, dims, ms, 1)
+}
+
+func (r *Recorder) add(name string, unit Unit, dims Dimensions, value, count float64) {
+	merged := Dimensions{}
+	for k, v := range r.base {
+		merged[k] = v
+	}
+	for k, v := range dims {
+		merged[k] = v
+	}
+	key := dimsKey(merged)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := series{name: name, unit: unit, dims: key}
+	if r.values[s] == nil {
+		r.values[s] = map[float64]float64{}
+	}
+	if unit == UnitCount {
+		// カウンターは値を合計して1つの値として出力します
+		r.values[s][0] += value * count
+	} else {
+		r.values[s][value] += count
+	}
+	r.dims[key] = merged
+}
+
+// Flush は集計中のメトリクスをSinkに出力してリセットします
+func (r *Recorder) Flush() {
+	r.mu.Lock()
+	values := r.values
+	dims := r.dims
+	r.values = map[series]map[float64]float64{}
+	r.dims = map[string]Dimensions{}
+	r.mu.Unlock()
+
+	byDims := map[string][]Metric{}
+	for s, hist := range values {
+		m := Metric{Name: s.name, Unit: s.unit}
+		buckets := make([]float64, 0, len(hist))
+		for v := range hist {
+			buckets = append(buckets, v)
+		}
+		sort.Float64s(buckets)
+		for _, v := range buckets {
+			if s.unit == UnitCount {
+				m.Values = append(m.Values, hist[v])
+				m.Counts = append(m.Counts, 1)
+			} else {
+				m.Values = append(m.Values, v)
+				m.Counts = append(m.Counts, hist[v])
+			}
+		}
+		byDims[s.dims] = append(byDims[s.dims], m)
+	}
+
+	now := r.now()
+	for key, metrics := range byDims {
+		sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+		record := Record{Timestamp: now, Namespace: r.namespace, Dimensions: dims[key], Metrics: metrics}
+		if err := r.sink.Emit(record); err != nil {
+			slog.Error("Failed to emit metrics", "error", err)
+		}
+	}
+}
+
+// dimsKey はディメンションをキーでソートして連結します
+func dimsKey(d Dimensions) string {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(d[k])
+		b.WriteByte(';')
+	}
+	return b.String()
+}
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Unit はCloudWatchメトリクスの単位
+type Unit string
+
+const (
+	UnitCount        Unit = "Count"
+	UnitMilliseconds Unit = "Milliseconds"
+)
+
+// Metric は1つのメトリクスの値
+// Values/Countsはヒストグラム形式で、Values[i]がCounts[i]回観測されたことを表します
+type Metric struct {
+	Name   string
+	Unit   Unit
+	Values []float64
+	Counts []float64
+}
+
+// Record は同じディメンションを持つメトリクスの集合
+type Record struct {
+	Timestamp  time.Time
+	Namespace  string
+	Dimensions map[string]string
+	Metrics    []Metric
+}
+
+// Sink はメトリクスの出力先
+type Sink interface {
+	Emit(record Record) error
+}
+
+// EMFSink はCloudWatch Embedded Metric Format(EMF)のJSONを1行ずつ書き込むSink
+// Lambdaでは標準出力に書き込むだけでCloudWatch Logsがメトリクスとして取り込みます
+type EMFSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewEMFSink はwに書き込むEMFSinkを作成します
+func NewEMFSink(w io.Writer) *EMFSink {
+	return &EMFSink{w: w}
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+type emfDirective struct {
+	Namespace  string           `json:"Namespace"`
+	Dimensions [][]string       `json:"Dimensions"`
+	Metrics    []emfMetricEntry `json:"Metrics"`
+}
+
+type emfMetricEntry struct {
+	Name string `json:"Name"`
+	Unit Unit   `json:"Unit"`
+}
+
+type emfValues struct {
+	Values []float64 `json:"Values"`
+	Counts []float64 `json:"Counts"`
+}
+
+// Emit はRecordをEMF形式で書き込みます
+func (s *EMFSink) Emit(record Record) error {
+	dimKeys := make([]string, 0, len(record.Dimensions))
+	for k := range record.Dimensions {
+		dimKeys = append(dimKeys, k)
+	}
+	sort.Strings(dimKeys)
+
+	directive := emfDirective{Namespace: record.Namespace, Dimensions: [][]string{dimKeys}}
+	doc := map[string]any{}
+	for k, v := range record.Dimensions {
+		doc[k] = v
+	}
+	for _, m := range record.Metrics {
+		directive.Metrics = append(directive.Metrics, emfMetricEntry{Name: m.Name, Unit: m.Unit})
+		if len(m.Values) == 1 && m.Counts[0] == 1 {
+			doc[m.Name] = m.Values[0]
+		} else {
+			doc[m.Name] = emfValues{Values: m.Values, Counts: m.Counts}
+		}
+	}
+	doc["_aws"] = emfMetadata{
+		Timestamp:         record.Timestamp.UnixMilli(),
+		CloudWatchMetrics: []emfDirective{directive},
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode EMF record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write EMF record: %w", err)
+	}
+	return nil
+}
+
+// MemorySink は出力されたRecordをメモリに保持するSink（テスト用）
+type MemorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// Emit はRecordを保持します
+func (s *MemorySink) Emit(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Records は保持しているRecordのコピーを返します
+func (s *MemorySink) Records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Record(nil), s.records...)
+}
+
+// Sum はディメンションがdimsを全て含むRecordについて、指定したメトリクスの合計値を返します
+func (s *MemorySink) Sum(name string, dims map[string]string) float64 {
+	var total float64
+	for _, r := range s.Records() {
+		if !matches(r.Dimensions, dims) {
+			continue
+		}
+		for _, m := range r.Metrics {
+			if m.Name != name {
+				continue
+			}
+			for i, v := range m.Values {
+				total += v * m.Counts[i]
+			}
+		}
+	}
+	return total
+}
+
+func matches(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,246 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// StateCookie は認可リクエストの状態を保持するCookieの名前
+const StateCookie = "oauth_state"
+
+// ErrInvalidState はstateが一致しない、またはCookieが改ざん・失効している場合のエラー
+var ErrInvalidState = errors.New("invalid oauth state")
+
+// Config はホストUIを利用した認可コードフローの設定
+type Config struct {
+	// AuthorizeURL はユーザープールドメインの /oauth2/authorize
+	AuthorizeURL string
+	// TokenURL はユーザープールドメインの /oauth2/token。ローカルではスタブのURLに差し替えられます
+	TokenURL    string
+	ClientID    string
+	RedirectURI string
+	Scopes      []string
+	// StateKey は状態Cookieの改ざん検出に使用するHMACの鍵
+	StateKey []byte
+	// CookiePath は状態Cookieを送信するパス。コールバックのパスを含めます
+	CookiePath string
+	// StateTTL は認可リクエストからコールバックまでの有効期間
+	StateTTL time.Duration
+	// PostLoginRedirect が設定されている場合、コールバック成功後にこのURLへリダイレクトします
+	PostLoginRedirect string
+}
+
+// Pending はコールバックで照合する認可リクエストの状態
+type Pending struct {
+	State        string `json:"s"`
+	Nonce        string `json:"n"`
+	CodeVerifier string `json:"v"`
+	Expires      int64  `json:"e"`
+}
+
+// Flow は認可URLの作成とコールバックの検証を行います
+type Flow struct {
+	cfg Config
+	now func() time.Time
+}
+
+// NewFlow は設定を検証してFlowを作成します
+func NewFlow(cfg Config) (*Flow, error) {
+	if cfg.AuthorizeURL == "" || cfg.TokenURL == "" {
+		return nil, fmt.Errorf("authorize and token URLs are required")
+	}
+	if cfg.ClientID == "" || cfg.RedirectURI == "" {
+		return nil, fmt.Errorf("client ID and redirect URI are required")
+	}
+	if len(cfg.StateKey) == 0 {
+		return nil, fmt.Errorf("state key is required")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.CookiePath == "" {
+		cfg.CookiePath = "/oauth"
+	}
+	if cfg.StateTTL <= 0 {
+		cfg.StateTTL = 10 * time.Minute
+	}
+	return &Flow{cfg: cfg, now: time.Now}, nil
+}
+
+// TokenURL はトークンエンドポイントのURLを返します
+func (f *Flow) TokenURL() string { return f.cfg.TokenURL }
+
+// RedirectURI はコールバックのURLを返します
+func (f *Flow) RedirectURI() string { return f.cfg.RedirectURI }
+
+// ClientID はアプリクライアントIDを返します
+func (f *Flow) ClientID() string { return f.cfg.ClientID }
+
+// PostLoginRedirect はコールバック成功後のリダイレクト先を返します
+func (f *Flow) PostLoginRedirect() string { return f.cfg.PostLoginRedirect }
+
+// Start はstate・nonce・PKCEのcode_verifierを生成して状態Cookieに保存し、ホストUIの認可URLを返します
+// identityProviderに "Google" などを指定すると、ホストUIを経由せずに外部IdPへ直接遷移します
+func (f *Flow) Start(w http.ResponseWriter, identityProvider string) (string, error) {
+	p := Pending{Expires: f.now().Add(f.cfg.StateTTL).Unix()}
+	var err error
+	if p.State, err = randomString(); err != nil {
+		return "", err
+	}
+	if p.Nonce, err = randomString(); err != nil {
+		return "", err
+	}
+	if p.CodeVerifier, err = randomString(); err != nil {
+		return "", err
+	}
+
+	value, err := f.seal(p)
+	if err != nil {
+		return "", err
+	}
+	// コールバックは外部サイトからのトップレベル遷移で届くため、SameSite=Laxにする
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookie,
+		Value:    value,
+		Path:     f.cfg.CookiePath,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(f.cfg.StateTTL.Seconds()),
+	})
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {f.cfg.ClientID},
+		"redirect_uri":          {f.cfg.RedirectURI},
+		"scope":                 {strings.Join(f.cfg.Scopes, " ")},
+		"state":                 {p.State},
+		"nonce":                 {p.Nonce},
+		"code_challenge":        {CodeChallenge(p.CodeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if identityProvider != "" {
+		q.Set("identity_provider", identityProvider)
+	}
+	return f.cfg.AuthorizeURL + "?" + q.Encode(), nil
+}
+
+// Verify はコールバックのstateを状態Cookieと照合し、認可リクエストの状態を返します
+func (f *Flow) Verify(r *http.Request) (Pending, error) {
+	c, err := r.Cookie(StateCookie)
+	if err != nil {
+		return Pending{}, ErrInvalidState
+	}
+	p, err := f.open(c.Value)
+	if err != nil {
+		return Pending{}, err
+	}
+	state := r.URL.Query().Get("state")
+	if state == "" || !hmac.Equal([]byte(state), []byte(p.State)) {
+		return Pending{}, ErrInvalidState
+	}
+	return p, nil
+}
+
+// Clear は状態Cookieを削除します。stateは1回限りの使用とします
+func (f *Flow) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookie,
+		Path:     f.cfg.CookiePath,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	})
+}
+
+// CheckIDToken はトークンエンドポイントから受け取ったIDトークンのnonce・aud・expを確認します
+// TLSで直接受け取ったトークンのため、署名の検証は行いません（OpenID Connect Core 3.1.3.7）
+func (f *Flow) CheckIDToken(idToken, nonce string) error {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed id token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("malformed id token payload: %w", err)
+	}
+	var claims struct {
+		Nonce    string `json:"nonce"`
+		Audience string `json:"aud"`
+		Expires  int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("malformed id token payload: %w", err)
+	}
+	if claims.Nonce == "" || !hmac.Equal([]byte(claims.Nonce), []byte(nonce)) {
+		return fmt.Errorf("id token nonce mismatch")
+	}
+	if claims.Audience != f.cfg.ClientID {
+		return fmt.Errorf("id token audience mismatch: %s", claims.Audience)
+	}
+	if f.now().Unix() >= claims.Expires {
+		return fmt.Errorf("id token expired")
+	}
+	return nil
+}
+
+// CodeChallenge はcode_verifierからS256のcode_challengeを計算します
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// seal は状態をJSONにしてHMACで署名します
+func (f *Flow) seal(p Pending) (string, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + f.sign(payload), nil
+}
+
+// open は署名と有効期限を確認して状態を取り出します
+func (f *Flow) open(value string) (Pending, error) {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(f.sign(payload))) {
+		return Pending{}, ErrInvalidState
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Pending{}, ErrInvalidState
+	}
+	var p Pending
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Pending{}, ErrInvalidState
+	}
+	if f.now().Unix() >= p.Expires {
+		return Pending{}, ErrInvalidState
+	}
+	return p, nil
+}
+
+func (f *Flow) sign(payload string) string {
+	mac := hmac.New(sha256.New, f.cfg.StateKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// randomString は32バイトの乱数をbase64urlで返します。PKCEのcode_verifierの文字種を満たします
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
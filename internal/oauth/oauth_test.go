@@ -0,0 +1,93 @@
+package oauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFlow(t *testing.T) *Flow {
+	f, err := NewFlow(Config{
+		AuthorizeURL: "https://auth.example.com/oauth2/authorize",
+		TokenURL:     "http://localhost:9000/oauth2/token",
+		ClientID:     "client-id",
+		RedirectURI:  "https://api.example.com/oauth/callback",
+		StateKey:     []byte("secret"),
+	})
+	assert.NoError(t, err)
+	f.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return f
+}
+
+func idToken(claims map[string]any) string {
+	payload, _ := json.Marshal(claims)
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+// 認可URLにstate・nonce・PKCEが含まれ、コールバックで状態Cookieと照合できることを確認
+func TestFlow_StartAndVerify(t *testing.T) {
+	f := newTestFlow(t)
+	rec := httptest.NewRecorder()
+	authURL, err := f.Start(rec, "Google")
+	assert.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "auth.example.com", u.Host)
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, "Google", q.Get("identity_provider"))
+	assert.NotEmpty(t, q.Get("nonce"))
+
+	cookie := rec.Result().Cookies()[0]
+	assert.Equal(t, StateCookie, cookie.Name)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	assert.True(t, cookie.HttpOnly)
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/callback?code=abc&state="+url.QueryEscape(q.Get("state")), nil)
+	req.AddCookie(cookie)
+	p, err := f.Verify(req)
+	assert.NoError(t, err)
+	assert.Equal(t, q.Get("nonce"), p.Nonce)
+	assert.Equal(t, q.Get("code_challenge"), CodeChallenge(p.CodeVerifier))
+
+	// stateの不一致
+	req = httptest.NewRequest(http.MethodGet, "/oauth/callback?code=abc&state=other", nil)
+	req.AddCookie(cookie)
+	_, err = f.Verify(req)
+	assert.ErrorIs(t, err, ErrInvalidState)
+
+	// 改ざんされたCookie
+	req = httptest.NewRequest(http.MethodGet, "/oauth/callback?code=abc&state="+url.QueryEscape(q.Get("state")), nil)
+	req.AddCookie(&http.Cookie{Name: StateCookie, Value: cookie.Value + "x"})
+	_, err = f.Verify(req)
+	assert.ErrorIs(t, err, ErrInvalidState)
+
+	// 有効期限切れ
+	f.now = func() time.Time { return time.Unix(1700000000, 0).Add(time.Hour) }
+	req = httptest.NewRequest(http.MethodGet, "/oauth/callback?code=abc&state="+url.QueryEscape(q.Get("state")), nil)
+	req.AddCookie(cookie)
+	_, err = f.Verify(req)
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
+
+// IDトークンのnonce・aud・expの確認
+func TestFlow_CheckIDToken(t *testing.T) {
+	f := newTestFlow(t)
+	exp := time.Unix(1700000000, 0).Add(time.Hour).Unix()
+
+	assert.NoError(t, f.CheckIDToken(idToken(map[string]any{"nonce": "n1", "aud": "client-id", "exp": exp}), "n1"))
+	assert.Error(t, f.CheckIDToken(idToken(map[string]any{"nonce": "n2", "aud": "client-id", "exp": exp}), "n1"))
+	assert.Error(t, f.CheckIDToken(idToken(map[string]any{"aud": "client-id", "exp": exp}), "n1"))
+	assert.Error(t, f.CheckIDToken(idToken(map[string]any{"nonce": "n1", "aud": "other", "exp": exp}), "n1"))
+	assert.Error(t, f.CheckIDToken(idToken(map[string]any{"nonce": "n1", "aud": "client-id", "exp": 1600000000}), "n1"))
+	assert.Error(t, f.CheckIDToken("not-a-jwt", "n1"))
+}
@@ -0,0 +1,209 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Document はOpenAPI 3.1のドキュメント
+type Document struct {
+	OpenAPI string               `json:"openapi"`
+	Info    Info                 `json:"info"`
+	Paths   map[string]*PathItem `json:"paths"`
+}
+
+// Info はAPIの概要
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem は1つのパスに対するメソッドごとの操作
+type PathItem map[string]*operationObject
+
+type operationObject struct {
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []parameterObject    `json:"parameters,omitempty"`
+	RequestBody *requestBodyObject   `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBodyObject struct {
+	Required bool                  `json:"required"`
+	Content  map[string]mediaValue `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]mediaValue `json:"content,omitempty"`
+}
+
+type mediaValue struct {
+	Schema  *Schema `json:"schema"`
+	Example any     `json:"example,omitempty"`
+}
+
+// Operation はルートに対応するドキュメント上の説明
+type Operation struct {
+	Summary        string
+	Description    string
+	Tags           []string
+	Query          []QueryParam
+	Request        any // リクエストボディの型（ゼロ値）。ボディがない場合はnil
+	Response       any // 成功時のレスポンスボディの型（ゼロ値）
+	ResponseStatus int // 成功時のステータス。0の場合は200
+	Errors         []ErrorResponse
+}
+
+// QueryParam は文字列のクエリパラメーター
+type QueryParam struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// ErrorResponse はエラーレスポンス
+// 通常はhttp.Errorで返されるテキストで、BodyにはJSONで返す場合の型（ゼロ値）を指定します
+type ErrorResponse struct {
+	Status  int
+	Message string
+	Body    any
+}
+
+// Generate はルーターに登録されたルートとopsからドキュメントを生成します
+// opsのキーは "METHOD /path/template" で、説明のないルートや存在しないルートの説明がある場合はエラーを返します
+func Generate(router *mux.Router, info Info, ops map[string]Operation) (*Document, error) {
+	doc := &Document{OpenAPI: "3.1.0", Info: info, Paths: map[string]*PathItem{}}
+	seen := map[string]bool{}
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				// CORSのプリフライトはドキュメントに含めない
+				continue
+			}
+			key := method + " " + path
+			op, ok := ops[key]
+			if !ok {
+				return fmt.Errorf("route %s has no OpenAPI operation", key)
+			}
+			seen[key] = true
+
+			item := doc.Paths[path]
+			if item == nil {
+				item = &PathItem{}
+				doc.Paths[path] = item
+			}
+			(*item)[strings.ToLower(method)] = op.build(method, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for key := range ops {
+		if !seen[key] {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		return nil, fmt.Errorf("OpenAPI operations without routes: %s", strings.Join(stale, ", "))
+	}
+	return doc, nil
+}
+
+// build はOperationをOpenAPIの操作オブジェクトに変換します
+func (op Operation) build(method, path string) *operationObject {
+	o := &operationObject{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		OperationID: operationID(method, path),
+		Responses:   map[string]*response{},
+	}
+
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			name, _, _ := strings.Cut(strings.Trim(seg, "{}"), ":")
+			o.Parameters = append(o.Parameters, parameterObject{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	for _, q := range op.Query {
+		o.Parameters = append(o.Parameters, parameterObject{Name: q.Name, In: "query", Description: q.Description, Required: q.Required, Schema: &Schema{Type: "string"}})
+	}
+
+	if op.Request != nil {
+		o.RequestBody = &requestBodyObject{
+			Required: true,
+			Content:  map[string]mediaValue{"application/json": {Schema: SchemaOf(op.Request)}},
+		}
+	}
+
+	status := op.ResponseStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		success.Content = map[string]mediaValue{"application/json": {Schema: SchemaOf(op.Response)}}
+	}
+	o.Responses[strconv.Itoa(status)] = success
+
+	for _, e := range op.Errors {
+		code := strconv.Itoa(e.Status)
+		mediaType, media := "text/plain", mediaValue{Schema: &Schema{Type: "string"}, Example: e.Message}
+		if e.Body != nil {
+			mediaType, media = "application/json", mediaValue{Schema: SchemaOf(e.Body)}
+		}
+		if existing, ok := o.Responses[code]; ok {
+			// 同じステータスで複数のメッセージがある場合は説明に併記し、異なる形式のボディも追加します
+			existing.Description += " / " + e.Message
+			if _, ok := existing.Content[mediaType]; !ok {
+				existing.Content[mediaType] = media
+			}
+			continue
+		}
+		o.Responses[code] = &response{Description: e.Message, Content: map[string]mediaValue{mediaType: media}}
+	}
+	return o
+}
+
+// operationID は "POST /forgot-password" を "postForgotPassword" のような識別子に変換します
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '{' || r == '}' || r == '.' || r == '_' }) {
+		name, _, _ := strings.Cut(part, ":")
+		b.WriteString(strings.ToUpper(name[:1]) + name[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval は満タンになったバケットを掃除する間隔
+const sweepInterval = time.Minute
+
+// MemoryStore はプロセス内のマップにバケットを保持するStore
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	bucket Bucket
+	limit  Limit
+}
+
+// NewMemoryStore は新しいMemoryStoreを作成します
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]memoryBucket{}}
+}
+
+// Take はkeyのバケットから1トークンを消費します
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, decision := limit.Take(s.buckets[key].bucket, now)
+	s.buckets[key] = memoryBucket{bucket: b, limit: limit}
+	return decision, nil
+}
+
+// sweep はトークンが満タンまで回復したバケットを削除し、メモリ使用量を抑えます
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, mb := range s.buckets {
+		if mb.limit.refill(mb.bucket, now).Tokens >= float64(mb.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
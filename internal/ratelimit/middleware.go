@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"cognito-lambda-handler/internal/httpx"
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Rule はルートごとのレート制限設定
+// ゼロ値のLimitはそのキーでの制限を無効にします
+type Rule struct {
+	ByIP    Limit
+	ByEmail Limit
+}
+
+// Limiter はルートごとのRuleに従ってリクエストを制限します
+type Limiter struct {
+	store Store
+	rules map[string]Rule
+	now   func() time.Time
+}
+
+// NewLimiter はパステンプレート（例: "/signin"）をキーとしたRuleでLimiterを作成します
+func NewLimiter(store Store, rules map[string]Rule) *Limiter {
+	return &Limiter{store: store, rules: rules, now: time.Now}
+}
+
+// Middleware はmuxルーターに登録するレート制限ミドルウェア
+// 制限を超えた場合は429とRetry-Afterヘッダーを返します
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rule, ok := l.rules[path]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := l.now()
+		if rule.ByIP.Enabled() {
+			if ip := httpx.ClientIP(r); ip != "" {
+				if !l.allow(r.Context(), w, "ip:"+path+":"+ip, rule.ByIP, now) {
+					return
+				}
+			}
+		}
+		if rule.ByEmail.Enabled() {
+			if email := httpx.PeekEmail(r); email != "" {
+				if !l.allow(r.Context(), w, "email:"+path+":"+email, rule.ByEmail, now) {
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow はトークンを消費し、制限超過の場合は429レスポンスを書き込んでfalseを返します
+// ストアのエラー時は認証処理を止めないよう通過させます
+func (l *Limiter) allow(ctx context.Context, w http.ResponseWriter, key string, limit Limit, now time.Time) bool {
+	decision, err := l.store.Take(ctx, key, limit, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Rate limit store error", "error", err)
+		return true
+	}
+	if decision.Allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	http.Error(w, "Too many requests", http.StatusTooManyRequests)
+	return false
+}
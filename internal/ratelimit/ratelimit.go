@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit はトークンバケットの設定
+type Limit struct {
+	Rate  float64 // 1秒あたりに補充されるトークン数
+	Burst int     // バケットの最大容量
+}
+
+// Per は期間dあたりn回のリクエストを許可するLimitを返します
+func Per(n int, d time.Duration) Limit {
+	return Limit{Rate: float64(n) / d.Seconds(), Burst: n}
+}
+
+// Enabled はLimitが有効かどうかを返します
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Bucket はキーごとに保存されるトークンバケットの状態
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Decision はトークン取得の結果
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// Take は経過時間分のトークンを補充したうえで1トークンを消費し、更新後のバケットと結果を返します
+// Storeの実装はこの関数を使って状態を計算し、永続化だけを担当します
+func (l Limit) Take(b Bucket, now time.Time) (Bucket, Decision) {
+	b = l.refill(b, now)
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return b, Decision{Allowed: true}
+	}
+
+	wait := time.Duration((1 - b.Tokens) / l.Rate * float64(time.Second))
+	return b, Decision{Allowed: false, RetryAfter: wait}
+}
+
+// refill は経過時間分のトークンを補充したバケットを返します
+func (l Limit) refill(b Bucket, now time.Time) Bucket {
+	if b.Updated.IsZero() {
+		return Bucket{Tokens: float64(l.Burst), Updated: now}
+	}
+
+	elapsed := now.Sub(b.Updated).Seconds()
+	if elapsed > 0 {
+		b.Tokens = math.Min(float64(l.Burst), b.Tokens+elapsed*l.Rate)
+		b.Updated = now
+	}
+	return b
+}
+
+// Store はトークンバケットの保存先
+// ローカルではMemoryStoreを使用し、Lambdaでは複数コンテナ間で状態を共有するため
+// DynamoDBの条件付き書き込みなどでこのインターフェースを実装します
+type Store interface {
+	// Take はkeyのバケットからアトミックに1トークンを消費します
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Decision, error)
+}
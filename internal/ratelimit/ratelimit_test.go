@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// バースト分を使い切ると拒否され、補充後に再び許可されることを確認
+func TestLimit_Take(t *testing.T) {
+	limit := Per(2, time.Minute)
+	now := time.Unix(0, 0)
+
+	b, d := limit.Take(Bucket{}, now)
+	assert.True(t, d.Allowed)
+	b, d = limit.Take(b, now)
+	assert.True(t, d.Allowed)
+	b, d = limit.Take(b, now)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 30*time.Second, d.RetryAfter)
+
+	_, d = limit.Take(b, now.Add(30*time.Second))
+	assert.True(t, d.Allowed)
+}
+
+// 同じメールアドレスはIPが異なっても制限され、429とRetry-Afterが返ることを確認
+func TestLimiter_Middleware_ByEmail(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), map[string]Rule{
+		"/signin": {ByEmail: Per(1, time.Minute)},
+	})
+	limiter.now = func() time.Time { return time.Unix(0, 0) }
+
+	r := mux.NewRouter()
+	r.Use(limiter.Middleware)
+	r.HandleFunc("/signin", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }).Methods("POST")
+
+	send := func(ip, email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/signin", strings.NewReader(`{"email":"`+email+`"}`))
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, send("192.0.2.1", "user@example.com").Code)
+
+	rec := send("192.0.2.2", " User@Example.com ")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, send("192.0.2.2", "other@example.com").Code)
+}
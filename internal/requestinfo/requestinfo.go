@@ -0,0 +1,51 @@
+package requestinfo
+
+import (
+	"context"
+	"sync"
+)
+
+// Info は1リクエストの処理中にログ・メトリクスなどで共有する情報
+// ハンドラーはCognitoのエラーコードなどをここに記録し、ミドルウェアが参照します
+type Info struct {
+	LambdaRequestID string
+	APIRequestID    string
+	Route           string
+	SourceIP        string
+	UserAgent       string
+	Tenant          string
+
+	mu               sync.Mutex
+	cognitoErrorCode string
+}
+
+type contextKey struct{}
+
+// NewContext はinfoを保持したコンテキストを返します
+func NewContext(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext はコンテキストに保持されたInfoを返します。存在しない場合はnilを返します
+func FromContext(ctx context.Context) *Info {
+	info, _ := ctx.Value(contextKey{}).(*Info)
+	return info
+}
+
+// SetCognitoErrorCode はリクエストで発生したCognitoのエラーコードを記録します
+func SetCognitoErrorCode(ctx context.Context, code string) {
+	info := FromContext(ctx)
+	if info == nil || code == "" {
+		return
+	}
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	info.cognitoErrorCode = code
+}
+
+// CognitoErrorCode は記録されたCognitoのエラーコードを返します
+func (i *Info) CognitoErrorCode() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.cognitoErrorCode
+}
@@ -1,6 +0,0 @@
-package services
-
-// TestService - Hello, World! を返すシンプルな関数
-func TestService() string {
-	return "Hello, World!"
-}
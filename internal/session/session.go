@@ -0,0 +1,162 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cookieとヘッダーの名前
+const (
+	RefreshCookie = "refresh_token"
+	AccessCookie  = "access_token"
+	CSRFCookie    = "csrf_token"
+	CSRFHeader    = "X-CSRF-Token"
+)
+
+// ErrNoSession はリフレッシュトークンのCookieがない、または壊れている場合のエラー
+var ErrNoSession = errors.New("no session")
+
+// Config はCookieセッションの設定
+type Config struct {
+	// RefreshPath はリフレッシュトークンのCookieを送信するパス。リフレッシュとログアウトのエンドポイントを含めます
+	RefreshPath string
+	// RefreshMaxAge はリフレッシュトークンとCSRFトークンのCookieの有効期間。アプリクライアントの設定に合わせます
+	RefreshMaxAge time.Duration
+	// AccessTokenInCookie が有効な場合、アクセストークンをレスポンスボディではなく短命のCookieで返します
+	AccessTokenInCookie bool
+	// Domain はCookieのDomain属性。空の場合はホストのみに送信されます
+	Domain string
+}
+
+// Tokens はCookieに保存するトークン
+type Tokens struct {
+	Username     string // SECRET_HASHの計算に使用するCognito上のユーザー名
+	AccessToken  string
+	RefreshToken string // 空の場合はリフレッシュトークンのCookieを更新しません
+	ExpiresIn    time.Duration
+}
+
+// Manager はトークンをSecure・HttpOnly・SameSite=StrictのCookieで受け渡します
+type Manager struct {
+	cfg Config
+}
+
+// NewManager はCookieセッションのManagerを作成します
+func NewManager(cfg Config) *Manager {
+	if cfg.RefreshPath == "" {
+		cfg.RefreshPath = "/session"
+	}
+	if cfg.RefreshMaxAge <= 0 {
+		cfg.RefreshMaxAge = 30 * 24 * time.Hour
+	}
+	return &Manager{cfg: cfg}
+}
+
+// AccessTokenInCookie はアクセストークンをCookieで返すかどうかを返します
+func (m *Manager) AccessTokenInCookie() bool {
+	return m.cfg.AccessTokenInCookie
+}
+
+// SetTokens はトークンをCookieに設定します
+// サインイン時はstartをtrueにして、ダブルサブミット用のCSRFトークンも発行します
+func (m *Manager) SetTokens(w http.ResponseWriter, t Tokens, start bool) error {
+	if t.RefreshToken != "" {
+		// ユーザー名はbase64urlで符号化し、"."を含むリフレッシュトークンと区切る
+		value := base64.RawURLEncoding.EncodeToString([]byte(t.Username)) + "." + t.RefreshToken
+		http.SetCookie(w, m.cookie(RefreshCookie, value, m.cfg.RefreshPath, m.cfg.RefreshMaxAge, true))
+	}
+	if m.cfg.AccessTokenInCookie {
+		http.SetCookie(w, m.cookie(AccessCookie, t.AccessToken, "/", t.ExpiresIn, true))
+	}
+	if start {
+		csrf, err := newCSRFToken()
+		if err != nil {
+			return err
+		}
+		// スクリプトから読み取ってヘッダーに設定するためHttpOnlyにしない
+		http.SetCookie(w, m.cookie(CSRFCookie, csrf, "/", m.cfg.RefreshMaxAge, false))
+	}
+	return nil
+}
+
+// RefreshToken はCookieからユーザー名とリフレッシュトークンを取り出します
+func (m *Manager) RefreshToken(r *http.Request) (username, refreshToken string, err error) {
+	c, err := r.Cookie(RefreshCookie)
+	if err != nil {
+		return "", "", ErrNoSession
+	}
+	encoded, token, ok := strings.Cut(c.Value, ".")
+	if !ok || token == "" {
+		return "", "", ErrNoSession
+	}
+	name, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(name) == 0 {
+		return "", "", ErrNoSession
+	}
+	return string(name), token, nil
+}
+
+// Clear はセッションのCookieをすべて削除します
+func (m *Manager) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, m.cookie(RefreshCookie, "", m.cfg.RefreshPath, -1, true))
+	http.SetCookie(w, m.cookie(AccessCookie, "", "/", -1, true))
+	http.SetCookie(w, m.cookie(CSRFCookie, "", "/", -1, false))
+}
+
+// RequireCSRF はCookieで認証するルートに適用し、CSRFトークンのCookieと X-CSRF-Token ヘッダーの一致を確認します
+func (m *Manager) RequireCSRF(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !m.CheckCSRF(r) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// CheckCSRF はCSRFトークンのCookieと X-CSRF-Token ヘッダーが一致するかを返します
+func (m *Manager) CheckCSRF(r *http.Request) bool {
+	c, err := r.Cookie(CSRFCookie)
+	header := r.Header.Get(CSRFHeader)
+	return err == nil && c.Value != "" && header != "" && subtle.ConstantTimeCompare([]byte(c.Value), []byte(header)) == 1
+}
+
+// AccessToken はCookieのアクセストークンを返します
+func (m *Manager) AccessToken(r *http.Request) string {
+	c, err := r.Cookie(AccessCookie)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// cookie はmaxAgeが負の場合に削除用のCookieを作成します
+func (m *Manager) cookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   m.cfg.Domain,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(maxAge.Seconds()),
+	}
+	if maxAge < 0 {
+		c.MaxAge = -1
+	}
+	return c
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
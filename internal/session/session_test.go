@@ -0,0 +1,101 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// サインイン時のCookieの属性と、リフレッシュトークンの取り出しを確認
+func TestManager_SetTokens(t *testing.T) {
+	m := NewManager(Config{AccessTokenInCookie: true})
+	rec := httptest.NewRecorder()
+	err := m.SetTokens(rec, Tokens{
+		Username:     "3f2a-user",
+		AccessToken:  "access",
+		RefreshToken: "eyJ.a.b.c.d",
+		ExpiresIn:    time.Hour,
+	}, true)
+	assert.NoError(t, err)
+
+	cookies := map[string]*http.Cookie{}
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+
+	refresh := cookies[RefreshCookie]
+	assert.Equal(t, "/session", refresh.Path)
+	assert.True(t, refresh.Secure)
+	assert.True(t, refresh.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, refresh.SameSite)
+
+	access := cookies[AccessCookie]
+	assert.Equal(t, "access", access.Value)
+	assert.Equal(t, 3600, access.MaxAge)
+	assert.True(t, access.HttpOnly)
+
+	csrf := cookies[CSRFCookie]
+	assert.NotEmpty(t, csrf.Value)
+	assert.False(t, csrf.HttpOnly)
+
+	req := httptest.NewRequest(http.MethodPost, "/session/refresh", nil)
+	req.AddCookie(refresh)
+	username, token, err := m.RefreshToken(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "3f2a-user", username)
+	assert.Equal(t, "eyJ.a.b.c.d", token)
+
+	_, _, err = m.RefreshToken(httptest.NewRequest(http.MethodPost, "/session/refresh", nil))
+	assert.ErrorIs(t, err, ErrNoSession)
+}
+
+// CSRFトークンのCookieとヘッダーが一致しない場合は403を返すことを確認
+func TestManager_RequireCSRF(t *testing.T) {
+	m := NewManager(Config{})
+	h := m.RequireCSRF(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   int
+	}{
+		{"一致", "token-1", "token-1", http.StatusOK},
+		{"不一致", "token-1", "token-2", http.StatusForbidden},
+		{"ヘッダーなし", "token-1", "", http.StatusForbidden},
+		{"Cookieなし", "", "token-1", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/session/logout", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+// ログアウト時にすべてのCookieが削除されることを確認
+func TestManager_Clear(t *testing.T) {
+	m := NewManager(Config{RefreshPath: "/prod/session"})
+	rec := httptest.NewRecorder()
+	m.Clear(rec)
+
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 3)
+	for _, c := range cookies {
+		assert.Equal(t, -1, c.MaxAge)
+		if c.Name == RefreshCookie {
+			assert.Equal(t, "/prod/session", c.Path)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package signuppolicy
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Reason はサインアップを拒否した理由
+type Reason string
+
+const (
+	ReasonInvalidEmail     Reason = "invalid_email"
+	ReasonDomainNotAllowed Reason = "domain_not_allowed"
+	ReasonDomainDenied     Reason = "domain_denied"
+)
+
+// defaultMessages は拒否理由ごとの既定のメッセージ
+var defaultMessages = map[Reason]map[string]string{
+	ReasonInvalidEmail: {
+		"en": "Invalid email address",
+		"ja": "メールアドレスの形式が正しくありません",
+	},
+	ReasonDomainNotAllowed: {
+		"en": "Sign up is not allowed for this email domain",
+		"ja": "このメールアドレスのドメインでは登録できません",
+	},
+	ReasonDomainDenied: {
+		"en": "Sign up is not allowed for this email address",
+		"ja": "このメールアドレスでは登録できません",
+	},
+}
+
+// Rejection はサインアップを拒否したことを表すエラー
+type Rejection struct {
+	Reason        Reason
+	messages      map[Reason]map[string]string
+	defaultLocale string
+}
+
+// Error は既定の言語のメッセージを返します
+func (e *Rejection) Error() string {
+	return e.Message()
+}
+
+// Message は指定したロケールのうち、最初に見つかった言語のメッセージを返します
+// "ja-JP" は "ja" のメッセージにも一致します
+func (e *Rejection) Message(locales ...string) string {
+	for _, locale := range append(locales, e.defaultLocale, "en") {
+		locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+		if locale == "" {
+			continue
+		}
+		base, _, _ := strings.Cut(locale, "-")
+		for _, catalog := range []map[string]string{e.messages[e.Reason], defaultMessages[e.Reason]} {
+			if msg, ok := catalog[locale]; ok {
+				return msg
+			}
+			if msg, ok := catalog[base]; ok {
+				return msg
+			}
+		}
+	}
+	return string(e.Reason)
+}
+
+// AcceptLanguage はAccept-Languageヘッダーの言語を優先度の高い順に返します
+func AcceptLanguage(header string) []string {
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		tags = append(tags, tag{lang, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	langs := make([]string, 0, len(tags))
+	for _, t := range tags {
+		langs = append(langs, t.lang)
+	}
+	return langs
+}
@@ -0,0 +1,168 @@
+package signuppolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Config はセルフサインアップを許可するメールアドレスの条件
+// APIのサインアップとPreSignUpトリガーで同じ設定を使います
+type Config struct {
+	// AllowedDomains が空でない場合、いずれかのドメインに一致するアドレスのみ許可します
+	// "*.example.com" のようにサブドメインのワイルドカードを指定できます
+	AllowedDomains []string `json:"allowed_domains"`
+	// DeniedDomains に一致するアドレスは許可リストより優先して拒否します
+	DeniedDomains []string `json:"denied_domains"`
+	// AllowedPatterns と DeniedPatterns はメールアドレス全体（小文字）に対する正規表現
+	// AllowedPatterns はAllowedDomainsと同じく、いずれかに一致すれば許可します
+	AllowedPatterns []string `json:"allowed_patterns"`
+	DeniedPatterns  []string `json:"denied_patterns"`
+	// AutoConfirm はドメインごとの自動確認の設定。先頭から順に評価し、最初に一致したものを使います
+	AutoConfirm []AutoConfirmRule `json:"auto_confirm"`
+	// Messages は拒否理由ごとのメッセージを上書きします（例: {"domain_not_allowed": {"ja": "..."}}）
+	Messages map[Reason]map[string]string `json:"messages"`
+	// DefaultLocale はロケールが指定されていない、または対応するメッセージがない場合の言語。空の場合は "en"
+	DefaultLocale string `json:"default_locale"`
+}
+
+// AutoConfirmRule は招待したパートナーなど、確認コードなしで登録させるアドレスの条件
+type AutoConfirmRule struct {
+	Domain  string `json:"domain"`
+	Pattern string `json:"pattern"`
+	// AutoVerifyEmail と AutoVerifyPhone はCognitoの仕様上、自動確認と併せて有効になります
+	AutoVerifyEmail bool `json:"auto_verify_email"`
+	AutoVerifyPhone bool `json:"auto_verify_phone"`
+}
+
+// Decision は許可されたサインアップに対する自動確認の設定
+type Decision struct {
+	AutoConfirm     bool
+	AutoVerifyEmail bool
+	AutoVerifyPhone bool
+}
+
+// Policy はConfigの正規表現をコンパイルしたもの
+type Policy struct {
+	cfg           Config
+	allowed       []*regexp.Regexp
+	denied        []*regexp.Regexp
+	autoConfirm   []*regexp.Regexp // AutoConfirmと同じ順序。Patternがない場合はnil
+	defaultLocale string
+}
+
+// New は設定を検証してPolicyを作成します
+func New(cfg Config) (*Policy, error) {
+	p := &Policy{cfg: cfg, defaultLocale: cfg.DefaultLocale}
+	if p.defaultLocale == "" {
+		p.defaultLocale = "en"
+	}
+	var err error
+	if p.allowed, err = compile(cfg.AllowedPatterns); err != nil {
+		return nil, err
+	}
+	if p.denied, err = compile(cfg.DeniedPatterns); err != nil {
+		return nil, err
+	}
+	for _, rule := range cfg.AutoConfirm {
+		if rule.Domain == "" && rule.Pattern == "" {
+			return nil, fmt.Errorf("auto confirm rule requires domain or pattern")
+		}
+		var re *regexp.Regexp
+		if rule.Pattern != "" {
+			if re, err = regexp.Compile(rule.Pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", rule.Pattern, err)
+			}
+		}
+		p.autoConfirm = append(p.autoConfirm, re)
+	}
+	return p, nil
+}
+
+// Load はJSONファイルから設定を読み込んでPolicyを作成します
+func Load(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sign-up policy: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse sign-up policy: %w", err)
+	}
+	return New(cfg)
+}
+
+// Check はメールアドレスでサインアップできるかを判定します
+// 拒否する場合は *Rejection を返します
+func (p *Policy) Check(email string) (Decision, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return Decision{}, p.reject(ReasonInvalidEmail)
+	}
+	email = strings.ToLower(email)
+	_, domain, _ := strings.Cut(email, "@")
+
+	if matchDomain(p.cfg.DeniedDomains, domain) || matchPattern(p.denied, email) {
+		return Decision{}, p.reject(ReasonDomainDenied)
+	}
+	if (len(p.cfg.AllowedDomains) > 0 || len(p.allowed) > 0) &&
+		!matchDomain(p.cfg.AllowedDomains, domain) && !matchPattern(p.allowed, email) {
+		return Decision{}, p.reject(ReasonDomainNotAllowed)
+	}
+
+	for i, rule := range p.cfg.AutoConfirm {
+		if rule.Domain != "" && !matchDomain([]string{rule.Domain}, domain) {
+			continue
+		}
+		if p.autoConfirm[i] != nil && !p.autoConfirm[i].MatchString(email) {
+			continue
+		}
+		return Decision{AutoConfirm: true, AutoVerifyEmail: rule.AutoVerifyEmail, AutoVerifyPhone: rule.AutoVerifyPhone}, nil
+	}
+	return Decision{}, nil
+}
+
+func (p *Policy) reject(reason Reason) *Rejection {
+	return &Rejection{Reason: reason, messages: p.cfg.Messages, defaultLocale: p.defaultLocale}
+}
+
+// matchDomain はドメインがリストのいずれかに一致するかを返します
+func matchDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(d)
+		if suffix, ok := strings.CutPrefix(d, "*."); ok {
+			if strings.HasSuffix(domain, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(patterns []*regexp.Regexp, email string) bool {
+	for _, re := range patterns {
+		if re.MatchString(email) {
+			return true
+		}
+	}
+	return false
+}
+
+func compile(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
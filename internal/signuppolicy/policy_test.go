@@ -0,0 +1,67 @@
+package signuppolicy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 許可・拒否リストと自動確認の判定を確認
+func TestPolicy_Check(t *testing.T) {
+	p, err := New(Config{
+		AllowedDomains:  []string{"example.com", "*.partner.example"},
+		AllowedPatterns: []string{`^[a-z]+\+contractor@gmail\.com$`},
+		DeniedDomains:   []string{"old.partner.example"},
+		AutoConfirm: []AutoConfirmRule{
+			{Domain: "*.partner.example", AutoVerifyEmail: true},
+		},
+	})
+	assert.NoError(t, err)
+
+	d, err := p.Check("user@example.com")
+	assert.NoError(t, err)
+	assert.False(t, d.AutoConfirm)
+
+	d, err = p.Check("Sales@EU.Partner.Example")
+	assert.NoError(t, err)
+	assert.Equal(t, Decision{AutoConfirm: true, AutoVerifyEmail: true}, d)
+
+	_, err = p.Check("alice+contractor@gmail.com")
+	assert.NoError(t, err)
+
+	tests := map[string]Reason{
+		"bob@gmail.com":             ReasonDomainNotAllowed,
+		"user@old.partner.example":  ReasonDomainDenied,
+		"User <user@example.com>":   ReasonInvalidEmail,
+		"not-an-email":              ReasonInvalidEmail,
+		"user@example.com.evil.net": ReasonDomainNotAllowed,
+	}
+	for email, reason := range tests {
+		_, err := p.Check(email)
+		var rejection *Rejection
+		if assert.True(t, errors.As(err, &rejection), email) {
+			assert.Equal(t, reason, rejection.Reason, email)
+		}
+	}
+}
+
+// ロケールに応じたメッセージと、設定による上書きを確認
+func TestRejection_Message(t *testing.T) {
+	p, err := New(Config{
+		AllowedDomains: []string{"example.com"},
+		Messages: map[Reason]map[string]string{
+			ReasonDomainNotAllowed: {"ja": "社外のアドレスでは登録できません"},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = p.Check("user@gmail.com")
+	var rejection *Rejection
+	assert.True(t, errors.As(err, &rejection))
+	assert.Equal(t, "社外のアドレスでは登録できません", rejection.Message("ja-JP"))
+	assert.Equal(t, "Sign up is not allowed for this email domain", rejection.Message("fr", "en-US"))
+	assert.Equal(t, "Sign up is not allowed for this email domain", rejection.Error())
+
+	assert.Equal(t, []string{"ja-JP", "ja", "en"}, AcceptLanguage("en;q=0.5, ja-JP, ja;q=0.8, *;q=0.1"))
+}
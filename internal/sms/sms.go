@@ -0,0 +1,87 @@
+// Package sms はSMSの送信先を抽象化します
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// Message は送信するSMS
+type Message struct {
+	To   string // E.164形式の電話番号
+	Text string
+}
+
+// Sender はSMSを送信します
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SNSSender はAmazon SNSでSMSを送信するSender
+type SNSSender struct {
+	Client *sns.Client
+	// SenderID は送信者ID。空の場合は指定しません
+	SenderID string
+}
+
+// NewSNSSender はSDKの設定からSNSSenderを作成します
+func NewSNSSender(cfg aws.Config) *SNSSender {
+	return &SNSSender{Client: sns.NewFromConfig(cfg)}
+}
+
+// Send は確認コードなどのトランザクションSMSとして送信します
+func (s *SNSSender) Send(ctx context.Context, msg Message) error {
+	attrs := map[string]types.MessageAttributeValue{
+		"AWS.SNS.SMS.SMSType": {DataType: aws.String("String"), StringValue: aws.String("Transactional")},
+	}
+	if s.SenderID != "" {
+		attrs["AWS.SNS.SMS.SenderID"] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(s.SenderID)}
+	}
+	_, err := s.Client.Publish(ctx, &sns.PublishInput{
+		PhoneNumber:       aws.String(msg.To),
+		Message:           aws.String(msg.Text),
+		MessageAttributes: attrs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	return nil
+}
+
+// FileSender はSMSを送信せず、JSON Lines形式でファイルに追記するSender（開発用）
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send はSMSをファイルに追記します
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	b, err := json.Marshal(struct {
+		Time time.Time `json:"time"`
+		To   string    `json:"to"`
+		Text string    `json:"text"`
+	}{time.Now().UTC(), msg.To, msg.Text})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open sms file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write sms file: %w", err)
+	}
+	return nil
+}
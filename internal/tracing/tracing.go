@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporter はスパンの出力先
+type Exporter string
+
+const (
+	// ExporterNone はスパンを出力しません（コンテキストの伝播のみ行います）
+	ExporterNone Exporter = "none"
+	// ExporterStdout はスパンをJSONで標準出力に書き込みます
+	ExporterStdout Exporter = "stdout"
+	// ExporterFile はスパンをJSONでファイルに書き込みます。オフラインでの調査に使用します
+	ExporterFile Exporter = "file"
+	// ExporterOTLP はOTLP/HTTPでコレクターに送信します。送信先は OTEL_EXPORTER_OTLP_ENDPOINT などで指定します
+	ExporterOTLP Exporter = "otlp"
+)
+
+// Config はトレースの設定
+type Config struct {
+	ServiceName string
+	Exporter    Exporter
+	FilePath    string // ExporterFileの場合の出力先
+}
+
+// Provider はTracerProviderと出力先のファイルをまとめたもの
+type Provider struct {
+	*sdktrace.TracerProvider
+	closer io.Closer
+}
+
+// Setup はグローバルなTracerProviderとプロパゲーターを設定します
+// プロパゲーターはW3Cのtraceparent/baggageとX-Rayの X-Amzn-Trace-Id ヘッダーの両方を扱います
+func Setup(ctx context.Context, cfg Config) (*Provider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	p := &Provider{}
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		f, openErr := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if openErr != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", openErr)
+		}
+		p.closer = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	p.TracerProvider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(p.TracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+		xray.Propagator{},
+	))
+	return p, nil
+}
+
+// Shutdown は未送信のスパンを出力してから終了します
+func (p *Provider) Shutdown(ctx context.Context) error {
+	err := p.TracerProvider.Shutdown(ctx)
+	if p.closer != nil {
+		if closeErr := p.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// WithAWSMiddleware はAWS SDKのミドルウェアスタックにスパンを作成するステップを追加します
+// config.LoadDefaultConfig（cognito.NewCognitoService）に渡して使用します
+func WithAWSMiddleware() func(*config.LoadOptions) error {
+	return func(o *config.LoadOptions) error {
+		otelaws.AppendMiddlewares(&o.APIOptions)
+		return nil
+	}
+}
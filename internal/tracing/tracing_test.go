@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 出力先がない場合はForceFlushで何も出力せず、ファイルの場合はForceFlushで書き込まれることを確認
+func TestSetup_ForceFlush(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := Setup(ctx, Config{ServiceName: "test", Exporter: ExporterNone})
+	assert.NoError(t, err)
+	_, span := p.Tracer("test").Start(ctx, "noop")
+	span.End()
+	assert.NoError(t, p.ForceFlush(ctx))
+	assert.NoError(t, p.Shutdown(ctx))
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	p, err = Setup(ctx, Config{ServiceName: "test", Exporter: ExporterFile, FilePath: path})
+	assert.NoError(t, err)
+	_, span = p.Tracer("test").Start(ctx, "flushed")
+	span.End()
+	assert.NoError(t, p.ForceFlush(ctx))
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"Name":"flushed"`)
+	assert.NoError(t, p.Shutdown(ctx))
+}
+
+// HandlerがLambdaのトレースIDから設定する X-Amzn-Trace-Id を親として読み込むことを確認
+func TestSetup_XRayPropagator(t *testing.T) {
+	ctx := context.Background()
+	p, err := Setup(ctx, Config{ServiceName: "test"})
+	assert.NoError(t, err)
+	t.Cleanup(func() { p.Shutdown(ctx) })
+
+	header := http.Header{}
+	header.Set("X-Amzn-Trace-Id", "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	parent := trace.SpanContextFromContext(otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header)))
+
+	assert.True(t, parent.IsRemote())
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", parent.TraceID().String())
+	assert.Equal(t, "53995c3f42cd8ad8", parent.SpanID().String())
+	assert.True(t, parent.IsSampled())
+}
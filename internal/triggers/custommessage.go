@@ -0,0 +1,154 @@
+package triggers
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+//go:embed templates/custommessage
+var customMessageFS embed.FS
+
+// customMessageTemplates はtriggerSourceごとのテンプレートファイル名
+// テンプレートは templates/custommessage/{言語}/{名前}.html に置き、subject・sms・content を定義します
+// カスタム送信者トリガーのテンプレート（customSenderTemplates）も同じディレクトリに置きます
+var customMessageTemplates = map[string]string{
+	"CustomMessage_SignUp":              "signup",
+	"CustomMessage_ForgotPassword":      "forgot_password",
+	"CustomMessage_ResendCode":          "resend_code",
+	"CustomMessage_UpdateUserAttribute": "update_user_attribute",
+	"CustomMessage_AdminCreateUser":     "admin_create_user",
+}
+
+// MessageData はテンプレートに渡す値
+type MessageData struct {
+	AppName string
+	Locale  string
+	// Code と Username はCognitoが実際の値に置き換えるプレースホルダー（{####}、{username}）
+	// カスタム送信者トリガーでは復号した確認コードとユーザー名
+	Code           string
+	Username       string
+	Attributes     map[string]string
+	ClientMetadata map[string]string
+}
+
+// CustomMessage は確認コードなどのメールを、ユーザーの locale 属性の言語のテンプレートで作成するCustomMessageトリガー
+// テンプレートのないtriggerSourceや言語は、既定のメッセージと既定の言語を使います
+type CustomMessage struct {
+	appName       string
+	defaultLocale string
+	templates     map[string]*template.Template // "言語/名前" ごとのテンプレート
+}
+
+// NewCustomMessage は埋め込みのテンプレートを読み込みます
+// defaultLocale のテンプレートはすべてのtriggerSourceについて必要です。空の場合は "en" を使います
+func NewCustomMessage(appName, defaultLocale string) (*CustomMessage, error) {
+	if defaultLocale == "" {
+		defaultLocale = "en"
+	}
+	c := &CustomMessage{appName: appName, defaultLocale: defaultLocale, templates: map[string]*template.Template{}}
+
+	locales, err := fs.ReadDir(customMessageFS, "templates/custommessage")
+	if err != nil {
+		return nil, err
+	}
+	for _, locale := range locales {
+		if !locale.IsDir() {
+			continue
+		}
+		files, err := fs.Glob(customMessageFS, path.Join("templates/custommessage", locale.Name(), "*.html"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			t, err := template.ParseFS(customMessageFS, "templates/custommessage/layout.html", file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			c.templates[locale.Name()+"/"+strings.TrimSuffix(path.Base(file), ".html")] = t
+		}
+	}
+	for _, m := range []map[string]string{customMessageTemplates, customSenderTemplates} {
+		for source, name := range m {
+			if _, ok := c.templates[defaultLocale+"/"+name]; !ok {
+				return nil, fmt.Errorf("no %s template for %s in default locale %q", name, source, defaultLocale)
+			}
+		}
+	}
+	return c, nil
+}
+
+// CustomMessage はメールの件名と本文、SMSのメッセージを設定します
+func (c *CustomMessage) CustomMessage(ctx context.Context, event *events.CognitoEventUserPoolsCustomMessage) (*events.CognitoEventUserPoolsCustomMessage, error) {
+	name, ok := customMessageTemplates[event.TriggerSource]
+	if !ok {
+		return event, nil
+	}
+
+	attrs := make(map[string]string, len(event.Request.UserAttributes))
+	for k, v := range event.Request.UserAttributes {
+		attrs[k] = fmt.Sprint(v)
+	}
+	data := MessageData{
+		AppName:        c.appName,
+		Locale:         attrs["locale"],
+		Code:           event.Request.CodeParameter,
+		Username:       event.Request.UsernameParameter,
+		Attributes:     attrs,
+		ClientMetadata: event.Request.ClientMetadata,
+	}
+	subject, sms, body, err := c.message(name, data)
+	if err != nil {
+		return nil, err
+	}
+	event.Response.EmailSubject = subject
+	event.Response.SMSMessage = sms
+	event.Response.EmailMessage = body
+	return event, nil
+}
+
+// message はdata.Localeの言語のテンプレートで、メールの件名、SMSのメッセージ、メールの本文（HTML）を作成します
+func (c *CustomMessage) message(name string, data MessageData) (subject, sms, body string, err error) {
+	locale, t := c.template(data.Locale, name)
+	data.Locale = locale
+
+	if subject, err = render(t, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	if sms, err = render(t, "sms", data); err != nil {
+		return "", "", "", err
+	}
+	if body, err = render(t, "layout", data); err != nil {
+		return "", "", "", err
+	}
+	// 件名とSMSはHTMLではないため、エスケープを戻す
+	return html.UnescapeString(subject), html.UnescapeString(sms), body, nil
+}
+
+// template はlocale属性（例: "ja-JP"、"ja_JP"、"ja"）に一致する言語のテンプレートを返します
+func (c *CustomMessage) template(locale, name string) (string, *template.Template) {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	base, _, _ := strings.Cut(locale, "-")
+	for _, l := range []string{locale, strings.ToLower(locale), strings.ToLower(base)} {
+		if t, ok := c.templates[l+"/"+name]; ok && l != "" {
+			return l, t
+		}
+	}
+	return c.defaultLocale, c.templates[c.defaultLocale+"/"+name]
+}
+
+func render(t *template.Template, name string, data MessageData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,82 @@
+package triggers
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "testdata/custommessageのゴールデンファイルを更新する")
+
+// customMessageEvent はtestdataのイベントのtriggerSourceとlocale属性を置き換えます
+func customMessageEvent(t *testing.T, source, locale string) *events.CognitoEventUserPoolsCustomMessage {
+	t.Helper()
+	b, err := os.ReadFile("testdata/custommessage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event events.CognitoEventUserPoolsCustomMessage
+	if err := json.Unmarshal(b, &event); err != nil {
+		t.Fatal(err)
+	}
+	event.TriggerSource = source
+	event.Request.UserAttributes["locale"] = locale
+	return &event
+}
+
+// triggerSourceと言語ごとのメッセージをゴールデンファイルと比較
+func TestCustomMessage_Golden(t *testing.T) {
+	c, err := NewCustomMessage("Example App", "en")
+	assert.NoError(t, err)
+
+	for source, name := range customMessageTemplates {
+		for _, locale := range []string{"en", "ja"} {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				event, err := c.CustomMessage(context.Background(), customMessageEvent(t, source, locale))
+				assert.NoError(t, err)
+
+				// Cognitoはメッセージにコードのプレースホルダーがない場合にエラーにする
+				assert.Contains(t, event.Response.EmailMessage, "{####}")
+				assert.Contains(t, event.Response.SMSMessage, "{####}")
+				if source == "CustomMessage_AdminCreateUser" {
+					assert.Contains(t, event.Response.EmailMessage, "{username}")
+				}
+
+				got := "Subject: " + event.Response.EmailSubject + "\nSMS: " + event.Response.SMSMessage + "\n\n" + event.Response.EmailMessage + "\n"
+				golden := filepath.Join("testdata", "custommessage", locale, name+".golden")
+				if *update {
+					assert.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+					assert.NoError(t, os.WriteFile(golden, []byte(got), 0o644))
+				}
+				want, err := os.ReadFile(golden)
+				assert.NoError(t, err)
+				assert.Equal(t, string(want), got)
+			})
+		}
+	}
+}
+
+// 地域付きのlocaleは言語で選び、テンプレートのない言語は既定の言語を使うことを確認
+func TestCustomMessage_Locale(t *testing.T) {
+	c, err := NewCustomMessage("Example App", "en")
+	assert.NoError(t, err)
+
+	event, err := c.CustomMessage(context.Background(), customMessageEvent(t, "CustomMessage_ForgotPassword", "ja_JP"))
+	assert.NoError(t, err)
+	assert.Equal(t, "【Example App】パスワードの再設定", event.Response.EmailSubject)
+
+	event, err = c.CustomMessage(context.Background(), customMessageEvent(t, "CustomMessage_ForgotPassword", "fr-FR"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Reset your Example App password", event.Response.EmailSubject)
+
+	// テンプレートのないtriggerSourceは変更しない
+	event, err = c.CustomMessage(context.Background(), customMessageEvent(t, "CustomMessage_Authentication", "ja"))
+	assert.NoError(t, err)
+	assert.Empty(t, event.Response.EmailMessage)
+}
@@ -0,0 +1,120 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/esdk"
+	"cognito-lambda-handler/internal/mail"
+	"cognito-lambda-handler/internal/sms"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// customSenderTemplates はカスタム送信者トリガーのtriggerSourceの "_" 以降ごとのテンプレートファイル名
+// テンプレートのないtriggerSource（AccountTakeOverNotification）は送信しません
+var customSenderTemplates = map[string]string{
+	"SignUp":              "signup",
+	"ResendCode":          "resend_code",
+	"ForgotPassword":      "forgot_password",
+	"UpdateUserAttribute": "update_user_attribute",
+	"VerifyUserAttribute": "update_user_attribute",
+	"AdminCreateUser":     "admin_create_user",
+	"Authentication":      "authentication",
+}
+
+// CustomSenderEvent はカスタムEメール送信者トリガー（CustomEmailSender_*）と
+// カスタムSMS送信者トリガー（CustomSMSSender_*）のイベント
+type CustomSenderEvent struct {
+	events.CognitoEventUserPoolsHeader
+	Request CustomSenderRequest `json:"request"`
+}
+
+// CustomSenderRequest はカスタム送信者トリガーのリクエスト
+type CustomSenderRequest struct {
+	Type string `json:"type"`
+	// Code はKMSキーで暗号化した確認コード（AWS Encryption SDKのメッセージをbase64でエンコードしたもの）
+	// AdminCreateUserでは仮パスワード
+	Code           string            `json:"code"`
+	ClientMetadata map[string]string `json:"clientMetadata"`
+	UserAttributes map[string]string `json:"userAttributes"`
+}
+
+// CustomSender は暗号化された確認コードを復号し、独自の送信先でメールやSMSを送信するカスタム送信者トリガー
+// メッセージはCustomMessageトリガーと同じテンプレートで、ユーザーの locale 属性の言語で作成します
+type CustomSender struct {
+	Keys     esdk.KeyProvider
+	Messages *CustomMessage
+	Email    mail.Sender // nilの場合はカスタムEメール送信者トリガーをエラーにします
+	SMS      sms.Sender  // nilの場合はカスタムSMS送信者トリガーをエラーにします
+}
+
+// SendCustomEmail は確認コードのメールを email 属性のアドレスに送信します
+func (s *CustomSender) SendCustomEmail(ctx context.Context, event *CustomSenderEvent) (*CustomSenderEvent, error) {
+	if s.Email == nil {
+		return nil, errors.New("no email sender configured")
+	}
+	to := event.Request.UserAttributes["email"]
+	subject, text, body, ok, err := s.message(ctx, event, to)
+	if err != nil || !ok {
+		return event, err
+	}
+	if err := s.Email.Send(ctx, mail.Message{To: to, Subject: subject, Text: text, HTML: body}); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Sent custom email", "trigger_source", event.TriggerSource, "email", to)
+	return event, nil
+}
+
+// SendCustomSMS は確認コードのSMSを phone_number 属性の電話番号に送信します
+func (s *CustomSender) SendCustomSMS(ctx context.Context, event *CustomSenderEvent) (*CustomSenderEvent, error) {
+	if s.SMS == nil {
+		return nil, errors.New("no sms sender configured")
+	}
+	to := event.Request.UserAttributes["phone_number"]
+	_, text, _, ok, err := s.message(ctx, event, to)
+	if err != nil || !ok {
+		return event, err
+	}
+	if err := s.SMS.Send(ctx, sms.Message{To: to, Text: text}); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Sent custom sms", "trigger_source", event.TriggerSource)
+	return event, nil
+}
+
+// message は確認コードを復号してメッセージを作成します
+// 送信するメッセージがないtriggerSourceの場合は ok=false を返します
+func (s *CustomSender) message(ctx context.Context, event *CustomSenderEvent, to string) (subject, text, body string, ok bool, err error) {
+	_, kind, _ := strings.Cut(event.TriggerSource, "_")
+	name, ok := customSenderTemplates[kind]
+	if !ok || event.Request.Code == "" {
+		slog.InfoContext(ctx, "Skipped custom sender trigger without message", "trigger_source", event.TriggerSource)
+		return "", "", "", false, nil
+	}
+	if to == "" {
+		return "", "", "", false, fmt.Errorf("user has no destination for %s", event.TriggerSource)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(event.Request.Code)
+	if err != nil {
+		return "", "", "", false, fmt.Errorf("failed to decode code: %w", err)
+	}
+	code, _, err := esdk.Decrypt(ctx, s.Keys, ciphertext)
+	if err != nil {
+		return "", "", "", false, fmt.Errorf("failed to decrypt code: %w", err)
+	}
+
+	subject, text, body, err = s.Messages.message(name, MessageData{
+		AppName:        s.Messages.appName,
+		Locale:         event.Request.UserAttributes["locale"],
+		Code:           string(code),
+		Username:       event.UserName,
+		Attributes:     event.Request.UserAttributes,
+		ClientMetadata: event.Request.ClientMetadata,
+	})
+	return subject, text, body, err == nil, err
+}
@@ -0,0 +1,95 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/esdk"
+	"cognito-lambda-handler/internal/sms"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSMSSender struct {
+	sent []sms.Message
+}
+
+func (f *fakeSMSSender) Send(ctx context.Context, msg sms.Message) error {
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// customSenderEvent はtestdataのイベントのtriggerSourceを置き換え、コードを暗号化して設定します
+func customSenderEvent(t *testing.T, keys *esdk.RawAESProvider, source, code string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/customsender.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event CustomSenderEvent
+	if err := json.Unmarshal(b, &event); err != nil {
+		t.Fatal(err)
+	}
+	event.TriggerSource = source
+	if code != "" {
+		ciphertext, err := keys.Encrypt([]byte(code), map[string]string{"userpool-id": event.UserPoolID})
+		assert.NoError(t, err)
+		event.Request.Code = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	b, err = json.Marshal(event)
+	assert.NoError(t, err)
+	return b
+}
+
+func testCustomSender(t *testing.T) (*CustomSender, *esdk.RawAESProvider, *fakeSender, *fakeSMSSender) {
+	t.Helper()
+	keys := &esdk.RawAESProvider{Namespace: "cognito-test", Name: "code-key", Key: []byte("0123456789abcdef0123456789abcdef")}
+	messages, err := NewCustomMessage("Example App", "en")
+	assert.NoError(t, err)
+	email, text := &fakeSender{}, &fakeSMSSender{}
+	return &CustomSender{Keys: keys, Messages: messages, Email: email, SMS: text}, keys, email, text
+}
+
+// 復号した確認コードをユーザーの言語のテンプレートでメールとSMSに送信することを確認
+func TestCustomSender(t *testing.T) {
+	s, keys, email, text := testCustomSender(t)
+	d := &Dispatcher{CustomEmailSender: s, CustomSMSSender: s}
+	ctx := context.Background()
+
+	payload := customSenderEvent(t, keys, "CustomEmailSender_SignUp", "482913")
+	_, err := d.Dispatch(ctx, Source(payload), payload)
+	assert.NoError(t, err)
+	if assert.Len(t, email.sent, 1) {
+		msg := email.sent[0]
+		assert.Equal(t, "taro@example.com", msg.To)
+		assert.Equal(t, "【Example App】メールアドレスの確認", msg.Subject)
+		assert.Equal(t, "Example Appの確認コード: 482913", msg.Text)
+		assert.Contains(t, msg.HTML, "482913")
+		assert.Contains(t, msg.HTML, "山田 様")
+	}
+
+	payload = customSenderEvent(t, keys, "CustomSMSSender_Authentication", "105732")
+	_, err = d.Dispatch(ctx, Source(payload), payload)
+	assert.NoError(t, err)
+	assert.Equal(t, []sms.Message{{To: "+819012345678", Text: "Example Appのサインイン確認コード: 105732"}}, text.sent)
+}
+
+// コードのないtriggerSourceは送信せず、復号できないコードはエラーにすることを確認
+func TestCustomSender_Errors(t *testing.T) {
+	s, keys, email, _ := testCustomSender(t)
+	ctx := context.Background()
+
+	var event CustomSenderEvent
+	assert.NoError(t, json.Unmarshal(customSenderEvent(t, keys, "CustomEmailSender_AccountTakeOverNotification", ""), &event))
+	_, err := s.SendCustomEmail(ctx, &event)
+	assert.NoError(t, err)
+	assert.Empty(t, email.sent)
+
+	other := &esdk.RawAESProvider{Namespace: "cognito-test", Name: "code-key", Key: []byte("fedcba9876543210fedcba9876543210")}
+	assert.NoError(t, json.Unmarshal(customSenderEvent(t, other, "CustomEmailSender_ForgotPassword", "482913"), &event))
+	_, err = s.SendCustomEmail(ctx, &event)
+	assert.ErrorIs(t, err, esdk.ErrNoDataKey)
+	assert.Empty(t, email.sent)
+}
@@ -0,0 +1,126 @@
+package triggers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Dispatcher はCognitoのトリガーイベントをtriggerSourceに応じたハンドラーに振り分けます
+// サインアップ・認証前後・トークン生成前・メッセージのカスタマイズのトリガーは、ハンドラーが設定されていなければイベントをそのまま返します
+// ユーザー移行・カスタム認証・カスタム送信者のトリガーは、設定されていない場合エラーにします
+type Dispatcher struct {
+	PreSignUp          PreSignUpHandler
+	PostConfirmation   PostConfirmationHandler
+	PreAuthentication  PreAuthenticationHandler
+	PostAuthentication PostAuthenticationHandler
+	PreTokenGen        PreTokenGenHandler
+	// PreTokenGenV2 はイベントのversionが "1" 以外の場合に使います
+	PreTokenGenV2 PreTokenGenV2Handler
+	CustomMessage CustomMessageHandler
+	UserMigration UserMigrationHandler
+	OTP           *OTP
+	// CustomEmailSender と CustomSMSSender は確認コードを独自の送信先で送る場合に使います
+	CustomEmailSender CustomEmailSenderHandler
+	CustomSMSSender   CustomSMSSenderHandler
+}
+
+// Source はペイロードのtriggerSourceを返します。Cognitoのトリガーイベントでない場合は空文字を返します
+func Source(payload []byte) string {
+	var header events.CognitoEventUserPoolsHeader
+	if err := json.Unmarshal(payload, &header); err != nil {
+		return ""
+	}
+	return header.TriggerSource
+}
+
+// version はペイロードのイベントのバージョンを返します
+func version(payload []byte) string {
+	var header events.CognitoEventUserPoolsHeader
+	if err := json.Unmarshal(payload, &header); err != nil {
+		return ""
+	}
+	return header.Version
+}
+
+// Dispatch はトリガーイベントを処理し、Cognitoに返すイベントを返します
+// triggerSourceは "PreSignUp_SignUp" のように、トリガーの種類と呼び出し元を "_" でつないだ形式です
+func (d *Dispatcher) Dispatch(ctx context.Context, source string, payload []byte) (any, error) {
+	trigger, _, _ := strings.Cut(source, "_")
+	switch trigger {
+	case "PreSignUp":
+		if d.PreSignUp == nil {
+			return json.RawMessage(payload), nil
+		}
+		return handle(ctx, payload, d.PreSignUp.PreSignUp)
+	case "PostConfirmation":
+		if d.PostConfirmation == nil {
+			return json.RawMessage(payload), nil
+		}
+		return handle(ctx, payload, d.PostConfirmation.PostConfirmation)
+	case "PreAuthentication":
+		if d.PreAuthentication == nil {
+			return json.RawMessage(payload), nil
+		}
+		return handle(ctx, payload, d.PreAuthentication.PreAuthentication)
+	case "PostAuthentication":
+		if d.PostAuthentication == nil {
+			return json.RawMessage(payload), nil
+		}
+		return handle(ctx, payload, d.PostAuthentication.PostAuthentication)
+	case "TokenGeneration":
+		// V2以降のイベントはアクセストークンも変更できるため、versionで振り分ける
+		if version(payload) != "1" {
+			if d.PreTokenGenV2 == nil {
+				return json.RawMessage(payload), nil
+			}
+			return handle(ctx, payload, d.PreTokenGenV2.PreTokenGenV2)
+		}
+		if d.PreTokenGen == nil {
+			return json.RawMessage(payload), nil
+		}
+		return handle(ctx, payload, d.PreTokenGen.PreTokenGen)
+	case "CustomMessage":
+		if d.CustomMessage == nil {
+			return json.RawMessage(payload), nil
+		}
+		return handle(ctx, payload, d.CustomMessage.CustomMessage)
+	case "UserMigration":
+		if d.UserMigration != nil {
+			return handle(ctx, payload, d.UserMigration.MigrateUser)
+		}
+	case "CustomEmailSender":
+		if d.CustomEmailSender != nil {
+			return handle(ctx, payload, d.CustomEmailSender.SendCustomEmail)
+		}
+	case "CustomSMSSender":
+		if d.CustomSMSSender != nil {
+			return handle(ctx, payload, d.CustomSMSSender.SendCustomSMS)
+		}
+	case "DefineAuthChallenge":
+		if d.OTP != nil {
+			return handle(ctx, payload, d.OTP.DefineAuthChallenge)
+		}
+	case "CreateAuthChallenge":
+		if d.OTP != nil {
+			return handle(ctx, payload, d.OTP.CreateAuthChallenge)
+		}
+	case "VerifyAuthChallengeResponse":
+		if d.OTP != nil {
+			return handle(ctx, payload, d.OTP.VerifyAuthChallenge)
+		}
+	}
+	return nil, fmt.Errorf("no handler for trigger source %q", source)
+}
+
+// handle はペイロードをイベントの型に変換してハンドラーを呼び出します
+func handle[E any](ctx context.Context, payload []byte, fn func(context.Context, *E) (*E, error)) (any, error) {
+	var event E
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode trigger event: %w", err)
+	}
+	return fn(ctx, &event)
+}
@@ -0,0 +1,72 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/signuppolicy"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// triggerSourceの種類で対応するハンドラーが呼ばれることを確認
+func TestDispatcher_Dispatch(t *testing.T) {
+	d := &Dispatcher{
+		PreSignUp: PreSignUpFunc(func(ctx context.Context, event *events.CognitoEventUserPoolsPreSignup) (*events.CognitoEventUserPoolsPreSignup, error) {
+			event.Response.AutoConfirmUser = true
+			return event, nil
+		}),
+	}
+	payload := []byte(`{"version":"1","triggerSource":"PreSignUp_AdminCreateUser","userName":"user","request":{"userAttributes":{"email":"user@example.com"}},"response":{}}`)
+
+	source := Source(payload)
+	assert.Equal(t, "PreSignUp_AdminCreateUser", source)
+
+	resp, err := d.Dispatch(context.Background(), source, payload)
+	assert.NoError(t, err)
+	event := resp.(*events.CognitoEventUserPoolsPreSignup)
+	assert.True(t, event.Response.AutoConfirmUser)
+	assert.Equal(t, "user@example.com", event.Request.UserAttributes["email"])
+}
+
+// 管理者によるユーザーの作成は、許可リストにないドメインでもサインアップの条件を適用せずにそのまま返すことを確認
+func TestDispatcher_Dispatch_AdminCreateUser(t *testing.T) {
+	policy, err := signuppolicy.New(signuppolicy.Config{AllowedDomains: []string{"example.com"}})
+	assert.NoError(t, err)
+	d := &Dispatcher{PreSignUp: &SignUpPolicy{Policy: policy}}
+
+	payload := []byte(`{"version":"1","triggerSource":"PreSignUp_AdminCreateUser","userName":"user","request":{"userAttributes":{"email":"user@customer.example"}},"response":{}}`)
+	resp, err := d.Dispatch(context.Background(), Source(payload), payload)
+	assert.NoError(t, err)
+	event := resp.(*events.CognitoEventUserPoolsPreSignup)
+	assert.False(t, event.Response.AutoConfirmUser)
+	assert.Equal(t, "user@customer.example", event.Request.UserAttributes["email"])
+
+	// 自己登録は同じドメインを拒否する
+	payload = []byte(`{"version":"1","triggerSource":"PreSignUp_SignUp","userName":"user","request":{"userAttributes":{"email":"user@customer.example"}},"response":{}}`)
+	_, err = d.Dispatch(context.Background(), Source(payload), payload)
+	assert.Error(t, err)
+}
+
+// ハンドラーのないトリガーはイベントをそのまま返し、未知のトリガーはエラーになることを確認
+func TestDispatcher_Dispatch_Unhandled(t *testing.T) {
+	d := &Dispatcher{}
+	payload := []byte(`{"version":"1","triggerSource":"PostAuthentication_Authentication","request":{"newDeviceUsed":false},"response":{}}`)
+
+	resp, err := d.Dispatch(context.Background(), Source(payload), payload)
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(payload), resp)
+
+	_, err = d.Dispatch(context.Background(), "DefineAuthChallenge_Authentication", payload)
+	assert.Error(t, err)
+
+	// 確認コードを送信しないまま成功させないよう、カスタム送信者トリガーはエラーにする
+	_, err = d.Dispatch(context.Background(), "CustomEmailSender_SignUp", payload)
+	assert.Error(t, err)
+
+	_, err = d.Dispatch(context.Background(), "UnknownTrigger_Source", payload)
+	assert.Error(t, err)
+
+	assert.Empty(t, Source([]byte(`{"httpMethod":"POST","path":"/signin"}`)))
+}
@@ -0,0 +1,132 @@
+package triggers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// PreSignUpHandler はサインアップ前トリガー（PreSignUp_*）を処理します
+// エラーを返すとサインアップは拒否され、メッセージがクライアントに返されます
+type PreSignUpHandler interface {
+	PreSignUp(ctx context.Context, event *events.CognitoEventUserPoolsPreSignup) (*events.CognitoEventUserPoolsPreSignup, error)
+}
+
+// PostConfirmationHandler は確認後トリガー（PostConfirmation_*）を処理します
+type PostConfirmationHandler interface {
+	PostConfirmation(ctx context.Context, event *events.CognitoEventUserPoolsPostConfirmation) (*events.CognitoEventUserPoolsPostConfirmation, error)
+}
+
+// PreAuthenticationHandler は認証前トリガー（PreAuthentication_Authentication）を処理します
+// エラーを返すとサインインは拒否されます
+type PreAuthenticationHandler interface {
+	PreAuthentication(ctx context.Context, event *events.CognitoEventUserPoolsPreAuthentication) (*events.CognitoEventUserPoolsPreAuthentication, error)
+}
+
+// PostAuthenticationHandler は認証後トリガー（PostAuthentication_Authentication）を処理します
+type PostAuthenticationHandler interface {
+	PostAuthentication(ctx context.Context, event *events.CognitoEventUserPoolsPostAuthentication) (*events.CognitoEventUserPoolsPostAuthentication, error)
+}
+
+// PreTokenGenHandler はV1のトークン生成前トリガー（TokenGeneration_*）を処理します
+type PreTokenGenHandler interface {
+	PreTokenGen(ctx context.Context, event *events.CognitoEventUserPoolsPreTokenGen) (*events.CognitoEventUserPoolsPreTokenGen, error)
+}
+
+// PreTokenGenV2Handler はV2（およびV3）のトークン生成前トリガーを処理します
+// アクセストークンのクレームとスコープも変更できます
+type PreTokenGenV2Handler interface {
+	PreTokenGenV2(ctx context.Context, event *PreTokenGenV2Event) (*PreTokenGenV2Event, error)
+}
+
+// CustomMessageHandler は確認コードなどのメッセージのカスタマイズトリガー（CustomMessage_*）を処理します
+type CustomMessageHandler interface {
+	CustomMessage(ctx context.Context, event *events.CognitoEventUserPoolsCustomMessage) (*events.CognitoEventUserPoolsCustomMessage, error)
+}
+
+// UserMigrationHandler はユーザー移行トリガー（UserMigration_*）を処理します
+// エラーを返すと、ユーザーが存在しないものとして扱われます
+type UserMigrationHandler interface {
+	MigrateUser(ctx context.Context, event *events.CognitoEventUserPoolsMigrateUser) (*events.CognitoEventUserPoolsMigrateUser, error)
+}
+
+// CustomEmailSenderHandler はカスタムEメール送信者トリガー（CustomEmailSender_*）を処理します
+// エラーを返すと、コードを送信する操作が失敗します
+type CustomEmailSenderHandler interface {
+	SendCustomEmail(ctx context.Context, event *CustomSenderEvent) (*CustomSenderEvent, error)
+}
+
+// CustomSMSSenderHandler はカスタムSMS送信者トリガー（CustomSMSSender_*）を処理します
+type CustomSMSSenderHandler interface {
+	SendCustomSMS(ctx context.Context, event *CustomSenderEvent) (*CustomSenderEvent, error)
+}
+
+// PreSignUpFunc は関数をPreSignUpHandlerとして使うためのアダプター
+type PreSignUpFunc func(ctx context.Context, event *events.CognitoEventUserPoolsPreSignup) (*events.CognitoEventUserPoolsPreSignup, error)
+
+func (f PreSignUpFunc) PreSignUp(ctx context.Context, event *events.CognitoEventUserPoolsPreSignup) (*events.CognitoEventUserPoolsPreSignup, error) {
+	return f(ctx, event)
+}
+
+// PostConfirmationFunc は関数をPostConfirmationHandlerとして使うためのアダプター
+type PostConfirmationFunc func(ctx context.Context, event *events.CognitoEventUserPoolsPostConfirmation) (*events.CognitoEventUserPoolsPostConfirmation, error)
+
+func (f PostConfirmationFunc) PostConfirmation(ctx context.Context, event *events.CognitoEventUserPoolsPostConfirmation) (*events.CognitoEventUserPoolsPostConfirmation, error) {
+	return f(ctx, event)
+}
+
+// PreAuthenticationFunc は関数をPreAuthenticationHandlerとして使うためのアダプター
+type PreAuthenticationFunc func(ctx context.Context, event *events.CognitoEventUserPoolsPreAuthentication) (*events.CognitoEventUserPoolsPreAuthentication, error)
+
+func (f PreAuthenticationFunc) PreAuthentication(ctx context.Context, event *events.CognitoEventUserPoolsPreAuthentication) (*events.CognitoEventUserPoolsPreAuthentication, error) {
+	return f(ctx, event)
+}
+
+// PostAuthenticationFunc は関数をPostAuthenticationHandlerとして使うためのアダプター
+type PostAuthenticationFunc func(ctx context.Context, event *events.CognitoEventUserPoolsPostAuthentication) (*events.CognitoEventUserPoolsPostAuthentication, error)
+
+func (f PostAuthenticationFunc) PostAuthentication(ctx context.Context, event *events.CognitoEventUserPoolsPostAuthentication) (*events.CognitoEventUserPoolsPostAuthentication, error) {
+	return f(ctx, event)
+}
+
+// PreTokenGenFunc は関数をPreTokenGenHandlerとして使うためのアダプター
+type PreTokenGenFunc func(ctx context.Context, event *events.CognitoEventUserPoolsPreTokenGen) (*events.CognitoEventUserPoolsPreTokenGen, error)
+
+func (f PreTokenGenFunc) PreTokenGen(ctx context.Context, event *events.CognitoEventUserPoolsPreTokenGen) (*events.CognitoEventUserPoolsPreTokenGen, error) {
+	return f(ctx, event)
+}
+
+// PreTokenGenV2Func は関数をPreTokenGenV2Handlerとして使うためのアダプター
+type PreTokenGenV2Func func(ctx context.Context, event *PreTokenGenV2Event) (*PreTokenGenV2Event, error)
+
+func (f PreTokenGenV2Func) PreTokenGenV2(ctx context.Context, event *PreTokenGenV2Event) (*PreTokenGenV2Event, error) {
+	return f(ctx, event)
+}
+
+// CustomMessageFunc は関数をCustomMessageHandlerとして使うためのアダプター
+type CustomMessageFunc func(ctx context.Context, event *events.CognitoEventUserPoolsCustomMessage) (*events.CognitoEventUserPoolsCustomMessage, error)
+
+func (f CustomMessageFunc) CustomMessage(ctx context.Context, event *events.CognitoEventUserPoolsCustomMessage) (*events.CognitoEventUserPoolsCustomMessage, error) {
+	return f(ctx, event)
+}
+
+// UserMigrationFunc は関数をUserMigrationHandlerとして使うためのアダプター
+type UserMigrationFunc func(ctx context.Context, event *events.CognitoEventUserPoolsMigrateUser) (*events.CognitoEventUserPoolsMigrateUser, error)
+
+func (f UserMigrationFunc) MigrateUser(ctx context.Context, event *events.CognitoEventUserPoolsMigrateUser) (*events.CognitoEventUserPoolsMigrateUser, error) {
+	return f(ctx, event)
+}
+
+// CustomEmailSenderFunc は関数をCustomEmailSenderHandlerとして使うためのアダプター
+type CustomEmailSenderFunc func(ctx context.Context, event *CustomSenderEvent) (*CustomSenderEvent, error)
+
+func (f CustomEmailSenderFunc) SendCustomEmail(ctx context.Context, event *CustomSenderEvent) (*CustomSenderEvent, error) {
+	return f(ctx, event)
+}
+
+// CustomSMSSenderFunc は関数をCustomSMSSenderHandlerとして使うためのアダプター
+type CustomSMSSenderFunc func(ctx context.Context, event *CustomSenderEvent) (*CustomSenderEvent, error)
+
+func (f CustomSMSSenderFunc) SendCustomSMS(ctx context.Context, event *CustomSenderEvent) (*CustomSenderEvent, error) {
+	return f(ctx, event)
+}
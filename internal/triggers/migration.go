@@ -0,0 +1,72 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/legacy"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// UserMigration は移行元のユーザーストアでパスワードを確認し、ユーザープールにユーザーを作成するユーザー移行トリガー
+// 移行したユーザーはパスワードを引き継ぎ、移行元のメールアドレスは確認済みとして作成します
+type UserMigration struct {
+	Authenticator legacy.Authenticator
+}
+
+// MigrateUser はサインイン（UserMigration_Authentication）とパスワードを忘れた場合（UserMigration_ForgotPassword）の移行を処理します
+func (m *UserMigration) MigrateUser(ctx context.Context, event *events.CognitoEventUserPoolsMigrateUser) (*events.CognitoEventUserPoolsMigrateUser, error) {
+	var (
+		user *legacy.User
+		err  error
+	)
+	switch event.TriggerSource {
+	case "UserMigration_Authentication":
+		user, err = m.Authenticator.Authenticate(ctx, event.UserName, event.CognitoEventUserPoolsMigrateUserRequest.Password)
+	case "UserMigration_ForgotPassword":
+		user, err = m.Authenticator.Lookup(ctx, event.UserName)
+	default:
+		return nil, fmt.Errorf("unsupported trigger source %q", event.TriggerSource)
+	}
+	if err != nil {
+		if !errors.Is(err, legacy.ErrNotFound) && !errors.Is(err, legacy.ErrInvalidPassword) {
+			slog.ErrorContext(ctx, "Failed to look up legacy user", "trigger_source", event.TriggerSource, "error", err)
+		}
+		// Cognitoはトリガーのエラーをユーザーが存在しない場合のエラーとしてクライアントに返す
+		return nil, errors.New("user migration failed")
+	}
+
+	resp := &event.CognitoEventUserPoolsMigrateUserResponse
+	resp.UserAttributes = migratedAttributes(event.UserName, user)
+	resp.MessageAction = "SUPPRESS"
+	resp.DesiredDeliveryMediums = []string{"EMAIL"}
+	if event.TriggerSource == "UserMigration_Authentication" {
+		resp.FinalUserStatus = "CONFIRMED"
+	}
+	slog.InfoContext(ctx, "Migrated legacy user", "trigger_source", event.TriggerSource, "email", resp.UserAttributes["email"])
+	return event, nil
+}
+
+// migratedAttributes はユーザープールに作成する属性を返します
+// Cognitoが管理する属性は除き、メールアドレスがない場合はメールアドレスの形式のユーザー名を使います
+// パスワードの再設定コードを送信できるよう、移行元のユーザーストアにあるメールアドレスのみ確認済みにします
+func migratedAttributes(username string, user *legacy.User) map[string]string {
+	attrs := make(map[string]string, len(user.Attributes)+2)
+	for k, v := range user.Attributes {
+		if k == "sub" || k == "email_verified" || strings.HasPrefix(k, "cognito:") || v == "" {
+			continue
+		}
+		attrs[k] = v
+	}
+	if attrs["email"] != "" {
+		attrs["email"] = strings.ToLower(attrs["email"])
+		attrs["email_verified"] = "true"
+	} else if addr, err := mail.ParseAddress(username); err == nil && addr.Address == username && addr.Name == "" {
+		attrs["email"] = strings.ToLower(username)
+	}
+	return attrs
+}
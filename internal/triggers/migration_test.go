@@ -0,0 +1,102 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/legacy"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuthenticator struct {
+	users map[string]string // ユーザー名とパスワード
+}
+
+func (f *fakeAuthenticator) Authenticate(ctx context.Context, username, password string) (*legacy.User, error) {
+	user, err := f.Lookup(ctx, username)
+	if err == nil && f.users[username] != password {
+		return nil, legacy.ErrInvalidPassword
+	}
+	return user, err
+}
+
+func (f *fakeAuthenticator) Lookup(ctx context.Context, username string) (*legacy.User, error) {
+	if _, ok := f.users[username]; !ok {
+		return nil, legacy.ErrNotFound
+	}
+	return &legacy.User{Username: username, Attributes: map[string]string{"email": username, "given_name": "Taro", "sub": "legacy-id"}}, nil
+}
+
+func migrateUserEvent(t *testing.T, source, password string) *events.CognitoEventUserPoolsMigrateUser {
+	t.Helper()
+	b, err := os.ReadFile("testdata/migrateuser.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event events.CognitoEventUserPoolsMigrateUser
+	if err := json.Unmarshal(b, &event); err != nil {
+		t.Fatal(err)
+	}
+	event.TriggerSource = source
+	event.CognitoEventUserPoolsMigrateUserRequest.Password = password
+	return &event
+}
+
+// サインイン時の移行で確認済みのユーザーと最終的な属性を返すことを確認
+func TestUserMigration_Authentication(t *testing.T) {
+	m := &UserMigration{Authenticator: &fakeAuthenticator{users: map[string]string{"Taro@Example.com": "legacy-pass"}}}
+
+	event, err := m.MigrateUser(context.Background(), migrateUserEvent(t, "UserMigration_Authentication", "legacy-pass"))
+	assert.NoError(t, err)
+	assert.Equal(t, "CONFIRMED", event.FinalUserStatus)
+	assert.Equal(t, "SUPPRESS", event.MessageAction)
+	assert.Equal(t, map[string]string{
+		"email":          "taro@example.com",
+		"email_verified": "true",
+		"given_name":     "Taro",
+	}, event.UserAttributes)
+
+	_, err = m.MigrateUser(context.Background(), migrateUserEvent(t, "UserMigration_Authentication", "wrong"))
+	assert.Error(t, err)
+}
+
+// パスワードを忘れた場合の移行ではパスワードを確認せず、ユーザーの状態を指定しないことを確認
+func TestUserMigration_ForgotPassword(t *testing.T) {
+	m := &UserMigration{Authenticator: &fakeAuthenticator{users: map[string]string{"Taro@Example.com": "legacy-pass"}}}
+
+	event, err := m.MigrateUser(context.Background(), migrateUserEvent(t, "UserMigration_ForgotPassword", ""))
+	assert.NoError(t, err)
+	assert.Empty(t, event.FinalUserStatus)
+	assert.Equal(t, "true", event.UserAttributes["email_verified"])
+
+	unknown := migrateUserEvent(t, "UserMigration_ForgotPassword", "")
+	unknown.UserName = "jiro@example.com"
+	_, err = m.MigrateUser(context.Background(), unknown)
+	assert.Error(t, err)
+}
+
+// 移行元のメールアドレスのみ確認済みにし、メールアドレスの形式のユーザー名のみメールアドレスとして使うことを確認
+func TestMigratedAttributes(t *testing.T) {
+	cases := []struct {
+		name     string
+		username string
+		attrs    map[string]string
+		want     map[string]string
+	}{
+		{"legacy email", "taro", map[string]string{"email": "Taro@Example.com", "email_verified": "false"},
+			map[string]string{"email": "taro@example.com", "email_verified": "true"}},
+		{"email username", "Taro@Example.com", map[string]string{"given_name": "Taro"},
+			map[string]string{"email": "taro@example.com", "given_name": "Taro"}},
+		{"plain username", "taro", map[string]string{"given_name": "Taro"},
+			map[string]string{"given_name": "Taro"}},
+		{"display name username", "Taro <taro@example.com>", nil,
+			map[string]string{}},
+	}
+	for _, c := range cases {
+		got := migratedAttributes(c.username, &legacy.User{Username: c.username, Attributes: c.attrs})
+		assert.Equal(t, c.want, got, c.name)
+	}
+}
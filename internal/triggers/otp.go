@@ -0,0 +1,143 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/mail"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const customChallenge = "CUSTOM_CHALLENGE"
+
+// codeMetadataPrefix はChallengeMetadataに保存する確認コードの接頭辞
+// 再試行時に同じコードを使い回すため、前回のチャレンジから取り出します
+const codeMetadataPrefix = "CODE-"
+
+// OTP はメールの確認コードによるパスワードレスサインイン（CUSTOM_AUTH）の
+// Define/Create/VerifyAuthChallengeトリガー
+type OTP struct {
+	Sender mail.Sender
+	// CodeLength は確認コードの桁数。0の場合は6桁
+	CodeLength int
+	// MaxAttempts は1回のサインインで入力できる回数。0の場合は3回
+	MaxAttempts int
+	// Subject と Body はメールの件名と本文。Bodyの %s は確認コードに置き換えます
+	Subject string
+	Body    string
+}
+
+// DefineAuthChallenge は認証の進行を決めます
+// 正しいコードが入力されればトークンを発行し、上限回数を超えるか存在しないユーザーの場合は失敗させます
+func (o *OTP) DefineAuthChallenge(ctx context.Context, event *events.CognitoEventUserPoolsDefineAuthChallenge) (*events.CognitoEventUserPoolsDefineAuthChallenge, error) {
+	resp := &event.Response
+	session := event.Request.Session
+
+	if event.Request.UserNotFound {
+		resp.FailAuthentication = true
+		return event, nil
+	}
+
+	attempts := 0
+	for _, s := range session {
+		if s.ChallengeName != customChallenge {
+			// パスワードレス以外のチャレンジが混在する場合は扱わない
+			resp.FailAuthentication = true
+			return event, nil
+		}
+		attempts++
+	}
+
+	switch {
+	case attempts > 0 && session[len(session)-1].ChallengeResult:
+		resp.IssueTokens = true
+	case attempts >= o.maxAttempts():
+		resp.FailAuthentication = true
+	default:
+		resp.ChallengeName = customChallenge
+	}
+	return event, nil
+}
+
+// CreateAuthChallenge は確認コードを生成してメールで送信します
+// 同じサインイン内の再試行では前回のコードを使い回し、メールは再送しません
+func (o *OTP) CreateAuthChallenge(ctx context.Context, event *events.CognitoEventUserPoolsCreateAuthChallenge) (*events.CognitoEventUserPoolsCreateAuthChallenge, error) {
+	if event.Request.ChallengeName != customChallenge {
+		return event, nil
+	}
+	email := event.Request.UserAttributes["email"]
+
+	code := ""
+	if n := len(event.Request.Session); n > 0 {
+		code = strings.TrimPrefix(event.Request.Session[n-1].ChallengeMetadata, codeMetadataPrefix)
+	}
+	if code == "" {
+		var err error
+		if code, err = o.generateCode(); err != nil {
+			return nil, err
+		}
+		if err := o.Sender.Send(ctx, mail.Message{
+			To:      email,
+			Subject: o.subject(),
+			Text:    fmt.Sprintf(o.body(), code),
+		}); err != nil {
+			slog.ErrorContext(ctx, "Failed to send sign-in code", "email", email, "error", err)
+			return nil, err
+		}
+	}
+
+	event.Response.PublicChallengeParameters = map[string]string{
+		"delivery": "EMAIL",
+		"email":    mail.MaskAddress(email),
+	}
+	event.Response.PrivateChallengeParameters = map[string]string{"answer": code}
+	event.Response.ChallengeMetadata = codeMetadataPrefix + code
+	return event, nil
+}
+
+// VerifyAuthChallenge は入力されたコードを確認します
+func (o *OTP) VerifyAuthChallenge(ctx context.Context, event *events.CognitoEventUserPoolsVerifyAuthChallenge) (*events.CognitoEventUserPoolsVerifyAuthChallenge, error) {
+	expected := event.Request.PrivateChallengeParameters["answer"]
+	answer, _ := event.Request.ChallengeAnswer.(string)
+	event.Response.AnswerCorrect = expected != "" && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(answer)), []byte(expected)) == 1
+	return event, nil
+}
+
+func (o *OTP) generateCode() (string, error) {
+	length := o.CodeLength
+	if length <= 0 {
+		length = 6
+	}
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", length, n), nil
+}
+
+func (o *OTP) maxAttempts() int {
+	if o.MaxAttempts <= 0 {
+		return 3
+	}
+	return o.MaxAttempts
+}
+
+func (o *OTP) subject() string {
+	if o.Subject == "" {
+		return "サインイン用の確認コード"
+	}
+	return o.Subject
+}
+
+func (o *OTP) body() string {
+	if o.Body == "" {
+		return "確認コード: %s\n\nこのコードに心当たりがない場合は、このメールを破棄してください。"
+	}
+	return o.Body
+}
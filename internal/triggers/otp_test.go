@@ -0,0 +1,97 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/mail"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSender struct {
+	sent []mail.Message
+}
+
+func (f *fakeSender) Send(ctx context.Context, msg mail.Message) error {
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func defineEvent(results ...bool) *events.CognitoEventUserPoolsDefineAuthChallenge {
+	event := &events.CognitoEventUserPoolsDefineAuthChallenge{}
+	for _, ok := range results {
+		event.Request.Session = append(event.Request.Session, &events.CognitoEventUserPoolsChallengeResult{
+			ChallengeName:   customChallenge,
+			ChallengeResult: ok,
+		})
+	}
+	return event
+}
+
+// 初回はチャレンジを発行し、正解でトークン発行、上限回数で失敗することを確認
+func TestOTP_DefineAuthChallenge(t *testing.T) {
+	o := &OTP{}
+	ctx := context.Background()
+
+	event, _ := o.DefineAuthChallenge(ctx, defineEvent())
+	assert.Equal(t, customChallenge, event.Response.ChallengeName)
+	assert.False(t, event.Response.IssueTokens)
+
+	event, _ = o.DefineAuthChallenge(ctx, defineEvent(false, true))
+	assert.True(t, event.Response.IssueTokens)
+
+	event, _ = o.DefineAuthChallenge(ctx, defineEvent(false, false))
+	assert.Equal(t, customChallenge, event.Response.ChallengeName)
+
+	event, _ = o.DefineAuthChallenge(ctx, defineEvent(false, false, false))
+	assert.True(t, event.Response.FailAuthentication)
+
+	notFound := defineEvent()
+	notFound.Request.UserNotFound = true
+	event, _ = o.DefineAuthChallenge(ctx, notFound)
+	assert.True(t, event.Response.FailAuthentication)
+}
+
+// 初回はコードをメールで送信し、再試行では前回のコードを使い回すことを確認
+func TestOTP_CreateAuthChallenge(t *testing.T) {
+	sender := &fakeSender{}
+	o := &OTP{Sender: sender, CodeLength: 8}
+	ctx := context.Background()
+
+	event := &events.CognitoEventUserPoolsCreateAuthChallenge{}
+	event.Request.ChallengeName = customChallenge
+	event.Request.UserAttributes = map[string]string{"email": "user@example.com"}
+
+	event, err := o.CreateAuthChallenge(ctx, event)
+	assert.NoError(t, err)
+	code := event.Response.PrivateChallengeParameters["answer"]
+	assert.Len(t, code, 8)
+	assert.Equal(t, "u***@example.com", event.Response.PublicChallengeParameters["email"])
+	assert.Len(t, sender.sent, 1)
+	assert.Equal(t, "user@example.com", sender.sent[0].To)
+	assert.Contains(t, sender.sent[0].Text, code)
+
+	retry := &events.CognitoEventUserPoolsCreateAuthChallenge{}
+	retry.Request.ChallengeName = customChallenge
+	retry.Request.UserAttributes = event.Request.UserAttributes
+	retry.Request.Session = []*events.CognitoEventUserPoolsChallengeResult{
+		{ChallengeName: customChallenge, ChallengeMetadata: event.Response.ChallengeMetadata},
+	}
+	retry, err = o.CreateAuthChallenge(ctx, retry)
+	assert.NoError(t, err)
+	assert.Equal(t, code, retry.Response.PrivateChallengeParameters["answer"])
+	assert.Len(t, sender.sent, 1)
+}
+
+// 入力されたコードの前後の空白を無視して比較することを確認
+func TestOTP_VerifyAuthChallenge(t *testing.T) {
+	o := &OTP{}
+	for answer, want := range map[string]bool{"123456": true, " 123456 ": true, "654321": false, "": false} {
+		event := &events.CognitoEventUserPoolsVerifyAuthChallenge{}
+		event.Request.PrivateChallengeParameters = map[string]string{"answer": "123456"}
+		event.Request.ChallengeAnswer = answer
+		event, _ = o.VerifyAuthChallenge(context.Background(), event)
+		assert.Equal(t, want, event.Response.AnswerCorrect, answer)
+	}
+}
@@ -0,0 +1,46 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/signuppolicy"
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// SignUpPolicy はAPIのサインアップと同じ条件でPreSignUpトリガーを処理します
+// 拒否した場合のメッセージは、ユーザー属性またはクライアントメタデータの locale の言語で返します
+type SignUpPolicy struct {
+	Policy *signuppolicy.Policy
+}
+
+// PreSignUp はメールアドレスを検証し、条件に応じて自動確認を設定します
+// 管理者によるユーザーの作成（招待やインポート）は自己登録ではないため対象外です
+func (s *SignUpPolicy) PreSignUp(ctx context.Context, event *events.CognitoEventUserPoolsPreSignup) (*events.CognitoEventUserPoolsPreSignup, error) {
+	if event.TriggerSource == "PreSignUp_AdminCreateUser" {
+		return event, nil
+	}
+	attrs := event.Request.UserAttributes
+	email := attrs["email"]
+	if email == "" && event.TriggerSource == "PreSignUp_ExternalProvider" {
+		// メールアドレスを提供しない外部IdPは対象外
+		return event, nil
+	}
+
+	decision, err := s.Policy.Check(email)
+	var rejection *signuppolicy.Rejection
+	if errors.As(err, &rejection) {
+		slog.InfoContext(ctx, "Sign up rejected", "trigger_source", event.TriggerSource, "email", email, "reason", rejection.Reason)
+		// Cognitoはエラーメッセージをサインアップのレスポンスに含めて返す
+		return nil, errors.New(rejection.Message(attrs["locale"], event.Request.ClientMetadata["locale"]))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event.Response.AutoConfirmUser = decision.AutoConfirm
+	event.Response.AutoVerifyEmail = decision.AutoVerifyEmail
+	event.Response.AutoVerifyPhone = decision.AutoVerifyPhone && attrs["phone_number"] != ""
+	return event, nil
+}
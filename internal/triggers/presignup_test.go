@@ -0,0 +1,36 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/signuppolicy"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// 自動確認の設定と、locale属性の言語での拒否メッセージを確認
+func TestSignUpPolicy_PreSignUp(t *testing.T) {
+	policy, err := signuppolicy.New(signuppolicy.Config{
+		AllowedDomains: []string{"example.com", "partner.example"},
+		AutoConfirm:    []signuppolicy.AutoConfirmRule{{Domain: "partner.example", AutoVerifyEmail: true, AutoVerifyPhone: true}},
+	})
+	assert.NoError(t, err)
+	s := &SignUpPolicy{Policy: policy}
+
+	event := &events.CognitoEventUserPoolsPreSignup{}
+	event.TriggerSource = "PreSignUp_SignUp"
+	event.Request.UserAttributes = map[string]string{"email": "user@partner.example"}
+	event, err = s.PreSignUp(context.Background(), event)
+	assert.NoError(t, err)
+	assert.True(t, event.Response.AutoConfirmUser)
+	assert.True(t, event.Response.AutoVerifyEmail)
+	// 電話番号がない場合は検証済みにしない
+	assert.False(t, event.Response.AutoVerifyPhone)
+
+	rejected := &events.CognitoEventUserPoolsPreSignup{}
+	rejected.TriggerSource = "PreSignUp_SignUp"
+	rejected.Request.UserAttributes = map[string]string{"email": "user@gmail.com", "locale": "ja"}
+	_, err = s.PreSignUp(context.Background(), rejected)
+	assert.EqualError(t, err, "このメールアドレスのドメインでは登録できません")
+}
@@ -0,0 +1,122 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/claims"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// PreTokenGenV2Event はトークン生成前トリガーのV2イベント
+// aws-lambda-goの型ではクレームの値が文字列に限られるため、配列やオブジェクトを返せるよう独自に定義します
+type PreTokenGenV2Event struct {
+	events.CognitoEventUserPoolsHeader
+	Request  events.CognitoEventUserPoolsPreTokenGenV2Request `json:"request"`
+	Response PreTokenGenV2Response                            `json:"response"`
+}
+
+// PreTokenGenV2Response はV2イベントのレスポンス
+type PreTokenGenV2Response struct {
+	ClaimsAndScopeOverrideDetails ClaimsAndScopeOverrideDetails `json:"claimsAndScopeOverrideDetails"`
+}
+
+// ClaimsAndScopeOverrideDetails はIDトークンとアクセストークンの変更内容
+type ClaimsAndScopeOverrideDetails struct {
+	IDTokenGeneration     TokenGeneration `json:"idTokenGeneration"`
+	AccessTokenGeneration TokenGeneration `json:"accessTokenGeneration"`
+	// GroupOverrideDetails がnilの場合、グループは変更されません
+	GroupOverrideDetails *events.GroupConfiguration `json:"groupOverrideDetails,omitempty"`
+}
+
+// TokenGeneration は1つのトークンに対する変更内容。スコープはアクセストークンのみ変更できます
+type TokenGeneration struct {
+	ClaimsToAddOrOverride map[string]any `json:"claimsToAddOrOverride,omitempty"`
+	ClaimsToSuppress      []string       `json:"claimsToSuppress,omitempty"`
+	ScopesToAdd           []string       `json:"scopesToAdd,omitempty"`
+	ScopesToSuppress      []string       `json:"scopesToSuppress,omitempty"`
+}
+
+// TokenClaims はSourceから取得したクレームをトークンに追加するトークン生成前トリガー
+// V1のイベントではIDトークンのみ、V2のイベントではIDトークンとアクセストークンの両方を変更します
+type TokenClaims struct {
+	Source claims.Source
+}
+
+// PreTokenGen はV1のイベントを処理します
+func (t *TokenClaims) PreTokenGen(ctx context.Context, event *events.CognitoEventUserPoolsPreTokenGen) (*events.CognitoEventUserPoolsPreTokenGen, error) {
+	c, err := t.lookup(ctx, event.CognitoEventUserPoolsHeader, event.Request.UserAttributes, event.Request.GroupConfiguration)
+	if err != nil || c == nil {
+		return event, err
+	}
+
+	details := &event.Response.ClaimsOverrideDetails
+	// V1ではグループの設定を省略できないため、変更しない場合もリクエストの値をそのまま返す
+	details.GroupOverrideDetails = overrideGroups(event.Request.GroupConfiguration, c.Groups)
+	details.ClaimsToSuppress = c.Suppress
+	if len(c.IDToken) > 0 {
+		details.ClaimsToAddOrOverride = make(map[string]string, len(c.IDToken))
+		for k, v := range c.IDToken {
+			s, err := stringClaim(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid claim %q: %w", k, err)
+			}
+			details.ClaimsToAddOrOverride[k] = s
+		}
+	}
+	return event, nil
+}
+
+// PreTokenGenV2 はV2のイベントを処理します
+func (t *TokenClaims) PreTokenGenV2(ctx context.Context, event *PreTokenGenV2Event) (*PreTokenGenV2Event, error) {
+	c, err := t.lookup(ctx, event.CognitoEventUserPoolsHeader, event.Request.UserAttributes, event.Request.GroupConfiguration)
+	if err != nil || c == nil {
+		return event, err
+	}
+
+	details := &event.Response.ClaimsAndScopeOverrideDetails
+	details.IDTokenGeneration.ClaimsToAddOrOverride = c.IDToken
+	details.IDTokenGeneration.ClaimsToSuppress = c.Suppress
+	details.AccessTokenGeneration.ClaimsToAddOrOverride = c.AccessToken
+	details.AccessTokenGeneration.ClaimsToSuppress = c.Suppress
+	if c.Groups != nil {
+		groups := overrideGroups(event.Request.GroupConfiguration, c.Groups)
+		details.GroupOverrideDetails = &groups
+	}
+	return event, nil
+}
+
+func (t *TokenClaims) lookup(ctx context.Context, header events.CognitoEventUserPoolsHeader, attrs map[string]string, groups events.GroupConfiguration) (*claims.Claims, error) {
+	c, err := t.Source.Claims(ctx, claims.Subject{
+		Username:   header.UserName,
+		Attributes: attrs,
+		Groups:     groups.GroupsToOverride,
+		ClientID:   header.CallerContext.ClientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up claims: %w", err)
+	}
+	return c, nil
+}
+
+// overrideGroups はグループを置き換えたGroupConfigurationを返します。groupsがnilの場合は変更しません
+// IAMロールと優先ロールはリクエストの値を引き継ぎます
+func overrideGroups(current events.GroupConfiguration, groups []string) events.GroupConfiguration {
+	if groups != nil {
+		current.GroupsToOverride = groups
+	}
+	return current
+}
+
+// stringClaim はV1で返せるようクレームの値を文字列に変換します。文字列以外はJSONにします
+func stringClaim(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,85 @@
+package triggers
+
+import (
+	"cognito-lambda-handler/internal/claims"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dispatchFixture はtestdataのイベントをDispatcherで処理し、Cognitoに返すJSONをmapで返します
+func dispatchFixture(t *testing.T, d *Dispatcher, name string) map[string]any {
+	t.Helper()
+	payload, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := d.Dispatch(context.Background(), Source(payload), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out["response"].(map[string]any)
+}
+
+func newTokenClaimsDispatcher(t *testing.T) *Dispatcher {
+	source, err := claims.LoadFile("testdata/claims.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := &TokenClaims{Source: source}
+	return &Dispatcher{PreTokenGen: tc, PreTokenGenV2: tc}
+}
+
+// V1ではIDトークンのクレームを文字列で追加し、IAMロールを保ったままグループを置き換えることを確認
+func TestTokenClaims_PreTokenGen(t *testing.T) {
+	resp := dispatchFixture(t, newTokenClaimsDispatcher(t), "pretokengen-v1.json")
+	details := resp["claimsOverrideDetails"].(map[string]any)
+
+	assert.Equal(t, map[string]any{"tenant_id": "acme", "roles": `["admin"]`}, details["claimsToAddOrOverride"])
+	assert.Equal(t, []any{"email_verified"}, details["claimsToSuppress"])
+	assert.Equal(t, map[string]any{
+		"groupsToOverride":   []any{"acme-admin"},
+		"iamRolesToOverride": []any{"arn:aws:iam::123456789012:role/admin"},
+		"preferredRole":      "arn:aws:iam::123456789012:role/admin",
+	}, details["groupOverrideDetails"])
+}
+
+// V2ではアクセストークンに配列やオブジェクトのクレームを追加し、グループ・ユーザーの定義で上書きすることを確認
+func TestTokenClaims_PreTokenGenV2(t *testing.T) {
+	resp := dispatchFixture(t, newTokenClaimsDispatcher(t), "pretokengen-v2.json")
+	details := resp["claimsAndScopeOverrideDetails"].(map[string]any)
+
+	access := details["accessTokenGeneration"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"tenant_id":     "acme",
+		"roles":         []any{"admin", "billing"},
+		"feature_flags": map[string]any{"new_dashboard": true},
+	}, access["claimsToAddOrOverride"])
+	assert.Equal(t, []any{"email_verified"}, access["claimsToSuppress"])
+
+	id := details["idTokenGeneration"].(map[string]any)
+	assert.Equal(t, map[string]any{"tenant_id": "acme", "roles": []any{"admin"}}, id["claimsToAddOrOverride"])
+
+	groups := details["groupOverrideDetails"].(map[string]any)
+	assert.Equal(t, []any{"acme-admin"}, groups["groupsToOverride"])
+}
+
+// 一致する定義がない場合はイベントを変更しないことを確認
+func TestTokenClaims_NoClaims(t *testing.T) {
+	tc := &TokenClaims{Source: &claims.FileSource{}}
+	resp := dispatchFixture(t, &Dispatcher{PreTokenGenV2: tc}, "pretokengen-v2.json")
+	details := resp["claimsAndScopeOverrideDetails"].(map[string]any)
+	assert.Empty(t, details["accessTokenGeneration"])
+	assert.Nil(t, details["groupOverrideDetails"])
+}
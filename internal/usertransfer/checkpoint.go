@@ -0,0 +1,86 @@
+package usertransfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint は中断したエクスポート・インポートを再開するための進捗
+// Pathが空の場合は保存しません
+type Checkpoint struct {
+	Path string `json:"-"`
+	// Processed は処理済みのレコード数。インポートではファイルの先頭からこの件数を読み飛ばします
+	Processed int `json:"processed"`
+	// NextToken はエクスポートで次に取得するListUsersのページ
+	NextToken string `json:"next_token,omitempty"`
+	// Offset はエクスポートで書き込み済みの出力ファイルのサイズ。再開時はこの位置まで切り詰めます
+	Offset int64 `json:"offset,omitempty"`
+	// Done はすべてのレコードを処理済みであること
+	Done bool `json:"done,omitempty"`
+}
+
+// LoadCheckpoint はpathのチェックポイントを読み込みます。ファイルがない場合は最初から処理します
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	cp := &Checkpoint{Path: path}
+	if path == "" {
+		return cp, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// Resumed は以前の実行の続きから処理するかを返します
+func (c *Checkpoint) Resumed() bool {
+	return c.Processed > 0 || c.NextToken != ""
+}
+
+// Save は進捗を保存します。書き込み途中で中断しても壊れないよう、一時ファイルから置き換えます
+func (c *Checkpoint) Save() error {
+	if c.Path == "" {
+		return nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.Path), filepath.Base(c.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.Path); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Remove はすべて処理し終えたチェックポイントを削除します
+func (c *Checkpoint) Remove() error {
+	if c.Path == "" {
+		return nil
+	}
+	if err := os.Remove(c.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
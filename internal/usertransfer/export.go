@@ -0,0 +1,97 @@
+package usertransfer
+
+import (
+	"cognito-lambda-handler/internal/cognito"
+	"context"
+	"fmt"
+)
+
+// pageSize はListUsersとAdminListGroupsForUserで1回に取得する件数（上限）
+const pageSize = 60
+
+// UserSource はエクスポート元のユーザープール。*cognito.Serviceが実装します
+type UserSource interface {
+	ListUsers(ctx context.Context, filter string, limit int32, paginationToken string) ([]cognito.User, string, error)
+	AdminListGroupsForUser(ctx context.Context, username string, limit int32, nextToken string) ([]cognito.Group, string, error)
+}
+
+// Exporter はユーザープールのユーザーをページごとに書き込みます
+// ページを書き込むたびにCheckpointを保存するため、中断した場合は次のページから再開できます
+type Exporter struct {
+	Source UserSource
+	// Filter はListUsersのフィルター式。空の場合はすべてのユーザー
+	Filter string
+	// Groups がtrueの場合、ユーザーごとに所属するグループを取得します
+	Groups     bool
+	Checkpoint *Checkpoint
+	// Offset はページを書き込んだ後の出力ファイルの位置を返します
+	// 指定した場合はCheckpointに保存し、ページの途中で中断した場合に重複して書き込まないようにします
+	Offset func() (int64, error)
+}
+
+// Export はユーザーをwに書き込み、書き込んだ件数（再開前の件数を含む）を返します
+func (e *Exporter) Export(ctx context.Context, w Writer) (int, error) {
+	cp := e.Checkpoint
+	if cp == nil {
+		cp = &Checkpoint{}
+	}
+	if cp.Done {
+		return cp.Processed, nil
+	}
+
+	token := cp.NextToken
+	for {
+		users, next, err := e.Source.ListUsers(ctx, e.Filter, pageSize, token)
+		if err != nil {
+			return cp.Processed, err
+		}
+		for _, u := range users {
+			var groups []string
+			if e.Groups {
+				if groups, err = e.groups(ctx, u.Username); err != nil {
+					return cp.Processed, err
+				}
+			}
+			if err := w.Write(toRecord(u, groups)); err != nil {
+				return cp.Processed, fmt.Errorf("failed to write user %s: %w", u.Username, err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return cp.Processed, fmt.Errorf("failed to flush output: %w", err)
+		}
+
+		if e.Offset != nil {
+			if cp.Offset, err = e.Offset(); err != nil {
+				return cp.Processed, fmt.Errorf("failed to get output offset: %w", err)
+			}
+		}
+		cp.Processed += len(users)
+		cp.NextToken, cp.Done = next, next == ""
+		if err := cp.Save(); err != nil {
+			return cp.Processed, err
+		}
+		if cp.Done {
+			return cp.Processed, nil
+		}
+		token = next
+	}
+}
+
+// groups はユーザーが所属するすべてのグループ名を返します
+func (e *Exporter) groups(ctx context.Context, username string) ([]string, error) {
+	var names []string
+	token := ""
+	for {
+		groups, next, err := e.Source.AdminListGroupsForUser(ctx, username, pageSize, token)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			names = append(names, g.Name)
+		}
+		if next == "" {
+			return names, nil
+		}
+		token = next
+	}
+}
@@ -0,0 +1,169 @@
+package usertransfer
+
+import (
+	"cognito-lambda-handler/internal/cognito"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// UserTarget はAdminCreateUserでのインポート先のユーザープール。*cognito.Serviceが実装します
+type UserTarget interface {
+	AdminCreateUser(ctx context.Context, in cognito.CreateUserInput) (*cognito.User, error)
+	AdminAddUserToGroup(ctx context.Context, username, groupName string) error
+	AdminDisableUser(ctx context.Context, username string) error
+	AdminEnableUser(ctx context.Context, username string) error
+}
+
+// ImportResult はインポートの件数
+type ImportResult struct {
+	Created int // 作成したユーザー（ドライランでは作成するユーザー）
+	Skipped int // インポート先にすでに存在するユーザー（グループと有効・無効の状態は適用します）
+	Failed  int // 作成やグループへの追加に失敗したユーザー
+}
+
+// Importer はレコードをAdminCreateUserで1件ずつ作成します
+// APIのクォータを超えないよう、Intervalごとに1件ずつ作成します
+// 1件ごとにCheckpointを保存するため、中断した場合は続きのレコードから再開できます
+// 作成したユーザーのグループへの追加や無効化に失敗した場合は、そのレコードの前で中断します
+type Importer struct {
+	Target UserTarget
+	// Interval はAdminCreateUserの呼び出し間隔
+	Interval time.Duration
+	// MessageAction はAdminCreateUserのMessageAction。移行時は通常SUPPRESSで招待メッセージを送信しません
+	MessageAction string
+	// UsernameAttribute を指定した場合は、その属性の値をユーザー名にします
+	UsernameAttribute string
+	// DryRun がtrueの場合、レコードの検証のみ行いユーザーを作成しません
+	DryRun     bool
+	Checkpoint *Checkpoint
+}
+
+// Import はrのレコードをインポートします
+// 作成に失敗したユーザーは記録して続行し、ファイルの読み込みやチェックポイントの保存に失敗した場合はエラーを返します
+// ユーザーの作成後の処理に失敗した場合はチェックポイントを進めずにエラーを返すため、再開時にそのレコードからやり直します
+func (im *Importer) Import(ctx context.Context, r Reader) (ImportResult, error) {
+	var result ImportResult
+	cp := im.Checkpoint
+	if cp == nil || im.DryRun {
+		// ドライランでは進捗を保存しない
+		cp = &Checkpoint{}
+	}
+
+	var tick <-chan time.Time
+	if im.Interval > 0 && !im.DryRun {
+		ticker := time.NewTicker(im.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	first := true
+	for n := 0; ; n++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		if n < cp.Processed {
+			continue
+		}
+
+		if tick != nil && !first {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-tick:
+			}
+		}
+		first = false
+
+		if err := im.importRecord(ctx, record, &result); err != nil {
+			return result, err
+		}
+		if err := ctx.Err(); err != nil {
+			// 中断された呼び出しは再開時にやり直す
+			return result, err
+		}
+		cp.Processed = n + 1
+		if err := cp.Save(); err != nil {
+			return result, err
+		}
+	}
+
+	cp.Done = true
+	return result, cp.Save()
+}
+
+// importRecord は1件のレコードのユーザーを作成し、グループへの追加と無効化を行います
+// すでに存在するユーザーは、前回の実行で途中まで処理した可能性があるため、グループと有効・無効の状態のみ適用します
+// グループへの追加や有効・無効の変更に失敗した場合は、ユーザーが一部だけ反映された状態のためエラーを返します
+func (im *Importer) importRecord(ctx context.Context, record Record, result *ImportResult) error {
+	username := record.username(im.UsernameAttribute)
+	if username == "" {
+		slog.ErrorContext(ctx, "Record has no username", "username", record.Username)
+		result.Failed++
+		return nil
+	}
+	if im.DryRun {
+		slog.InfoContext(ctx, "Would create user", "username", username, "groups", record.Groups, "enabled", record.Enabled)
+		result.Created++
+		return nil
+	}
+
+	existing := false
+	_, err := im.Target.AdminCreateUser(ctx, cognito.CreateUserInput{
+		Username:      username,
+		Attributes:    record.importAttributes(),
+		MessageAction: im.MessageAction,
+	})
+	if err != nil {
+		if cognito.ErrorCode(err) != "UsernameExistsException" {
+			slog.ErrorContext(ctx, "Failed to create user", "username", username, "error", err)
+			result.Failed++
+			return nil
+		}
+		slog.InfoContext(ctx, "User already exists", "username", username)
+		existing = true
+	}
+
+	if err := im.applyState(ctx, username, record, existing); err != nil {
+		result.Failed++
+		return fmt.Errorf("user %s was not fully imported: %w", username, err)
+	}
+	if existing {
+		result.Skipped++
+	} else {
+		result.Created++
+	}
+	return nil
+}
+
+// applyState はユーザーをレコードのグループに追加し、有効・無効の状態を合わせます
+// 作成したばかりのユーザーは有効なため、有効化はすでに存在するユーザーにのみ行います
+func (im *Importer) applyState(ctx context.Context, username string, record Record, existing bool) error {
+	var errs []error
+	for _, group := range record.Groups {
+		if err := im.Target.AdminAddUserToGroup(ctx, username, group); err != nil {
+			slog.ErrorContext(ctx, "Failed to add user to group", "username", username, "group", group, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	switch {
+	case !record.Enabled:
+		if err := im.Target.AdminDisableUser(ctx, username); err != nil {
+			slog.ErrorContext(ctx, "Failed to disable user", "username", username, "error", err)
+			errs = append(errs, err)
+		}
+	case existing:
+		if err := im.Target.AdminEnableUser(ctx, username); err != nil {
+			slog.ErrorContext(ctx, "Failed to enable user", "username", username, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,60 @@
+package usertransfer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// JobCSVWriter はユーザーのインポートジョブ（CreateUserImportJob）用のCSVを書き込みます
+// 列はGetCSVHeaderが返すユーザープールの列名の順で、インポートしたユーザーはRESET_REQUIREDの状態になります
+// グループと有効・無効の状態はインポートジョブでは移せません
+type JobCSVWriter struct {
+	w      *csv.Writer
+	header []string
+	// UsernameAttribute を指定した場合は、その属性の値をcognito:usernameにします
+	UsernameAttribute string
+}
+
+// NewJobCSVWriter はheaderの列でJobCSVWriterを作成します
+// writeHeaderがfalseの場合（追記で再開する場合）は見出し行を書きません
+func NewJobCSVWriter(w io.Writer, header []string, writeHeader bool) (*JobCSVWriter, error) {
+	j := &JobCSVWriter{w: csv.NewWriter(w), header: header}
+	if writeHeader {
+		if err := j.w.Write(header); err != nil {
+			return nil, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+	}
+	return j, nil
+}
+
+// Write は1ユーザーを書き込みます
+// インポートジョブは真偽値の列の空欄を受け付けないため、cognito:mfa_enabledと確認済みフラグの既定値はfalseです
+func (j *JobCSVWriter) Write(r Record) error {
+	username := r.username(j.UsernameAttribute)
+	if username == "" {
+		return fmt.Errorf("record has no username")
+	}
+	attrs := r.importAttributes()
+	row := make([]string, len(j.header))
+	for i, name := range j.header {
+		switch name {
+		case "cognito:username":
+			row[i] = username
+		case "cognito:mfa_enabled", "email_verified", "phone_number_verified":
+			row[i] = "false"
+			if v, ok := attrs[name]; ok {
+				row[i] = v
+			}
+		default:
+			row[i] = attrs[name]
+		}
+	}
+	return j.w.Write(row)
+}
+
+// Flush はバッファしたCSVを書き込みます
+func (j *JobCSVWriter) Flush() error {
+	j.w.Flush()
+	return j.w.Error()
+}
@@ -0,0 +1,249 @@
+// Package usertransfer はユーザープール間でユーザーを移すためのエクスポートとインポートを提供します
+package usertransfer
+
+import (
+	"cognito-lambda-handler/internal/cognito"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format はエクスポートファイルの形式
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// FormatFromPath はファイルの拡張子から形式を返します。.csv以外はJSONLとして扱います
+func FormatFromPath(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSONL
+}
+
+// Record はエクスポートした1ユーザー
+type Record struct {
+	Username       string            `json:"username"`
+	Status         string            `json:"status,omitempty"`
+	Enabled        bool              `json:"enabled"`
+	Attributes     map[string]string `json:"attributes"`
+	Groups         []string          `json:"groups,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	LastModifiedAt time.Time         `json:"last_modified_at"`
+}
+
+func toRecord(u cognito.User, groups []string) Record {
+	return Record{
+		Username:       u.Username,
+		Status:         u.Status,
+		Enabled:        u.Enabled,
+		Attributes:     u.Attributes,
+		Groups:         groups,
+		CreatedAt:      u.CreatedAt,
+		LastModifiedAt: u.LastModifiedAt,
+	}
+}
+
+// username はインポート先のユーザー名を返します
+// attributeを指定した場合は、その属性（例: email）の値をユーザー名にします
+func (r Record) username(attribute string) string {
+	if attribute != "" {
+		return r.Attributes[attribute]
+	}
+	return r.Username
+}
+
+// importAttributes はインポートできる属性を返します。subはCognitoが発行するため除きます
+func (r Record) importAttributes() map[string]string {
+	attrs := make(map[string]string, len(r.Attributes))
+	for name, value := range r.Attributes {
+		if name != "sub" && value != "" {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
+
+// Writer はレコードをファイルに書き込みます
+type Writer interface {
+	Write(Record) error
+	Flush() error
+}
+
+// Reader はファイルからレコードを読み込みます。終端ではio.EOFを返します
+type Reader interface {
+	Read() (Record, error)
+}
+
+// csvColumns はCSVの属性以外の列
+var csvColumns = []string{"username", "status", "enabled", "groups", "created_at", "last_modified_at"}
+
+// NewWriter は形式に応じたWriterを作成します
+// CSVではattributesの属性を列にし、headerがfalseの場合（追記で再開する場合）は見出し行を書きません
+func NewWriter(format Format, w io.Writer, attributes []string, header bool) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		cw := &csvWriter{w: csv.NewWriter(w), attributes: attributes}
+		if header {
+			if err := cw.w.Write(append(append([]string{}, csvColumns...), attributes...)); err != nil {
+				return nil, fmt.Errorf("failed to write CSV header: %w", err)
+			}
+		}
+		return cw, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+// NewReader は形式に応じたReaderを作成します
+// CSVでは見出し行の既知の列以外を属性として読み込みます
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlReader{dec: json.NewDecoder(r)}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		header, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		return &csvReader{r: cr, header: header}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(r Record) error {
+	return j.enc.Encode(r)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return nil
+}
+
+type jsonlReader struct {
+	dec *json.Decoder
+}
+
+func (j *jsonlReader) Read() (Record, error) {
+	var r Record
+	if err := j.dec.Decode(&r); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+		return Record{}, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return r, nil
+}
+
+type csvWriter struct {
+	w          *csv.Writer
+	attributes []string
+}
+
+func (c *csvWriter) Write(r Record) error {
+	// グループ名には空白を含められないため、空白区切りで1列にまとめる
+	row := []string{
+		r.Username,
+		r.Status,
+		strconv.FormatBool(r.Enabled),
+		strings.Join(r.Groups, " "),
+		formatTime(r.CreatedAt),
+		formatTime(r.LastModifiedAt),
+	}
+	for _, name := range c.attributes {
+		row = append(row, r.Attributes[name])
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+func (c *csvReader) Read() (Record, error) {
+	row, err := c.r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+		return Record{}, fmt.Errorf("failed to read CSV row: %w", err)
+	}
+
+	r := Record{Enabled: true, Attributes: map[string]string{}}
+	for i, name := range c.header {
+		if i >= len(row) {
+			break
+		}
+		value := row[i]
+		switch name {
+		case "username":
+			r.Username = value
+		case "status":
+			r.Status = value
+		case "enabled":
+			if value != "" {
+				if r.Enabled, err = strconv.ParseBool(value); err != nil {
+					return Record{}, fmt.Errorf("invalid enabled value %q: %w", value, err)
+				}
+			}
+		case "groups":
+			r.Groups = strings.Fields(value)
+		case "created_at":
+			r.CreatedAt, _ = time.Parse(time.RFC3339, value)
+		case "last_modified_at":
+			r.LastModifiedAt, _ = time.Parse(time.RFC3339, value)
+		default:
+			if value != "" {
+				r.Attributes[name] = value
+			}
+		}
+	}
+	return r, nil
+}
+
+// Copy はrのすべてのレコードをwに書き込み、件数を返します
+func Copy(w Writer, r Reader) (int, error) {
+	n := 0
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return n, w.Flush()
+		}
+		if err != nil {
+			return n, fmt.Errorf("record %d: %w", n+1, err)
+		}
+		if err := w.Write(record); err != nil {
+			return n, fmt.Errorf("record %d: %w", n+1, err)
+		}
+		n++
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,244 @@
+package usertransfer
+
+import (
+	"bytes"
+	"cognito-lambda-handler/internal/cognito"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePool はページングするユーザー一覧と、作成したユーザーを記録するテスト用のユーザープール
+type fakePool struct {
+	pages   [][]cognito.User
+	groups  map[string][]string
+	created []cognito.CreateUserInput
+	added   []string
+	enabled []string
+	exists  map[string]bool
+	// failGroup のグループへの追加は、値の回数だけ失敗する
+	failGroup map[string]int
+}
+
+func (f *fakePool) ListUsers(ctx context.Context, filter string, limit int32, token string) ([]cognito.User, string, error) {
+	i := 0
+	if token != "" {
+		i = int(token[0] - '0')
+	}
+	next := ""
+	if i+1 < len(f.pages) {
+		next = string(rune('0' + i + 1))
+	}
+	return f.pages[i], next, nil
+}
+
+func (f *fakePool) AdminListGroupsForUser(ctx context.Context, username string, limit int32, token string) ([]cognito.Group, string, error) {
+	var groups []cognito.Group
+	for _, name := range f.groups[username] {
+		groups = append(groups, cognito.Group{Name: name})
+	}
+	return groups, "", nil
+}
+
+func (f *fakePool) AdminCreateUser(ctx context.Context, in cognito.CreateUserInput) (*cognito.User, error) {
+	if f.exists[in.Username] {
+		return nil, &smithy.GenericAPIError{Code: "UsernameExistsException"}
+	}
+	f.created = append(f.created, in)
+	if f.exists == nil {
+		f.exists = map[string]bool{}
+	}
+	f.exists[in.Username] = true
+	return &cognito.User{Username: in.Username}, nil
+}
+
+func (f *fakePool) AdminAddUserToGroup(ctx context.Context, username, group string) error {
+	if f.failGroup[group] > 0 {
+		f.failGroup[group]--
+		return &smithy.GenericAPIError{Code: "TooManyRequestsException"}
+	}
+	f.added = append(f.added, username+":"+group)
+	return nil
+}
+
+func (f *fakePool) AdminEnableUser(ctx context.Context, username string) error {
+	f.enabled = append(f.enabled, username)
+	return nil
+}
+
+func (f *fakePool) AdminDisableUser(ctx context.Context, username string) error {
+	return nil
+}
+
+func testUser(name string) cognito.User {
+	return cognito.User{
+		Username:   name,
+		Status:     "CONFIRMED",
+		Enabled:    true,
+		Attributes: map[string]string{"sub": name, "email": name + "@example.com", "email_verified": "true"},
+		CreatedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+// CSVとJSONLでエクスポートしたレコードを読み戻せることを確認
+func TestWriterReader_RoundTrip(t *testing.T) {
+	record := toRecord(testUser("taro"), []string{"admin", "support"})
+	for _, format := range []Format{FormatCSV, FormatJSONL} {
+		var buf bytes.Buffer
+		w, err := NewWriter(format, &buf, []string{"email", "email_verified", "sub"}, true)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Write(record))
+		assert.NoError(t, w.Flush())
+
+		r, err := NewReader(format, &buf)
+		assert.NoError(t, err)
+		got, err := r.Read()
+		assert.NoError(t, err, format)
+		assert.Equal(t, record.Username, got.Username, format)
+		assert.Equal(t, record.Groups, got.Groups, format)
+		assert.Equal(t, record.Attributes, got.Attributes, format)
+		assert.True(t, record.CreatedAt.Equal(got.CreatedAt), format)
+		_, err = r.Read()
+		assert.ErrorIs(t, err, io.EOF, format)
+	}
+	assert.Equal(t, FormatCSV, FormatFromPath("users.CSV"))
+	assert.Equal(t, FormatJSONL, FormatFromPath("users.jsonl"))
+}
+
+// ページごとにチェックポイントを保存し、中断したページから再開できることを確認
+func TestExporter_Resume(t *testing.T) {
+	pool := &fakePool{
+		pages:  [][]cognito.User{{testUser("a"), testUser("b")}, {testUser("c")}},
+		groups: map[string][]string{"a": {"admin"}},
+	}
+	path := filepath.Join(t.TempDir(), "export.checkpoint")
+	cp, err := LoadCheckpoint(path)
+	assert.NoError(t, err)
+	assert.False(t, cp.Resumed())
+
+	// 1ページ目の後で中断した状態から再開する
+	cp.Processed, cp.NextToken = 2, "1"
+	assert.NoError(t, cp.Save())
+	cp, err = LoadCheckpoint(path)
+	assert.NoError(t, err)
+	assert.True(t, cp.Resumed())
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(FormatJSONL, &buf, nil, false)
+	offset := func() (int64, error) { return int64(buf.Len()), nil }
+	n, err := (&Exporter{Source: pool, Groups: true, Checkpoint: cp, Offset: offset}).Export(context.Background(), w)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), `"username":"c"`)
+
+	cp, _ = LoadCheckpoint(path)
+	assert.True(t, cp.Done)
+	assert.Equal(t, int64(buf.Len()), cp.Offset)
+
+	buf.Reset()
+	w, _ = NewWriter(FormatJSONL, &buf, nil, true)
+	_, err = (&Exporter{Source: pool, Groups: true}).Export(context.Background(), w)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"groups":["admin"]`)
+}
+
+// インポートジョブのCSVがプールの列の順で、真偽値の列が空欄にならないことを確認
+func TestJobCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewJobCSVWriter(&buf, []string{"name", "email", "email_verified", "phone_number_verified", "cognito:mfa_enabled", "cognito:username"}, true)
+	assert.NoError(t, err)
+	w.UsernameAttribute = "email"
+	assert.NoError(t, w.Write(toRecord(testUser("taro"), nil)))
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "name,email,email_verified,phone_number_verified,cognito:mfa_enabled,cognito:username\n"+
+		",taro@example.com,true,false,false,taro@example.com\n", buf.String())
+
+	assert.Error(t, w.Write(Record{Username: "no-email"}))
+}
+
+// 既存のユーザーを飛ばして作成し、チェックポイントから続きを再開できることを確認
+func TestImporter_Import(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(FormatJSONL, &buf, nil, true)
+	for _, name := range []string{"a", "b", "c"} {
+		w.Write(toRecord(testUser(name), []string{"staff"}))
+	}
+	input := buf.String()
+
+	// ドライランでは作成もチェックポイントの保存もしない
+	path := filepath.Join(t.TempDir(), "import.checkpoint")
+	cp, _ := LoadCheckpoint(path)
+	pool := &fakePool{exists: map[string]bool{"b": true}}
+	r, _ := NewReader(FormatJSONL, strings.NewReader(input))
+	result, err := (&Importer{Target: pool, DryRun: true, Checkpoint: cp}).Import(context.Background(), r)
+	assert.NoError(t, err)
+	assert.Equal(t, ImportResult{Created: 3}, result)
+	assert.Empty(t, pool.created)
+	assert.NoFileExists(t, path)
+
+	// 1件目の処理後に中断した状態から再開する
+	cp.Processed = 1
+	r, _ = NewReader(FormatJSONL, strings.NewReader(input))
+	im := &Importer{Target: pool, Interval: time.Millisecond, MessageAction: "SUPPRESS", Checkpoint: cp}
+	result, err = im.Import(context.Background(), r)
+	assert.NoError(t, err)
+	assert.Equal(t, ImportResult{Created: 1, Skipped: 1}, result)
+	assert.Len(t, pool.created, 1)
+	assert.Equal(t, "c", pool.created[0].Username)
+	assert.Equal(t, "SUPPRESS", pool.created[0].MessageAction)
+	assert.NotContains(t, pool.created[0].Attributes, "sub")
+	// すでに存在するユーザーにもグループと有効な状態を適用する
+	assert.Equal(t, []string{"b:staff", "c:staff"}, pool.added)
+	assert.Equal(t, []string{"b"}, pool.enabled)
+
+	cp, _ = LoadCheckpoint(path)
+	assert.Equal(t, 3, cp.Processed)
+	assert.True(t, cp.Done)
+
+	// 壊れたレコードはエラーで止める
+	r, _ = NewReader(FormatJSONL, strings.NewReader("{"))
+	_, err = (&Importer{Target: pool}).Import(context.Background(), r)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, io.EOF))
+}
+
+// グループへの追加に失敗したレコードでチェックポイントを進めずに中断し、再開時にそのレコードから適用し直すことを確認
+func TestImporter_ResumeAfterPartialFailure(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(FormatJSONL, &buf, nil, true)
+	w.Write(toRecord(testUser("a"), []string{"staff"}))
+	w.Write(toRecord(testUser("b"), []string{"staff", "support"}))
+	w.Write(toRecord(testUser("c"), nil))
+	input := buf.String()
+
+	path := filepath.Join(t.TempDir(), "import.checkpoint")
+	pool := &fakePool{failGroup: map[string]int{"support": 1}}
+	cp, _ := LoadCheckpoint(path)
+	r, _ := NewReader(FormatJSONL, strings.NewReader(input))
+	result, err := (&Importer{Target: pool, Checkpoint: cp}).Import(context.Background(), r)
+	assert.Error(t, err)
+	assert.Equal(t, ImportResult{Created: 1, Failed: 1}, result)
+
+	cp, _ = LoadCheckpoint(path)
+	assert.Equal(t, 1, cp.Processed)
+	assert.False(t, cp.Done)
+
+	r, _ = NewReader(FormatJSONL, strings.NewReader(input))
+	result, err = (&Importer{Target: pool, Checkpoint: cp}).Import(context.Background(), r)
+	assert.NoError(t, err)
+	assert.Equal(t, ImportResult{Created: 1, Skipped: 1}, result)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{pool.created[0].Username, pool.created[1].Username, pool.created[2].Username})
+	assert.Equal(t, []string{"a:staff", "b:staff", "b:staff", "b:support"}, pool.added)
+
+	cp, _ = LoadCheckpoint(path)
+	assert.Equal(t, 3, cp.Processed)
+	assert.True(t, cp.Done)
+}
@@ -0,0 +1,30 @@
+package version
+
+import (
+	"runtime"
+)
+
+// ビルド時に -ldflags "-X cognito-lambda-handler/internal/version.Commit=..." で埋め込まれる値
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info はビルド情報
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get は埋め込まれたビルド情報を返します
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+}
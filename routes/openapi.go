@@ -0,0 +1,456 @@
+package routes
+
+import (
+	"cognito-lambda-handler/internal/handlers"
+	"cognito-lambda-handler/internal/health"
+	"cognito-lambda-handler/internal/openapi"
+	"cognito-lambda-handler/internal/version"
+	"net/http"
+)
+
+// apiInfo はOpenAPIドキュメントの概要
+var apiInfo = openapi.Info{
+	Title:       "Cognito Lambda Handler API",
+	Version:     "1.0.0",
+	Description: "Amazon Cognitoユーザープールを利用した認証API",
+}
+
+// 複数のルートで共通のエラーレスポンス
+var (
+	errInvalidPayload  = openapi.ErrorResponse{Status: http.StatusBadRequest, Message: "Invalid request payload"}
+	errTooManyRequests = openapi.ErrorResponse{Status: http.StatusTooManyRequests, Message: "Too many requests"}
+	errInvalidCSRF     = openapi.ErrorResponse{Status: http.StatusForbidden, Message: "Invalid CSRF token"}
+	errSessionDisabled = openapi.ErrorResponse{Status: http.StatusNotFound, Message: "Cookie session is not enabled"}
+	errOAuthDisabled   = openapi.ErrorResponse{Status: http.StatusNotFound, Message: "OAuth is not enabled"}
+)
+
+// deviceErrors はアクセストークンで認証するデバイス操作に共通のエラーレスポンス
+var deviceErrors = []openapi.ErrorResponse{
+	{Status: http.StatusBadRequest, Message: "Invalid input parameters"},
+	{Status: http.StatusUnauthorized, Message: "Missing access token"},
+	{Status: http.StatusUnauthorized, Message: "Invalid or expired access token"},
+	errInvalidCSRF,
+	{Status: http.StatusNotFound, Message: "Device not found"},
+	{Status: http.StatusTooManyRequests, Message: "Request limit exceeded"},
+}
+
+// adminErrors は管理APIに共通のエラーレスポンス
+var adminErrors = []openapi.ErrorResponse{
+	{Status: http.StatusBadRequest, Message: "Invalid input parameters"},
+	{Status: http.StatusUnauthorized, Message: "Missing access token"},
+	{Status: http.StatusUnauthorized, Message: "Invalid or expired access token"},
+	errInvalidCSRF,
+	{Status: http.StatusForbidden, Message: "Insufficient permissions"},
+	{Status: http.StatusNotFound, Message: "Admin API is not enabled"},
+	{Status: http.StatusTooManyRequests, Message: "Request limit exceeded"},
+	{Status: http.StatusServiceUnavailable, Message: "Failed to verify access token"},
+}
+
+// adminUserErrors はパスのユーザーを操作する管理APIのエラーレスポンス
+func adminUserErrors(failure string, extra ...openapi.ErrorResponse) []openapi.ErrorResponse {
+	errs := append([]openapi.ErrorResponse{
+		{Status: http.StatusNotFound, Message: "User not found"},
+		{Status: http.StatusInternalServerError, Message: failure},
+	}, extra...)
+	return append(errs, adminErrors...)
+}
+
+// adminPageQuery は一覧を返す管理APIのページングのパラメーター
+var adminPageQuery = []openapi.QueryParam{
+	{Name: "limit", Description: "1ページの件数（最大60）"},
+	{Name: "pagination_token", Description: "前のページのnext_token"},
+}
+
+// operations はルートごとのOpenAPI上の説明
+// ルートを追加・変更した場合はここも更新し、`go test ./routes -update` でテスト用のスナップショットを更新します
+var operations = map[string]openapi.Operation{
+	"POST /signup": {
+		Summary:     "ユーザーを登録します",
+		Description: "SIGNUP_POLICY_FILEが設定されている場合、PreSignUpトリガーと同じ条件でメールアドレスを検証します。拒否理由のメッセージはAccept-Languageの言語で返します。",
+		Tags:        []string{"auth"},
+		Request:     handlers.SignUpRequest{},
+		Response:    handlers.MessageResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid input parameters"},
+			{Status: http.StatusBadRequest, Message: "Invalid email address"},
+			{Status: http.StatusUnauthorized, Message: "Not authorized"},
+			{Status: http.StatusForbidden, Message: "Sign up is not allowed for this email domain"},
+			{Status: http.StatusForbidden, Message: "Sign up is not allowed for this email address"},
+			{Status: http.StatusForbidden, Message: "Sign up is not allowed"},
+			{Status: http.StatusNotFound, Message: "Resource not found"},
+			{Status: http.StatusConflict, Message: "User with this email already exists"},
+			{Status: http.StatusTooManyRequests, Message: "Request limit exceeded"},
+			{Status: http.StatusInternalServerError, Message: "Failed to sign up user"},
+		},
+	},
+	"POST /signin": {
+		Summary:     "SRP認証でサインインします",
+		Description: "ENUMERATION_SAFE_RESPONSESが有効な場合、存在しないユーザーも401を返します。SESSION_MODE=cookieの場合、リフレッシュトークンとCSRFトークンをCookieで返します。管理者が作成したユーザーの初回サインインでは、トークンの代わりにchallengeがNEW_PASSWORD_REQUIREDのNewPasswordRequiredResponseを返すため、そのusernameとsessionを/signin/new-passwordに送信して新しいパスワードを設定します。",
+		Tags:        []string{"auth"},
+		Request:     handlers.SignInRequest{},
+		Response:    handlers.SignInResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid input parameters"},
+			{Status: http.StatusUnauthorized, Message: "Incorrect username or password"},
+			{Status: http.StatusForbidden, Message: "CAPTCHA verification required"},
+			{Status: http.StatusNotFound, Message: "User does not exist"},
+			errTooManyRequests,
+			{Status: http.StatusTooManyRequests, Message: "Too many failed sign-in attempts"},
+			{Status: http.StatusInternalServerError, Message: "Failed to sign in user"},
+		},
+	},
+	"POST /signin/new-password": {
+		Summary:     "一時パスワードでのサインインに続けて新しいパスワードを設定します",
+		Description: "/signinが返したsessionとrequired_attributesの属性を送信します。成功時のレスポンスとCookieは/signinと同じです。",
+		Tags:        []string{"auth"},
+		Request:     handlers.NewPasswordRequest{},
+		Response:    handlers.SignInResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid input parameters"},
+			{Status: http.StatusBadRequest, Message: "Password does not meet requirements"},
+			{Status: http.StatusUnauthorized, Message: "Session expired"},
+			errTooManyRequests,
+			{Status: http.StatusTooManyRequests, Message: "Request limit exceeded"},
+			{Status: http.StatusInternalServerError, Message: "Failed to set new password"},
+		},
+	},
+	"POST /signin/otp/start": {
+		Summary:     "メールの確認コードによるパスワードレスサインインを開始します",
+		Description: "CUSTOM_AUTHフローを開始し、CreateAuthChallengeトリガーが確認コードをメールで送信します。ENUMERATION_SAFE_RESPONSESが有効な場合、存在しないユーザーにもダミーのセッションを返します。",
+		Tags:        []string{"auth"},
+		Request:     handlers.OTPStartRequest{},
+		Response:    handlers.OTPChallengeResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid input parameters"},
+			{Status: http.StatusUnauthorized, Message: "Passwordless sign-in is not available"},
+			{Status: http.StatusNotFound, Message: "User does not exist"},
+			errTooManyRequests,
+			{Status: http.StatusTooManyRequests, Message: "Request limit exceeded"},
+			{Status: http.StatusInternalServerError, Message: "Failed to start sign in"},
+		},
+	},
+	"POST /signin/otp/verify": {
+		Summary:     "確認コードを検証してサインインします",
+		Description: "成功時のレスポンスとCookieは/signinと同じです。コードが誤っていて再入力できる場合は、次の検証に使うセッションを含む401を返します。",
+		Tags:        []string{"auth"},
+		Request:     handlers.OTPVerifyRequest{},
+		Response:    handlers.SignInResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid input parameters"},
+			{Status: http.StatusUnauthorized, Message: "Incorrect code", Body: handlers.OTPRetryResponse{}},
+			{Status: http.StatusUnauthorized, Message: "Incorrect code or session expired"},
+			errTooManyRequests,
+			{Status: http.StatusTooManyRequests, Message: "Request limit exceeded"},
+			{Status: http.StatusInternalServerError, Message: "Failed to sign in user"},
+		},
+	},
+	"POST /confirm": {
+		Summary:  "サインアップを確認コードで確定します",
+		Tags:     []string{"auth"},
+		Request:  handlers.ConfirmSignUpRequest{},
+		Response: handlers.MessageResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid verification code"},
+			{Status: http.StatusBadRequest, Message: "Verification code expired"},
+			errTooManyRequests,
+			{Status: http.StatusInternalServerError, Message: "Failed to confirm sign up"},
+		},
+	},
+	"POST /forgot-password": {
+		Summary:     "パスワードリセット用の確認コードを送信します",
+		Description: "ENUMERATION_SAFE_RESPONSESが有効な場合、Cognitoのエラーに関わらず200を返します。",
+		Tags:        []string{"auth"},
+		Request:     handlers.ForgotPasswordRequest{},
+		Response:    handlers.MessageResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusNotFound, Message: "User not found"},
+			errTooManyRequests,
+			{Status: http.StatusTooManyRequests, Message: "Password reset limit exceeded"},
+			{Status: http.StatusInternalServerError, Message: "Failed to request password reset"},
+		},
+	},
+	"POST /reset-password": {
+		Summary:  "確認コードで新しいパスワードを設定します",
+		Tags:     []string{"auth"},
+		Request:  handlers.ResetPasswordRequest{},
+		Response: handlers.MessageResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid verification code"},
+			{Status: http.StatusBadRequest, Message: "Verification code has expired"},
+			{Status: http.StatusInternalServerError, Message: "Failed to reset password"},
+		},
+	},
+	"POST /session/refresh": {
+		Summary:     "Cookieのリフレッシュトークンでアクセストークンを更新します",
+		Description: "Cookieセッションモードでのみ使用できます。csrf_token Cookieの値を X-CSRF-Token ヘッダーに設定して送信します。",
+		Tags:        []string{"session"},
+		Response:    handlers.SignInResponse{},
+		Errors: []openapi.ErrorResponse{
+			{Status: http.StatusUnauthorized, Message: "No active session"},
+			{Status: http.StatusUnauthorized, Message: "Session expired"},
+			errInvalidCSRF,
+			errSessionDisabled,
+			errTooManyRequests,
+			{Status: http.StatusInternalServerError, Message: "Failed to refresh session"},
+		},
+	},
+	"POST /session/logout": {
+		Summary:     "リフレッシュトークンを取り消し、セッションのCookieを削除します",
+		Description: "Cookieセッションモードでのみ使用できます。csrf_token Cookieの値を X-CSRF-Token ヘッダーに設定して送信します。",
+		Tags:        []string{"session"},
+		Response:    handlers.MessageResponse{},
+		Errors:      []openapi.ErrorResponse{errInvalidCSRF, errSessionDisabled},
+	},
+	"GET /oauth/authorize": {
+		Summary:        "ホストUIの認可URLへリダイレクトします",
+		Description:    "state・nonce・PKCEのcode_verifierを署名付きのoauth_state Cookieに保存します。",
+		Tags:           []string{"oauth"},
+		Query:          []openapi.QueryParam{{Name: "identity_provider", Description: "外部IdP（例: Google, SignInWithApple）。省略時はホストUIのサインイン画面を表示します"}},
+		ResponseStatus: http.StatusFound,
+		Errors: []openapi.ErrorResponse{
+			errOAuthDisabled,
+			{Status: http.StatusInternalServerError, Message: "Failed to start authorization"},
+		},
+	},
+	"GET /oauth/callback": {
+		Summary:     "認可コードをトークンに交換します",
+		Description: "stateとIDトークンのnonceを確認します。Cookieセッションモードでは/signinと同じCookieを設定し、OAUTH_POST_LOGIN_REDIRECTが設定されていればリダイレクトします。",
+		Tags:        []string{"oauth"},
+		Query: []openapi.QueryParam{
+			{Name: "code", Description: "認可コード", Required: true},
+			{Name: "state", Description: "認可リクエストのstate", Required: true},
+		},
+		Response: handlers.SignInResponse{},
+		Errors: []openapi.ErrorResponse{
+			{Status: http.StatusBadRequest, Message: "Invalid state"},
+			{Status: http.StatusBadRequest, Message: "Missing authorization code"},
+			{Status: http.StatusUnauthorized, Message: "Authorization failed"},
+			{Status: http.StatusUnauthorized, Message: "Invalid or expired authorization code"},
+			{Status: http.StatusUnauthorized, Message: "Invalid ID token"},
+			errOAuthDisabled,
+			{Status: http.StatusBadGateway, Message: "Failed to exchange authorization code"},
+		},
+	},
+	"POST /credentials": {
+		Summary:     "IDトークンをIDプールの一時的なAWS認証情報に交換します",
+		Description: "GetIdとGetCredentialsForIdentityを呼び出します。IDENTITY_POOL_IDが設定されている場合のみ使用できます。",
+		Tags:        []string{"auth"},
+		Request:     handlers.CredentialsRequest{},
+		Response:    handlers.CredentialsResponse{},
+		Errors: []openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusUnauthorized, Message: "Invalid or expired ID token"},
+			{Status: http.StatusNotFound, Message: "Identity pool is not configured"},
+			errTooManyRequests,
+			{Status: http.StatusTooManyRequests, Message: "Request limit exceeded"},
+			{Status: http.StatusInternalServerError, Message: "Failed to get credentials"},
+		},
+	},
+	"GET /devices": {
+		Summary: "サインイン中のユーザーのデバイス一覧を返します",
+		Tags:    []string{"devices"},
+		Query: []openapi.QueryParam{
+			{Name: "limit", Description: "1ページの件数"},
+			{Name: "pagination_token", Description: "前のページのnext_token"},
+		},
+		Response: handlers.ListDevicesResponse{},
+		Errors: append([]openapi.ErrorResponse{
+			{Status: http.StatusBadRequest, Message: "Invalid limit"},
+			{Status: http.StatusInternalServerError, Message: "Failed to list devices"},
+		}, deviceErrors...),
+	},
+	"PUT /devices/{device_key}/status": {
+		Summary:  "デバイスを記憶するかどうかを設定します",
+		Tags:     []string{"devices"},
+		Request:  handlers.UpdateDeviceStatusRequest{},
+		Response: handlers.MessageResponse{},
+		Errors: append([]openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusInternalServerError, Message: "Failed to update device status"},
+		}, deviceErrors...),
+	},
+	"DELETE /devices/{device_key}": {
+		Summary:  "デバイスの登録を削除します",
+		Tags:     []string{"devices"},
+		Response: handlers.MessageResponse{},
+		Errors: append([]openapi.ErrorResponse{
+			{Status: http.StatusInternalServerError, Message: "Failed to forget device"},
+		}, deviceErrors...),
+	},
+	"GET /admin/users": {
+		Summary:     "ユーザーの一覧を返します",
+		Description: "管理者グループ（ADMIN_GROUP）のユーザーのアクセストークンが必要です。操作は監査ログに記録します。",
+		Tags:        []string{"admin"},
+		Query: []openapi.QueryParam{
+			{Name: "filter", Description: `Cognitoのフィルター式（例: email ^= "taro"）`},
+			{Name: "limit", Description: "1ページの件数（最大60）"},
+			{Name: "pagination_token", Description: "前のページのnext_token"},
+		},
+		Response: handlers.ListUsersResponse{},
+		Errors: append([]openapi.ErrorResponse{
+			{Status: http.StatusBadRequest, Message: "Invalid limit"},
+			{Status: http.StatusInternalServerError, Message: "Failed to list users"},
+		}, adminErrors...),
+	},
+	"POST /admin/users": {
+		Summary:        "ユーザーを作成し、招待メッセージを送信します",
+		Description:    "ユーザーは一時パスワードで初回サインインし、/signin/new-passwordで新しいパスワードを設定します。message_actionがRESENDの場合は招待メッセージを再送し、200を返します。",
+		Tags:           []string{"admin"},
+		Request:        handlers.AdminCreateUserRequest{},
+		Response:       handlers.AdminUserResponse{},
+		ResponseStatus: http.StatusCreated,
+		Errors: append([]openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid message action"},
+			{Status: http.StatusBadRequest, Message: "Invalid delivery medium"},
+			{Status: http.StatusBadRequest, Message: "Password does not meet requirements"},
+			{Status: http.StatusNotFound, Message: "User not found"},
+			{Status: http.StatusConflict, Message: "User already exists"},
+			{Status: http.StatusConflict, Message: "Email or phone number is already in use"},
+			{Status: http.StatusConflict, Message: "User has already signed in"},
+			{Status: http.StatusInternalServerError, Message: "Failed to create user"},
+			{Status: http.StatusBadGateway, Message: "Failed to deliver invitation"},
+		}, adminErrors...),
+	},
+	"GET /admin/users/{username}": {
+		Summary:  "ユーザーを返します",
+		Tags:     []string{"admin"},
+		Response: handlers.AdminUserResponse{},
+		Errors:   adminUserErrors("Failed to get user"),
+	},
+	"DELETE /admin/users/{username}": {
+		Summary:  "ユーザーを削除します",
+		Tags:     []string{"admin"},
+		Response: handlers.MessageResponse{},
+		Errors:   adminUserErrors("Failed to delete user"),
+	},
+	"PUT /admin/users/{username}/attributes": {
+		Summary:     "ユーザーの属性を更新します",
+		Description: "メールアドレスや電話番号を変更した場合、Cognitoが確認コードを送信します。",
+		Tags:        []string{"admin"},
+		Request:     handlers.UpdateUserAttributesRequest{},
+		Response:    handlers.MessageResponse{},
+		Errors: adminUserErrors("Failed to update user attributes",
+			errInvalidPayload,
+			openapi.ErrorResponse{Status: http.StatusConflict, Message: "Email or phone number is already in use"},
+		),
+	},
+	"POST /admin/users/{username}/disable": {
+		Summary:  "ユーザーを無効化し、サインインを拒否します",
+		Tags:     []string{"admin"},
+		Response: handlers.MessageResponse{},
+		Errors:   adminUserErrors("Failed to disable user"),
+	},
+	"POST /admin/users/{username}/enable": {
+		Summary:  "無効化したユーザーを有効化します",
+		Tags:     []string{"admin"},
+		Response: handlers.MessageResponse{},
+		Errors:   adminUserErrors("Failed to enable user"),
+	},
+	"POST /admin/users/{username}/reset-password": {
+		Summary:     "ユーザーのパスワードをリセットします",
+		Description: "現在のパスワードを無効にし、パスワードリセットの確認コードを送信します。",
+		Tags:        []string{"admin"},
+		Response:    handlers.MessageResponse{},
+		Errors:      adminUserErrors("Failed to reset password"),
+	},
+	"POST /admin/users/{username}/sign-out": {
+		Summary:     "ユーザーをすべての端末からサインアウトさせます",
+		Description: "リフレッシュトークンを無効にします。発行済みのアクセストークンは有効期限まで使用できます。",
+		Tags:        []string{"admin"},
+		Response:    handlers.MessageResponse{},
+		Errors:      adminUserErrors("Failed to sign out user"),
+	},
+	"GET /admin/users/{username}/groups": {
+		Summary:  "ユーザーが所属するグループの一覧を返します",
+		Tags:     []string{"admin"},
+		Query:    adminPageQuery,
+		Response: handlers.ListGroupsResponse{},
+		Errors: adminUserErrors("Failed to list groups for user",
+			openapi.ErrorResponse{Status: http.StatusBadRequest, Message: "Invalid limit"},
+		),
+	},
+	"PUT /admin/users/{username}/groups/{group}": {
+		Summary:     "ユーザーをグループに追加します",
+		Description: "追加したグループは次に発行されるトークンのcognito:groupsから反映されます。",
+		Tags:        []string{"admin"},
+		Response:    handlers.MessageResponse{},
+		Errors: adminUserErrors("Failed to add user to group",
+			openapi.ErrorResponse{Status: http.StatusNotFound, Message: "Group not found"},
+		),
+	},
+	"DELETE /admin/users/{username}/groups/{group}": {
+		Summary:     "ユーザーをグループから外します",
+		Description: "発行済みのトークンのcognito:groupsは有効期限まで変わりません。",
+		Tags:        []string{"admin"},
+		Response:    handlers.MessageResponse{},
+		Errors: adminUserErrors("Failed to remove user from group",
+			openapi.ErrorResponse{Status: http.StatusNotFound, Message: "Group not found"},
+		),
+	},
+	"GET /admin/groups": {
+		Summary:  "グループの一覧を返します",
+		Tags:     []string{"admin"},
+		Query:    adminPageQuery,
+		Response: handlers.ListGroupsResponse{},
+		Errors: append([]openapi.ErrorResponse{
+			{Status: http.StatusBadRequest, Message: "Invalid limit"},
+			{Status: http.StatusInternalServerError, Message: "Failed to list groups"},
+		}, adminErrors...),
+	},
+	"POST /admin/groups": {
+		Summary:        "グループを作成します",
+		Tags:           []string{"admin"},
+		Request:        handlers.CreateGroupRequest{},
+		Response:       handlers.GroupResponse{},
+		ResponseStatus: http.StatusCreated,
+		Errors: append([]openapi.ErrorResponse{
+			errInvalidPayload,
+			{Status: http.StatusBadRequest, Message: "Invalid precedence"},
+			{Status: http.StatusConflict, Message: "Group already exists"},
+			{Status: http.StatusInternalServerError, Message: "Failed to create group"},
+		}, adminErrors...),
+	},
+	"GET /admin/groups/{group}/users": {
+		Summary:  "グループに所属するユーザーの一覧を返します",
+		Tags:     []string{"admin"},
+		Query:    adminPageQuery,
+		Response: handlers.ListUsersResponse{},
+		Errors: append([]openapi.ErrorResponse{
+			{Status: http.StatusBadRequest, Message: "Invalid limit"},
+			{Status: http.StatusNotFound, Message: "Group not found"},
+			{Status: http.StatusInternalServerError, Message: "Failed to list users in group"},
+		}, adminErrors...),
+	},
+	"GET /healthz": {
+		Summary:  "ライブネスチェック",
+		Tags:     []string{"ops"},
+		Response: handlers.HealthResponse{},
+	},
+	"GET /readyz": {
+		Summary:  "依存関係を確認するレディネスチェック",
+		Tags:     []string{"ops"},
+		Response: health.Report{},
+		Errors: []openapi.ErrorResponse{
+			{Status: http.StatusServiceUnavailable, Message: "One or more dependencies are unavailable", Body: health.Report{}},
+		},
+	},
+	"GET /version": {
+		Summary:  "ビルド情報",
+		Tags:     []string{"ops"},
+		Response: version.Info{},
+	},
+	"GET /openapi.json": {
+		Summary:  "このAPIのOpenAPIドキュメント",
+		Tags:     []string{"ops"},
+		Response: map[string]any{},
+	},
+}
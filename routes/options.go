@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"cognito-lambda-handler/internal/cors"
+	"cognito-lambda-handler/internal/handlers"
+	"cognito-lambda-handler/internal/health"
+	"cognito-lambda-handler/internal/identity"
+	"github.com/gorilla/mux"
+)
+
+// Option はRegisterRoutesの挙動を変更する設定
+type Option func(*options)
+
+type options struct {
+	middlewares []mux.MiddlewareFunc
+	handlers    handlers.Options
+	readiness   *health.Checker
+	cors        *cors.Policy
+	identity    *identity.Service
+}
+
+// WithMiddleware はルーターにミドルウェアを追加します
+// 追加した順に外側から適用されます
+func WithMiddleware(mw ...mux.MiddlewareFunc) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, mw...)
+	}
+}
+
+// WithHandlerOptions はハンドラーの挙動を切り替える設定を指定します
+func WithHandlerOptions(opts handlers.Options) Option {
+	return func(o *options) {
+		o.handlers = opts
+	}
+}
+
+// WithReadinessChecker は/readyzで実行する依存関係の確認処理を指定します
+func WithReadinessChecker(checker *health.Checker) Option {
+	return func(o *options) {
+		o.readiness = checker
+	}
+}
+
+// WithCORS はCORSヘッダーの付与とプリフライトへの応答を有効にします
+// CORSミドルウェアはWithMiddlewareで追加したものより外側に適用されます
+func WithCORS(policy *cors.Policy) Option {
+	return func(o *options) {
+		o.cors = policy
+	}
+}
+
+// WithIdentityService は/credentialsで使用するIDプールのサービスを指定します
+func WithIdentityService(svc *identity.Service) Option {
+	return func(o *options) {
+		o.identity = svc
+	}
+}
@@ -3,20 +3,142 @@ package routes
 import (
 	"cognito-lambda-handler/internal/cognito"
 	"cognito-lambda-handler/internal/handlers"
+	"cognito-lambda-handler/internal/openapi"
+	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
+	"log/slog"
 	"net/http"
+	"sync"
 )
 
+var (
+	openAPIMu   sync.Mutex
+	openAPIJSON []byte
+)
+
+// openAPIDocument は登録済みのルートから生成したOpenAPIドキュメントのJSONを返します
+// ルートは設定によらず同じため、型のリフレクションを伴う生成は成功するまでプロセスで一度だけ行います
+// 生成に失敗した場合は結果を残さず、次の呼び出しで生成し直します
+func openAPIDocument(r *mux.Router) ([]byte, error) {
+	openAPIMu.Lock()
+	defer openAPIMu.Unlock()
+	if openAPIJSON != nil {
+		return openAPIJSON, nil
+	}
+	doc, err := openapi.Generate(r, apiInfo, operations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate OpenAPI document: %w", err)
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	openAPIJSON = append(b, '\n')
+	return openAPIJSON, nil
+}
+
 // RegisterRoutes 関数はすべてのAPIルートを登録します
-func RegisterRoutes(cognitoService *cognito.Service) *mux.Router {
+func RegisterRoutes(cognitoService *cognito.Service, opts ...Option) *mux.Router {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	r := mux.NewRouter()
+	if o.cors != nil {
+		// プリフライトをレート制限やロックアウトの対象にしないよう最初に適用する
+		r.Use(o.cors.Middleware)
+	}
+	r.Use(o.middlewares...)
 
 	// ルートの設定: handlersで定義したハンドラーを直接使用
-	r.HandleFunc("/signup", func(w http.ResponseWriter, r *http.Request) { handlers.SignUpHandler(w, r, cognitoService) }).Methods("POST")
-	r.HandleFunc("/signin", func(w http.ResponseWriter, r *http.Request) { handlers.SignInHandler(w, r, cognitoService) }).Methods("POST")
+	r.HandleFunc("/signup", func(w http.ResponseWriter, r *http.Request) { handlers.SignUpHandler(w, r, cognitoService, o.handlers) }).Methods("POST")
+	r.HandleFunc("/signin", func(w http.ResponseWriter, r *http.Request) { handlers.SignInHandler(w, r, cognitoService, o.handlers) }).Methods("POST")
+	r.HandleFunc("/signin/new-password", func(w http.ResponseWriter, r *http.Request) {
+		handlers.NewPasswordHandler(w, r, cognitoService, o.handlers)
+	}).Methods("POST")
+	r.HandleFunc("/signin/otp/start", func(w http.ResponseWriter, r *http.Request) {
+		handlers.OTPStartHandler(w, r, cognitoService, o.handlers)
+	}).Methods("POST")
+	r.HandleFunc("/signin/otp/verify", func(w http.ResponseWriter, r *http.Request) {
+		handlers.OTPVerifyHandler(w, r, cognitoService, o.handlers)
+	}).Methods("POST")
 	r.HandleFunc("/confirm", func(w http.ResponseWriter, r *http.Request) { handlers.ConfirmSignUpHandler(w, r, cognitoService) }).Methods("POST")
-	r.HandleFunc("/forgot-password", func(w http.ResponseWriter, r *http.Request) { handlers.ForgotPasswordHandler(w, r, cognitoService) }).Methods("POST")
+	r.HandleFunc("/forgot-password", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ForgotPasswordHandler(w, r, cognitoService, o.handlers)
+	}).Methods("POST")
 	r.HandleFunc("/reset-password", func(w http.ResponseWriter, r *http.Request) { handlers.ResetPasswordHandler(w, r, cognitoService) }).Methods("POST")
-	r.HandleFunc("/test", handlers.TestHandler).Methods("GET")
+
+	// Cookieセッション: Cookieで認証するルートにはCSRFトークンの確認を適用する
+	refresh := func(w http.ResponseWriter, r *http.Request) {
+		handlers.RefreshHandler(w, r, cognitoService, o.handlers)
+	}
+	logout := func(w http.ResponseWriter, r *http.Request) { handlers.LogoutHandler(w, r, cognitoService, o.handlers) }
+	if o.handlers.Session != nil {
+		refresh = o.handlers.Session.RequireCSRF(refresh)
+		logout = o.handlers.Session.RequireCSRF(logout)
+	}
+	r.HandleFunc("/session/refresh", refresh).Methods("POST")
+	r.HandleFunc("/session/logout", logout).Methods("POST")
+
+	r.HandleFunc("/oauth/authorize", func(w http.ResponseWriter, r *http.Request) { handlers.OAuthAuthorizeHandler(w, r, o.handlers) }).Methods("GET")
+	r.HandleFunc("/oauth/callback", func(w http.ResponseWriter, r *http.Request) {
+		handlers.OAuthCallbackHandler(w, r, cognitoService, o.handlers)
+	}).Methods("GET")
+	r.HandleFunc("/credentials", func(w http.ResponseWriter, r *http.Request) { handlers.CredentialsHandler(w, r, o.identity) }).Methods("POST")
+
+	// デバイス: Authorizationヘッダー、またはCookieセッションのアクセストークンで認証する
+	r.HandleFunc("/devices", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListDevicesHandler(w, r, cognitoService, o.handlers)
+	}).Methods("GET")
+	r.HandleFunc("/devices/{device_key}/status", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UpdateDeviceStatusHandler(w, r, cognitoService, o.handlers)
+	}).Methods("PUT")
+	r.HandleFunc("/devices/{device_key}", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ForgetDeviceHandler(w, r, cognitoService, o.handlers)
+	}).Methods("DELETE")
+
+	// 管理API: アクセストークンの署名と管理者グループ（ADMIN_GROUP）を確認する
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(handlers.RequireAdmin(o.handlers))
+	adminRoute := func(path, method string, h func(http.ResponseWriter, *http.Request, *cognito.Service, handlers.Options)) {
+		admin.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) { h(w, r, cognitoService, o.handlers) }).Methods(method)
+	}
+	adminRoute("/users", "GET", handlers.AdminListUsersHandler)
+	adminRoute("/users", "POST", handlers.AdminCreateUserHandler)
+	adminRoute("/users/{username}", "GET", handlers.AdminGetUserHandler)
+	adminRoute("/users/{username}", "DELETE", handlers.AdminDeleteUserHandler)
+	adminRoute("/users/{username}/attributes", "PUT", handlers.AdminUpdateUserAttributesHandler)
+	adminRoute("/users/{username}/disable", "POST", handlers.AdminDisableUserHandler)
+	adminRoute("/users/{username}/enable", "POST", handlers.AdminEnableUserHandler)
+	adminRoute("/users/{username}/reset-password", "POST", handlers.AdminResetUserPasswordHandler)
+	adminRoute("/users/{username}/sign-out", "POST", handlers.AdminUserGlobalSignOutHandler)
+	adminRoute("/users/{username}/groups", "GET", handlers.AdminListGroupsForUserHandler)
+	adminRoute("/users/{username}/groups/{group}", "PUT", handlers.AdminAddUserToGroupHandler)
+	adminRoute("/users/{username}/groups/{group}", "DELETE", handlers.AdminRemoveUserFromGroupHandler)
+	adminRoute("/groups", "GET", handlers.AdminListGroupsHandler)
+	adminRoute("/groups", "POST", handlers.AdminCreateGroupHandler)
+	adminRoute("/groups/{group}/users", "GET", handlers.AdminListUsersInGroupHandler)
+
+	r.HandleFunc("/healthz", handlers.HealthzHandler).Methods("GET")
+	r.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) { handlers.ReadyzHandler(w, r, o.readiness) }).Methods("GET")
+	r.HandleFunc("/version", handlers.VersionHandler).Methods("GET")
+
+	// OpenAPIドキュメントは登録済みのルートから生成する
+	router := r
+	r.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		handlers.OpenAPIHandler(w, r, func() ([]byte, error) { return openAPIDocument(router) })
+	}).Methods("GET")
+	if _, err := openAPIDocument(r); err != nil {
+		slog.Error("Failed to generate OpenAPI document", "error", err)
+	}
+
+	// プリフライト用のOPTIONSルートは登録済みのルートから作成するため最後に登録する
+	if o.cors != nil {
+		if err := o.cors.Register(r); err != nil {
+			slog.Error("Failed to register CORS preflight routes", "error", err)
+		}
+	}
 	return r
 }
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"bytes"
+	"cognito-lambda-handler/internal/openapi"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "testdata/openapi.jsonを更新する")
+
+// /openapi.json が登録済みのルートと一致するドキュメントを返すことを確認
+func TestOpenAPIDocument(t *testing.T) {
+	r := RegisterRoutes(nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got bytes.Buffer
+	assert.NoError(t, json.Indent(&got, rec.Body.Bytes(), "", "  "))
+	got.WriteString("\n")
+
+	golden := filepath.Join("testdata", "openapi.json")
+	if *update {
+		assert.NoError(t, os.WriteFile(golden, got.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), got.String())
+
+	// 2つ目のルーターも生成済みのドキュメントを返す
+	rec2 := httptest.NewRecorder()
+	RegisterRoutes(nil).ServeHTTP(rec2, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, rec.Body.String(), rec2.Body.String())
+}
+
+// 生成に失敗した場合はエラーを返し、次のリクエストで生成し直すことを確認
+func TestOpenAPIDocument_RetryAfterFailure(t *testing.T) {
+	reset := func() {
+		openAPIMu.Lock()
+		openAPIJSON = nil
+		openAPIMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+
+	saved := operations
+	t.Cleanup(func() { operations = saved })
+	operations = map[string]openapi.Operation{}
+	r := RegisterRoutes(nil)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	operations = saved
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}